@@ -2,14 +2,20 @@ package api
 
 import (
 	"errors"
+	"fmt"
+	"strconv"
 	"strings"
 
+	"github.com/go-skynet/LocalAI/api/backend"
 	config "github.com/go-skynet/LocalAI/api/config"
 	"github.com/go-skynet/LocalAI/api/localai"
 	"github.com/go-skynet/LocalAI/api/openai"
 	"github.com/go-skynet/LocalAI/api/options"
 	"github.com/go-skynet/LocalAI/internal"
 	"github.com/go-skynet/LocalAI/pkg/assets"
+	"github.com/go-skynet/LocalAI/pkg/grpc"
+	"github.com/go-skynet/LocalAI/pkg/jinja"
+	model "github.com/go-skynet/LocalAI/pkg/model"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
@@ -32,23 +38,7 @@ func App(opts ...options.AppOption) (*fiber.App, error) {
 		BodyLimit:             options.UploadLimitMB * 1024 * 1024, // this is the default limit of 4MB
 		DisableStartupMessage: options.DisableMessage,
 		// Override default error handler
-		ErrorHandler: func(ctx *fiber.Ctx, err error) error {
-			// Status code defaults to 500
-			code := fiber.StatusInternalServerError
-
-			// Retrieve the custom status code if it's a *fiber.Error
-			var e *fiber.Error
-			if errors.As(err, &e) {
-				code = e.Code
-			}
-
-			// Send custom error page
-			return ctx.Status(code).JSON(
-				openai.ErrorResponse{
-					Error: &openai.APIError{Message: err.Error(), Code: code},
-				},
-			)
-		},
+		ErrorHandler: errorHandler,
 	})
 
 	if options.Debug {
@@ -57,9 +47,29 @@ func App(opts ...options.AppOption) (*fiber.App, error) {
 		}))
 	}
 
+	lifecycle := newLifecycle(app, options)
+
 	log.Info().Msgf("Starting LocalAI using %d threads, with models path: %s", options.Threads, options.Loader.ModelPath)
 	log.Info().Msgf("LocalAI version: %s", internal.PrintableVersion())
 
+	options.Loader.SetMaxLoadedModels(options.MaxLoadedModels)
+	options.Loader.SetMemoryBudget(uint64(options.MemoryBudgetMB) * 1024 * 1024)
+	options.Loader.SetTCPTransport(options.BackendsTCP)
+	options.Loader.SetWatchdogTimeouts(options.WatchDogIdleTimeout, options.WatchDogBusyTimeout)
+	options.Loader.StartWatchdog(options.Context)
+	options.Loader.SetRestartPolicy(model.RestartPolicy{
+		MaxCrashes: options.BackendMaxCrashes,
+		Backoff:    options.BackendRestartBackoff,
+		Cooldown:   options.BackendCircuitCooldown,
+	})
+	options.Loader.StartSupervisor(options.Context)
+
+	for _, priority := range options.ApiKeyPriorities {
+		if _, err := backend.ParsePriority(priority); err != nil {
+			return nil, fmt.Errorf("invalid API key priority: %w", err)
+		}
+	}
+
 	cm := config.NewConfigLoader()
 	if err := cm.LoadConfigs(options.Loader.ModelPath); err != nil {
 		log.Error().Msgf("error loading config files: %s", err.Error())
@@ -71,6 +81,12 @@ func App(opts ...options.AppOption) (*fiber.App, error) {
 		}
 	}
 
+	if options.WatchConfigs {
+		if err := cm.WatchConfigs(options.Context, options.Loader.ModelPath, options.Loader.InvalidateTemplateFile); err != nil {
+			log.Warn().Msgf("Cannot watch %s for changes, call /models/reload to reload the configs: %s", options.Loader.ModelPath, err.Error())
+		}
+	}
+
 	if options.Debug {
 		for _, v := range cm.ListConfigs() {
 			cfg, _ := cm.GetConfig(v)
@@ -89,6 +105,7 @@ func App(opts ...options.AppOption) (*fiber.App, error) {
 
 	// Default middleware config
 	app.Use(recover.New())
+	app.Use(lifecycle.reject)
 
 	// Auth middleware checking if API key is valid. If no API key is set, no auth is required.
 	auth := func(c *fiber.Ctx) error {
@@ -152,6 +169,13 @@ func App(opts ...options.AppOption) (*fiber.App, error) {
 	app.Post("/models/apply", auth, localai.ApplyModelGalleryEndpoint(options.Loader.ModelPath, cm, galleryService.C, options.Galleries))
 	app.Get("/models/available", auth, localai.ListModelFromGalleryEndpoint(options.Galleries, options.Loader.ModelPath))
 	app.Get("/models/jobs/:uuid", auth, localai.GetOpStatusEndpoint(galleryService))
+	app.Post("/models/reload", auth, localai.ReloadConfigsEndpoint(cm, options))
+
+	app.Post("/backend/load", auth, localai.BackendLoadEndpoint(cm, options))
+	app.Post("/backend/unload", auth, localai.BackendUnloadEndpoint(cm, options.Loader))
+	app.Get("/backend/status", auth, localai.BackendStatusEndpoint(options.Loader))
+	app.Get("/backend/evictions", auth, localai.BackendEvictionsEndpoint(options.Loader))
+	app.Get("/backend/queues", auth, localai.BackendQueuesEndpoint())
 
 	// openAI compatible API endpoint
 
@@ -168,6 +192,10 @@ func App(opts ...options.AppOption) (*fiber.App, error) {
 	app.Post("/completions", auth, openai.CompletionEndpoint(cm, options))
 	app.Post("/v1/engines/:model/completions", auth, openai.CompletionEndpoint(cm, options))
 
+	// prompt rendering, to debug the templates
+	app.Post("/v1/prompt/render", auth, openai.PromptRenderEndpoint(cm, options))
+	app.Post("/prompt/render", auth, openai.PromptRenderEndpoint(cm, options))
+
 	// embeddings
 	app.Post("/v1/embeddings", auth, openai.EmbeddingsEndpoint(cm, options))
 	app.Post("/embeddings", auth, openai.EmbeddingsEndpoint(cm, options))
@@ -194,18 +222,57 @@ func App(opts ...options.AppOption) (*fiber.App, error) {
 
 	// Kubernetes health checks
 	app.Get("/healthz", ok)
-	app.Get("/readyz", ok)
+	app.Get("/readyz", lifecycle.ready)
 
 	// models
 	app.Get("/v1/models", auth, openai.ListModelsEndpoint(options.Loader, cm))
 	app.Get("/models", auth, openai.ListModelsEndpoint(options.Loader, cm))
 
-	// turn off any process that was started by GRPC if the context is canceled
-	go func() {
-		<-options.Context.Done()
-		log.Debug().Msgf("Context canceled, shutting down")
-		options.Loader.StopGRPC()
-	}()
-
 	return app, nil
 }
+
+// errorHandler returns the errors as JSON responses, in the OpenAI format
+func errorHandler(ctx *fiber.Ctx, err error) error {
+	// Status code defaults to 500
+	code := fiber.StatusInternalServerError
+
+	// Retrieve the custom status code if it's a *fiber.Error
+	var e *fiber.Error
+	if errors.As(err, &e) {
+		code = e.Code
+	} else if errors.Is(err, grpc.ErrNotSupported) {
+		code = fiber.StatusBadRequest
+	} else if errors.Is(err, model.ErrCircuitOpen) {
+		code = fiber.StatusServiceUnavailable
+	}
+
+	// the chat template rejected the messages
+	var raised *jinja.RaisedError
+	if errors.As(err, &raised) {
+		code = fiber.StatusBadRequest
+	}
+
+	var queueFull *backend.QueueFullError
+	if errors.As(err, &queueFull) {
+		code = fiber.StatusTooManyRequests
+		ctx.Set(fiber.HeaderRetryAfter, strconv.Itoa(queueFull.RetryAfterSeconds()))
+	}
+
+	// the model output is still invalid after the retries: the request was understood, but can't be served
+	var callErr *openai.FunctionCallError
+	if errors.As(err, &callErr) {
+		code = fiber.StatusUnprocessableEntity
+	}
+
+	apiErr := &openai.APIError{Message: err.Error(), Code: code}
+	if callErr != nil {
+		apiErr.Type = openai.InvalidFunctionCallType
+	}
+
+	// Send custom error page
+	return ctx.Status(code).JSON(
+		openai.ErrorResponse{
+			Error: apiErr,
+		},
+	)
+}
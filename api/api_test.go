@@ -12,6 +12,7 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"time"
 
 	. "github.com/go-skynet/LocalAI/api"
 	"github.com/go-skynet/LocalAI/api/options"
@@ -711,6 +712,307 @@ var _ = Describe("API test", func() {
 		})
 	})
 
+	Context("Graceful shutdown", func() {
+		var stopped chan struct{}
+
+		BeforeEach(func() {
+			var err error
+			tmpdir, err = os.MkdirTemp("", "")
+			Expect(err).ToNot(HaveOccurred())
+			modelLoader = model.NewModelLoader(tmpdir)
+			c, cancel = context.WithCancel(context.Background())
+
+			app, err = App(
+				append(commonOpts,
+					options.WithContext(c),
+					options.WithDrainTimeout(5*time.Second),
+					options.WithReadinessDelay(time.Second),
+					options.WithModelLoader(modelLoader),
+				)...)
+			Expect(err).ToNot(HaveOccurred())
+			app.Get("/slow", func(c *fiber.Ctx) error {
+				time.Sleep(500 * time.Millisecond)
+				return c.SendString("done")
+			})
+			stopped = make(chan struct{})
+			app.Hooks().OnShutdown(func() error {
+				close(stopped)
+				return nil
+			})
+			go app.Listen("127.0.0.1:9090")
+
+			Eventually(func() error {
+				_, err := http.Get("http://127.0.0.1:9090/readyz")
+				return err
+			}, "2m").ShouldNot(HaveOccurred())
+		})
+		AfterEach(func() {
+			cancel()
+			Eventually(stopped, "10s").Should(BeClosed())
+			os.RemoveAll(tmpdir)
+		})
+
+		It("lets the running requests complete", func() {
+			type result struct {
+				status int
+				body   string
+				err    error
+			}
+			results := make(chan result, 1)
+			go func() {
+				resp, err := http.Get("http://127.0.0.1:9090/slow")
+				if err != nil {
+					results <- result{err: err}
+					return
+				}
+				defer resp.Body.Close()
+				body, err := io.ReadAll(resp.Body)
+				results <- result{status: resp.StatusCode, body: string(body), err: err}
+			}()
+
+			time.Sleep(100 * time.Millisecond)
+			cancel()
+
+			var r result
+			Eventually(results, "5s").Should(Receive(&r))
+			Expect(r.err).ToNot(HaveOccurred())
+			Expect(r.status).To(Equal(200))
+			Expect(r.body).To(Equal("done"))
+
+			Eventually(stopped, "5s").Should(BeClosed())
+			_, err := http.Get("http://127.0.0.1:9090/readyz")
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("reports not ready while still serving the requests, before closing the listener", func() {
+			cancel()
+
+			// new connections are still accepted during the readiness delay
+			client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+			Eventually(func() int {
+				resp, err := client.Get("http://127.0.0.1:9090/readyz")
+				Expect(err).ToNot(HaveOccurred())
+				resp.Body.Close()
+				return resp.StatusCode
+			}).Should(Equal(503))
+			resp, err := client.Get("http://127.0.0.1:9090/slow")
+			Expect(err).ToNot(HaveOccurred())
+			resp.Body.Close()
+			Expect(resp.StatusCode).To(Equal(200))
+
+			Eventually(stopped, "5s").Should(BeClosed())
+			_, err = client.Get("http://127.0.0.1:9090/readyz")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("Prompt rendering", func() {
+		BeforeEach(func() {
+			var err error
+			tmpdir, err = os.MkdirTemp("", "")
+			Expect(err).ToNot(HaveOccurred())
+			modelLoader = model.NewModelLoader(tmpdir)
+			c, cancel = context.WithCancel(context.Background())
+
+			Expect(os.WriteFile(filepath.Join(tmpdir, "chatml.yaml"), []byte(`name: chatml
+parameters:
+  model: model.bin
+  temperature: 0.3
+template:
+  chat_template: "{% for m in messages %}<|im_start|>{{ m.role }}\n{{ m.content }}<|im_end|>\n{% endfor %}<|im_start|>assistant\n"
+  completion: completion
+`), 0644)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(tmpdir, "completion.tmpl"), []byte("Q: {{ .Input }}\nA:"), 0644)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(tmpdir, "broken.yaml"), []byte(`name: broken
+parameters:
+  model: model.bin
+template:
+  chat: failing
+  completion: unparsable
+`), 0644)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(tmpdir, "failing.tmpl"), []byte("{{ .Missing }}"), 0644)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(tmpdir, "unparsable.tmpl"), []byte("Q: {{ .Input "), 0644)).To(Succeed())
+
+			app, err = App(
+				append(commonOpts,
+					options.WithContext(c),
+					options.WithModelLoader(modelLoader),
+				)...)
+			Expect(err).ToNot(HaveOccurred())
+			go app.Listen("127.0.0.1:9090")
+
+			Eventually(func() error {
+				_, err := http.Get("http://127.0.0.1:9090/readyz")
+				return err
+			}, "2m").ShouldNot(HaveOccurred())
+		})
+		AfterEach(func() {
+			cancel()
+			app.Shutdown()
+			os.RemoveAll(tmpdir)
+		})
+
+		render := func(query string, request map[string]interface{}) (int, map[string]interface{}) {
+			body, err := json.Marshal(request)
+			Expect(err).ToNot(HaveOccurred())
+			resp, err := http.Post("http://127.0.0.1:9090/v1/prompt/render"+query, "application/json", bytes.NewReader(body))
+			Expect(err).ToNot(HaveOccurred())
+			defer resp.Body.Close()
+			var res map[string]interface{}
+			Expect(json.NewDecoder(resp.Body).Decode(&res)).To(Succeed())
+			return resp.StatusCode, res
+		}
+
+		It("renders chat prompts with the chat template", func() {
+			status, res := render("", map[string]interface{}{
+				"model":    "chatml",
+				"messages": []map[string]string{{"role": "user", "content": "Hi"}},
+			})
+			Expect(status).To(Equal(200))
+			Expect(res["type"]).To(Equal("chat"))
+			Expect(res["prompts"]).To(Equal([]interface{}{"<|im_start|>user\nHi<|im_end|>\n<|im_start|>assistant\n"}))
+			Expect(res["template"]).To(Equal("inline"))
+			Expect(res["config"]).To(HaveKeyWithValue("name", "chatml"))
+			Expect(res["config"]).To(HaveKeyWithValue("parameters", HaveKeyWithValue("temperature", 0.3)))
+		})
+
+		It("renders completion prompts with the completion template", func() {
+			status, res := render("", map[string]interface{}{"model": "chatml", "prompt": "2+2?"})
+			Expect(status).To(Equal(200))
+			Expect(res["type"]).To(Equal("completion"))
+			Expect(res["prompts"]).To(Equal([]interface{}{"Q: 2+2?\nA:"}))
+			Expect(res["template"]).To(Equal("completion.tmpl"))
+		})
+
+		It("returns the grammar of the functions", func() {
+			status, res := render("?type=chat", map[string]interface{}{
+				"model":    "chatml",
+				"messages": []map[string]string{{"role": "user", "content": "Weather in Rome?"}},
+				"functions": []map[string]interface{}{{
+					"name":       "get_weather",
+					"parameters": map[string]interface{}{"type": "object", "properties": map[string]interface{}{"city": map[string]string{"type": "string"}}},
+				}},
+			})
+			Expect(status).To(Equal(200))
+			Expect(res["grammar"]).To(ContainSubstring("get_weather"))
+		})
+
+		It("rejects the functions whose parameters don't convert to a valid grammar", func() {
+			status, res := render("?type=chat", map[string]interface{}{
+				"model":    "chatml",
+				"messages": []map[string]string{{"role": "user", "content": "Weather in Rome?"}},
+				"tools": []map[string]interface{}{{
+					"type": "function",
+					"function": map[string]interface{}{
+						"name":       "get_weather",
+						"parameters": map[string]interface{}{"type": "object", "properties": map[string]interface{}{"city": map[string]string{"$ref": "#/properties/city"}}},
+					},
+				}},
+			})
+			Expect(status).To(Equal(400))
+			Expect(res["error"]).To(HaveKeyWithValue("message", ContainSubstring(`invalid tools: function "get_weather": `)))
+		})
+
+		It("returns the grammar of the tools, allowing parallel calls", func() {
+			tools := []map[string]interface{}{{
+				"type": "function",
+				"function": map[string]interface{}{
+					"name":       "get_weather",
+					"parameters": map[string]interface{}{"type": "object", "properties": map[string]interface{}{"city": map[string]string{"type": "string"}}},
+				},
+			}}
+			messages := []map[string]interface{}{
+				{"role": "user", "content": "Weather in Rome and Paris?"},
+				{"role": "assistant", "content": nil, "tool_calls": []map[string]interface{}{{
+					"id": "call_1", "type": "function", "function": map[string]string{"name": "get_weather", "arguments": `{"city":"Rome"}`},
+				}}},
+				{"role": "tool", "tool_call_id": "call_1", "content": "sunny"},
+			}
+
+			status, res := render("?type=chat", map[string]interface{}{"model": "chatml", "messages": messages, "tools": tools})
+			Expect(status).To(Equal(200))
+			Expect(res["prompts"]).To(Equal([]interface{}{"<|im_start|>user\nWeather in Rome and Paris?<|im_end|>\n<|im_start|>assistant\n<|im_end|>\n<|im_start|>tool\nsunny<|im_end|>\n<|im_start|>assistant\n"}))
+			Expect(res["grammar"]).To(ContainSubstring(`root ::= "[" space call ("," space call)* "]" space | call`))
+			Expect(res["grammar"]).To(ContainSubstring("answer"))
+
+			status, res = render("?type=chat", map[string]interface{}{"model": "chatml", "messages": messages, "tools": tools, "tool_choice": "required", "parallel_tool_calls": false})
+			Expect(status).To(Equal(200))
+			Expect(res["grammar"]).To(ContainSubstring("get_weather"))
+			Expect(res["grammar"]).ToNot(ContainSubstring("answer"))
+			Expect(res["grammar"]).ToNot(ContainSubstring(`"["`))
+		})
+
+		It("returns the grammar of the response format", func() {
+			status, res := render("", map[string]interface{}{
+				"model":    "chatml",
+				"messages": []map[string]string{{"role": "user", "content": "Capital of Italy?"}},
+				"response_format": map[string]interface{}{
+					"type": "json_schema",
+					"json_schema": map[string]interface{}{
+						"name":   "capital",
+						"schema": map[string]interface{}{"type": "object", "properties": map[string]interface{}{"capital": map[string]string{"type": "string"}}, "required": []string{"capital"}},
+					},
+				},
+			})
+			Expect(status).To(Equal(200))
+			Expect(res["grammar"]).To(ContainSubstring(`root ::= "{" space capital-kv "}" space`))
+
+			status, res = render("", map[string]interface{}{"model": "chatml", "prompt": "x", "response_format": map[string]string{"type": "json_schema"}})
+			Expect(status).To(Equal(400))
+			Expect(res["error"]).To(HaveKeyWithValue("message", ContainSubstring("requires a schema")))
+		})
+
+		It("rejects invalid grammars", func() {
+			status, res := render("", map[string]interface{}{"model": "chatml", "prompt": "x", "grammar": "root ::= answer\nanswer ::= \"yes\" | \"no"})
+			Expect(status).To(Equal(400))
+			Expect(res["error"]).To(HaveKeyWithValue("message", ContainSubstring("invalid grammar: line 2, column 20: unterminated literal")))
+
+			status, res = render("", map[string]interface{}{"model": "chatml", "prompt": "x", "grammar": `root ::= "yes" | "no"`})
+			Expect(status).To(Equal(200))
+			Expect(res["grammar"]).To(Equal(`root ::= "yes" | "no"`))
+		})
+
+		It("returns the grammar of the regex or of the choices", func() {
+			status, res := render("", map[string]interface{}{"model": "chatml", "prompt": "x", "regex": `[0-9]+ (apples|pears)`})
+			Expect(status).To(Equal(200))
+			Expect(res["grammar"]).To(Equal(`root ::= [0-9]+ " " ("apples" | "pears")`))
+
+			status, res = render("", map[string]interface{}{"model": "chatml", "prompt": "x", "choices": []string{"yes", "no"}})
+			Expect(status).To(Equal(200))
+			Expect(res["grammar"]).To(Equal(`root ::= "yes" | "no"`))
+
+			status, res = render("", map[string]interface{}{"model": "chatml", "prompt": "x", "regex": "[0-9", "choices": []string{"yes"}})
+			Expect(status).To(Equal(400))
+			Expect(res["error"]).To(HaveKeyWithValue("message", ContainSubstring("got regex, choices")))
+		})
+
+		It("reports the errors of the templates along with the inputs as is", func() {
+			status, res := render("", map[string]interface{}{"model": "broken", "prompt": "2+2?"})
+			Expect(status).To(Equal(200))
+			Expect(res["prompts"]).To(Equal([]interface{}{"2+2?"}))
+			Expect(res["template"]).To(Equal("unparsable.tmpl"))
+			Expect(res["template_error"]).To(ContainSubstring("unclosed action"))
+
+			status, res = render("", map[string]interface{}{
+				"model":    "broken",
+				"messages": []map[string]string{{"role": "user", "content": "Hi"}},
+			})
+			Expect(status).To(Equal(200))
+			Expect(res["prompts"]).To(Equal([]interface{}{"Hi"}))
+			Expect(res["template"]).To(Equal("failing.tmpl"))
+			Expect(res["template_error"]).To(ContainSubstring("can't evaluate field Missing"))
+
+			status, res = render("", map[string]interface{}{"model": "chatml", "prompt": "2+2?"})
+			Expect(status).To(Equal(200))
+			Expect(res).ToNot(HaveKey("template_error"))
+		})
+
+		It("rejects unknown request types", func() {
+			status, _ := render("?type=image", map[string]interface{}{"model": "chatml", "prompt": "x"})
+			Expect(status).To(Equal(400))
+		})
+	})
+
 	Context("Config file", func() {
 		BeforeEach(func() {
 			modelLoader = model.NewModelLoader(os.Getenv("MODELS_PATH"))
@@ -1,15 +1,16 @@
 package backend
 
 import (
-	"sync"
+	"context"
 
 	config "github.com/go-skynet/LocalAI/api/config"
 	"github.com/go-skynet/LocalAI/api/options"
+	"github.com/go-skynet/LocalAI/pkg/grpc"
 	"github.com/go-skynet/LocalAI/pkg/grpc/proto"
 	model "github.com/go-skynet/LocalAI/pkg/model"
 )
 
-func ImageGeneration(height, width, mode, step, seed int, positive_prompt, negative_prompt, src, dst string, loader *model.ModelLoader, c config.Config, o *options.Option) (func() error, error) {
+func ImageGeneration(ctx context.Context, height, width, mode, step, seed int, positive_prompt, negative_prompt, src, dst string, loader *model.ModelLoader, c config.Config, o *options.Option) (func() error, error) {
 
 	opts := []model.Option{
 		model.WithBackendString(c.Backend),
@@ -17,6 +18,7 @@ func ImageGeneration(height, width, mode, step, seed int, positive_prompt, negat
 		model.WithThreads(uint32(c.Threads)),
 		model.WithContext(o.Context),
 		model.WithModel(c.Model),
+		model.WithCapability(grpc.CapabilityGenerateImage),
 		model.WithLoadGRPCLoadModelOpts(&proto.ModelOptions{
 			CUDA:          c.Diffusers.CUDA,
 			SchedulerType: c.Diffusers.SchedulerType,
@@ -41,6 +43,8 @@ func ImageGeneration(height, width, mode, step, seed int, positive_prompt, negat
 		opts = append(opts, model.WithExternalBackend(k, v))
 	}
 
+	opts = append(opts, watchdogOpts(c)...)
+
 	inferenceModel, err := loader.BackendLoader(
 		opts...,
 	)
@@ -48,9 +52,13 @@ func ImageGeneration(height, width, mode, step, seed int, positive_prompt, negat
 		return nil, err
 	}
 
+	if err := checkCapability(inferenceModel, c.Model, grpc.CapabilityGenerateImage); err != nil {
+		return nil, err
+	}
+
 	fn := func() error {
 		_, err := inferenceModel.GenerateImage(
-			o.Context,
+			ctx,
 			&proto.GenerateImageRequest{
 				Height:           int32(height),
 				Width:            int32(width),
@@ -68,17 +76,13 @@ func ImageGeneration(height, width, mode, step, seed int, positive_prompt, negat
 	}
 
 	return func() error {
-		// This is still needed, see: https://github.com/ggerganov/llama.cpp/discussions/784
-		mutexMap.Lock()
-		l, ok := mutexes[c.Backend]
-		if !ok {
-			m := &sync.Mutex{}
-			mutexes[c.Backend] = m
-			l = m
+		release, err := acquireSlot(ctx, c.Model, c, o)
+		if err != nil {
+			return err
 		}
-		mutexMap.Unlock()
-		l.Lock()
-		defer l.Unlock()
+		defer release()
+
+		defer markBusy(loader, c.Model)()
 
 		return fn()
 	}, nil
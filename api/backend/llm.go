@@ -2,6 +2,7 @@ package backend
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"regexp"
 	"strings"
@@ -18,34 +19,10 @@ import (
 func ModelInference(ctx context.Context, s string, loader *model.ModelLoader, c config.Config, o *options.Option, tokenCallback func(string) bool) (func() (string, error), error) {
 	modelFile := c.Model
 
-	grpcOpts := gRPCModelOpts(c)
-
 	var inferenceModel *grpc.Client
 	var err error
 
-	opts := []model.Option{
-		model.WithLoadGRPCLoadModelOpts(grpcOpts),
-		model.WithThreads(uint32(c.Threads)), // some models uses this to allocate threads during startup
-		model.WithAssetDir(o.AssetsDestination),
-		model.WithModel(modelFile),
-		model.WithContext(o.Context),
-	}
-
-	if c.GRPC.Attempts != 0 {
-		opts = append(opts, model.WithGRPCAttempts(c.GRPC.Attempts))
-	}
-
-	if c.GRPC.AttemptsSleepTime != 0 {
-		opts = append(opts, model.WithGRPCAttemptsDelay(c.GRPC.AttemptsSleepTime))
-	}
-
-	for k, v := range o.ExternalGRPCBackends {
-		opts = append(opts, model.WithExternalBackend(k, v))
-	}
-
-	if c.Backend != "" {
-		opts = append(opts, model.WithBackendString(c.Backend))
-	}
+	opts := llmModelOpts(c, o)
 
 	// Check if the modelFile exists, if it doesn't try to load it from the gallery
 	if o.AutoloadGalleries { // experimental
@@ -59,15 +36,20 @@ func ModelInference(ctx context.Context, s string, loader *model.ModelLoader, c
 		}
 	}
 
-	if c.Backend == "" {
-		inferenceModel, err = loader.GreedyLoader(opts...)
-	} else {
-		inferenceModel, err = loader.BackendLoader(opts...)
+	capability := grpc.CapabilityPredict
+	if tokenCallback != nil {
+		capability = grpc.CapabilityPredictStream
 	}
 
+	inferenceModel, err = loadModel(c, loader, opts, capability)
 	if err != nil {
 		return nil, err
 	}
+	if c.Grammar != "" && c.StrictGrammar() {
+		if err := checkCapability(inferenceModel, modelFile, grpc.CapabilityGrammar); err != nil {
+			return nil, fmt.Errorf("the output can't be constrained to the requested format: %w", err)
+		}
+	}
 
 	// in GRPC, the backend is supposed to answer to 1 single token if stream is not supported
 	fn := func() (string, error) {
@@ -75,10 +57,14 @@ func ModelInference(ctx context.Context, s string, loader *model.ModelLoader, c
 		opts.Prompt = s
 		if tokenCallback != nil {
 			ss := ""
-			err := inferenceModel.PredictStream(ctx, opts, func(s []byte) {
-				tokenCallback(string(s))
+			err := inferenceModel.PredictStream(ctx, opts, func(s []byte) bool {
 				ss += string(s)
+				return tokenCallback(string(s))
 			})
+			if err == nil {
+				// the callback stops the stream early once the request is canceled
+				err = ctx.Err()
+			}
 			return ss, err
 		} else {
 			reply, err := inferenceModel.Predict(ctx, opts)
@@ -90,22 +76,84 @@ func ModelInference(ctx context.Context, s string, loader *model.ModelLoader, c
 	}
 
 	return func() (string, error) {
-		// This is still needed, see: https://github.com/ggerganov/llama.cpp/discussions/784
-		mutexMap.Lock()
-		l, ok := mutexes[modelFile]
-		if !ok {
-			m := &sync.Mutex{}
-			mutexes[modelFile] = m
-			l = m
+		release, err := acquireSlot(ctx, modelFile, c, o)
+		if err != nil {
+			return "", err
 		}
-		mutexMap.Unlock()
-		l.Lock()
-		defer l.Unlock()
+		defer release()
+
+		defer markBusy(loader, c.Model)()
 
 		return fn()
 	}, nil
 }
 
+// ModelLoad loads the model described by the config in memory, without running any inference.
+// It can be used to warm up a model before it receives traffic.
+func ModelLoad(loader *model.ModelLoader, c config.Config, o *options.Option) error {
+	_, err := loadModel(c, loader, llmModelOpts(c, o), "")
+	return err
+}
+
+// llmModelOpts returns the options to load a language or embedding model as described by the config
+func llmModelOpts(c config.Config, o *options.Option) []model.Option {
+	opts := []model.Option{
+		model.WithLoadGRPCLoadModelOpts(gRPCModelOpts(c)),
+		model.WithThreads(uint32(c.Threads)), // some models uses this to allocate threads during startup
+		model.WithAssetDir(o.AssetsDestination),
+		model.WithModel(c.Model),
+		model.WithContext(o.Context),
+	}
+
+	if c.GRPC.Attempts != 0 {
+		opts = append(opts, model.WithGRPCAttempts(c.GRPC.Attempts))
+	}
+
+	if c.GRPC.AttemptsSleepTime != 0 {
+		opts = append(opts, model.WithGRPCAttemptsDelay(c.GRPC.AttemptsSleepTime))
+	}
+
+	for k, v := range o.ExternalGRPCBackends {
+		opts = append(opts, model.WithExternalBackend(k, v))
+	}
+
+	opts = append(opts, watchdogOpts(c)...)
+
+	if c.Backend != "" {
+		opts = append(opts, model.WithBackendString(c.Backend))
+	}
+
+	return opts
+}
+
+// loadModel loads the model with the backend set in the config, or tries all the available ones if none is set.
+// If a capability is given, only backends implementing it are considered.
+func loadModel(c config.Config, loader *model.ModelLoader, opts []model.Option, capability grpc.Capability) (*grpc.Client, error) {
+	var client *grpc.Client
+	var err error
+
+	opts = append(opts, model.WithCapability(capability))
+	if c.Backend == "" {
+		client, err = loader.GreedyLoader(opts...)
+	} else {
+		client, err = loader.BackendLoader(opts...)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return client, checkCapability(client, c.Model, capability)
+}
+
+// checkCapability returns an error if the backend serving the model doesn't implement the operation.
+// This can happen if the model was already loaded by a backend for a different kind of request.
+func checkCapability(client *grpc.Client, modelFile string, capability grpc.Capability) error {
+	if capability == "" || client.Supports(context.Background(), capability) {
+		return nil
+	}
+	return fmt.Errorf("model %s: %w", modelFile, grpc.NotSupportedError("", capability))
+}
+
 var cutstrings map[string]*regexp.Regexp = make(map[string]*regexp.Regexp)
 var mu sync.Mutex = sync.Mutex{}
 
@@ -3,8 +3,11 @@ package backend
 import (
 	"os"
 	"path/filepath"
+	"time"
 
 	pb "github.com/go-skynet/LocalAI/pkg/grpc/proto"
+	model "github.com/go-skynet/LocalAI/pkg/model"
+	"github.com/rs/zerolog/log"
 
 	config "github.com/go-skynet/LocalAI/api/config"
 )
@@ -83,3 +86,31 @@ func gRPCPredictOpts(c config.Config, modelPath string) *pb.PredictOptions {
 		TypicalP:            float32(c.TypicalP),
 	}
 }
+
+// watchdogOpts returns the model options to override the watchdog timeouts as set in the model config
+func watchdogOpts(c config.Config) []model.Option {
+	opts := []model.Option{}
+	if c.IdleTimeout != "" {
+		d, err := time.ParseDuration(c.IdleTimeout)
+		if err != nil {
+			log.Warn().Msgf("invalid idle_timeout for model %s: %s", c.Name, err.Error())
+		} else {
+			opts = append(opts, model.WithIdleTimeout(d))
+		}
+	}
+	if c.BusyTimeout != "" {
+		d, err := time.ParseDuration(c.BusyTimeout)
+		if err != nil {
+			log.Warn().Msgf("invalid busy_timeout for model %s: %s", c.Name, err.Error())
+		} else {
+			opts = append(opts, model.WithBusyTimeout(d))
+		}
+	}
+	return opts
+}
+
+// markBusy marks the model as serving a request, for the watchdog, until the returned function is called.
+// The loader tracks the models by the name given to model.WithModel, which must be the one passed here.
+func markBusy(loader *model.ModelLoader, modelName string) (idle func()) {
+	return loader.MarkBusy(modelName)
+}
@@ -0,0 +1,78 @@
+package backend
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// PriorityHeader is the request header clients can set to choose the priority of their request
+const PriorityHeader = "X-Request-Priority"
+
+// Priority is the class of a request in the model queues: higher priority requests are served first
+type Priority int
+
+const (
+	PriorityLow Priority = iota
+	PriorityNormal
+	PriorityHigh
+)
+
+func (p Priority) String() string {
+	switch p {
+	case PriorityLow:
+		return "low"
+	case PriorityHigh:
+		return "high"
+	}
+	return "normal"
+}
+
+// ParsePriority parses a priority class name (low, normal or high)
+func ParsePriority(s string) (Priority, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "low":
+		return PriorityLow, nil
+	case "", "normal":
+		return PriorityNormal, nil
+	case "high":
+		return PriorityHigh, nil
+	}
+	return PriorityNormal, fmt.Errorf("invalid priority %q: must be one of low, normal or high", s)
+}
+
+// RequestPriority returns the priority of a request, from the priority header and the priority assigned to its API key (if any).
+// The header can lower the priority of the API key, but not raise it: without a priority assigned to the key,
+// the requests can't be raised above normal, so that only the keys allowed to can jump the queues.
+func RequestPriority(header, apiKey string, keyPriorities map[string]string) (Priority, error) {
+	p, err := ParsePriority(header)
+	if err != nil {
+		return p, err
+	}
+
+	max := PriorityNormal
+	if kp, ok := keyPriorities[apiKey]; ok {
+		if max, err = ParsePriority(kp); err != nil {
+			return p, err
+		}
+	}
+	if header == "" || p > max {
+		p = max
+	}
+	return p, nil
+}
+
+type priorityKey struct{}
+
+// WithPriority returns a context carrying the priority of the request
+func WithPriority(ctx context.Context, p Priority) context.Context {
+	return context.WithValue(ctx, priorityKey{}, p)
+}
+
+// PriorityFromContext returns the priority of the request, normal if none was set
+func PriorityFromContext(ctx context.Context) Priority {
+	if p, ok := ctx.Value(priorityKey{}).(Priority); ok {
+		return p
+	}
+	return PriorityNormal
+}
@@ -0,0 +1,302 @@
+package backend
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	config "github.com/go-skynet/LocalAI/api/config"
+	"github.com/go-skynet/LocalAI/api/options"
+)
+
+// QueueFullError is returned when a request is rejected because too many requests are already waiting for the model
+type QueueFullError struct {
+	Model      string
+	Queued     int
+	RetryAfter time.Duration
+}
+
+func (e *QueueFullError) Error() string {
+	return fmt.Sprintf("too many requests queued for model %s (%d waiting), retry after %s", e.Model, e.Queued, e.RetryAfter)
+}
+
+// RetryAfterSeconds returns the value of the Retry-After header to send along the error
+func (e *QueueFullError) RetryAfterSeconds() int {
+	return int(math.Ceil(e.RetryAfter.Seconds()))
+}
+
+// QueueStatus describes the requests running and waiting for a model
+type QueueStatus struct {
+	Model         string `json:"model"`
+	Parallel      int    `json:"parallel_requests"`
+	MaxQueueDepth int    `json:"max_queue_depth"`
+	Active        int    `json:"active"`
+	Queued        int    `json:"queued"`
+	// QueuedByPriority counts the waiting requests of each priority class
+	QueuedByPriority map[string]int `json:"queued_by_priority"`
+	Requests         uint64         `json:"requests"`
+	Rejected         uint64         `json:"rejected"`
+	AvgWaitMs        float64        `json:"avg_wait_ms"`
+	MaxWaitMs        float64        `json:"max_wait_ms"`
+	LastWaitMs       float64        `json:"last_wait_ms"`
+}
+
+// serviceTimeWeight is the weight of the last request in the moving average of the time requests take to be served
+const serviceTimeWeight = 0.2
+
+// defaultPriorityAging is how long a request waits before being promoted to the next priority class
+const defaultPriorityAging = 10 * time.Second
+
+type waiter struct {
+	ready    chan struct{}
+	priority Priority
+	enqueued time.Time
+}
+
+// effectivePriority raises the priority of the requests that waited for long,
+// so that low priority requests are eventually served even under a steady flow of high priority ones
+func (w *waiter) effectivePriority(now time.Time, aging time.Duration) Priority {
+	if aging <= 0 {
+		return w.priority
+	}
+	return w.priority + Priority(now.Sub(w.enqueued)/aging)
+}
+
+// modelQueue lets up to parallel requests run at the same time for a model, queuing the others.
+// Waiting requests are served by priority, then in arrival order.
+type modelQueue struct {
+	sync.Mutex
+	parallel int
+	maxQueue int
+	aging    time.Duration
+	active   int
+	waiting  []*waiter
+
+	requests, rejected     uint64
+	totalWait, maxWait     time.Duration
+	lastWait, avgService   time.Duration
+	hasServiceTimeEstimate bool
+}
+
+var (
+	queuesMu sync.Mutex
+	queues   = map[string]*modelQueue{}
+)
+
+// queueLimits returns the number of parallel requests and the maximum queue depth for the model
+func queueLimits(c config.Config, o *options.Option) (int, int) {
+	parallel, maxQueue := c.ParallelRequests, c.MaxQueueDepth
+	if parallel == 0 {
+		parallel = o.ParallelRequests
+	}
+	if maxQueue == 0 {
+		maxQueue = o.MaxQueueDepth
+	}
+	// By default, one request at a time is served.
+	// This is still needed, see: https://github.com/ggerganov/llama.cpp/discussions/784
+	if parallel <= 0 {
+		parallel = 1
+	}
+	return parallel, maxQueue
+}
+
+func getQueue(key string, parallel, maxQueue int, aging time.Duration) *modelQueue {
+	queuesMu.Lock()
+	q, ok := queues[key]
+	if !ok {
+		q = &modelQueue{}
+		queues[key] = q
+	}
+	queuesMu.Unlock()
+
+	q.Lock()
+	q.parallel, q.maxQueue, q.aging = parallel, maxQueue, aging
+	// the limits might have been raised: let the waiting requests in
+	q.dispatch()
+	q.Unlock()
+	return q
+}
+
+func priorityAging(o *options.Option) time.Duration {
+	if o.PriorityAging != 0 {
+		return o.PriorityAging
+	}
+	return defaultPriorityAging
+}
+
+// acquireSlot waits for a free slot to run a request for the model. The returned function must be called to release it.
+// The request is queued with the priority carried by the context, see WithPriority.
+// If too many requests are already waiting, it fails immediately with a QueueFullError.
+func acquireSlot(ctx context.Context, key string, c config.Config, o *options.Option) (func(), error) {
+	parallel, maxQueue := queueLimits(c, o)
+	return getQueue(key, parallel, maxQueue, priorityAging(o)).acquire(ctx, key, PriorityFromContext(ctx))
+}
+
+// CheckQueue returns a QueueFullError if a request for the model would be rejected right now.
+// It is meant for streaming requests, which can't return an error status once the response started.
+func CheckQueue(c config.Config, o *options.Option) error {
+	parallel, maxQueue := queueLimits(c, o)
+	q := getQueue(c.Model, parallel, maxQueue, priorityAging(o))
+	q.Lock()
+	defer q.Unlock()
+	if q.full() {
+		return &QueueFullError{Model: c.Model, Queued: len(q.waiting), RetryAfter: q.retryAfter()}
+	}
+	return nil
+}
+
+func (q *modelQueue) acquire(ctx context.Context, key string, priority Priority) (func(), error) {
+	start := time.Now()
+
+	q.Lock()
+	if q.full() {
+		q.rejected++
+		err := &QueueFullError{Model: key, Queued: len(q.waiting), RetryAfter: q.retryAfter()}
+		q.Unlock()
+		return nil, err
+	}
+	w := &waiter{ready: make(chan struct{}), priority: priority, enqueued: start}
+	q.waiting = append(q.waiting, w)
+	q.dispatch()
+	q.Unlock()
+
+	select {
+	case <-w.ready:
+	case <-ctx.Done():
+		q.Lock()
+		select {
+		case <-w.ready:
+			// the slot was granted in the meantime: give it back
+			q.active--
+			q.dispatch()
+		default:
+			q.remove(w)
+		}
+		q.Unlock()
+		return nil, ctx.Err()
+	}
+
+	served := time.Now()
+	q.Lock()
+	q.recordWait(served.Sub(start))
+	q.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			q.Lock()
+			defer q.Unlock()
+			q.recordService(time.Since(served))
+			q.active--
+			q.dispatch()
+		})
+	}, nil
+}
+
+// full must be called with the lock held
+func (q *modelQueue) full() bool {
+	if q.maxQueue <= 0 || q.active < q.parallel {
+		return false
+	}
+	return len(q.waiting) >= q.maxQueue
+}
+
+// dispatch grants the free slots to the waiting requests. It must be called with the lock held.
+func (q *modelQueue) dispatch() {
+	now := time.Now()
+	for q.active < q.parallel && len(q.waiting) > 0 {
+		// waiting is in arrival order: the first request with the highest priority goes next
+		next := 0
+		best := q.waiting[0].effectivePriority(now, q.aging)
+		for i, w := range q.waiting[1:] {
+			if p := w.effectivePriority(now, q.aging); p > best {
+				next, best = i+1, p
+			}
+		}
+		w := q.waiting[next]
+		q.waiting = append(q.waiting[:next], q.waiting[next+1:]...)
+		q.active++
+		close(w.ready)
+	}
+}
+
+// remove must be called with the lock held
+func (q *modelQueue) remove(w *waiter) {
+	for i, ww := range q.waiting {
+		if ww == w {
+			q.waiting = append(q.waiting[:i], q.waiting[i+1:]...)
+			return
+		}
+	}
+}
+
+func (q *modelQueue) recordWait(d time.Duration) {
+	q.requests++
+	q.totalWait += d
+	q.lastWait = d
+	if d > q.maxWait {
+		q.maxWait = d
+	}
+}
+
+func (q *modelQueue) recordService(d time.Duration) {
+	if !q.hasServiceTimeEstimate {
+		q.avgService, q.hasServiceTimeEstimate = d, true
+		return
+	}
+	q.avgService = time.Duration(serviceTimeWeight*float64(d) + (1-serviceTimeWeight)*float64(q.avgService))
+}
+
+// retryAfter estimates when a slot will be available for a new request, from the time requests usually take.
+// It must be called with the lock held.
+func (q *modelQueue) retryAfter() time.Duration {
+	d := time.Duration(float64(q.avgService) * float64(len(q.waiting)+1) / float64(q.parallel))
+	if d < time.Second {
+		return time.Second
+	}
+	return d
+}
+
+func (q *modelQueue) status(model string) QueueStatus {
+	q.Lock()
+	defer q.Unlock()
+	st := QueueStatus{
+		Model:            model,
+		Parallel:         q.parallel,
+		MaxQueueDepth:    q.maxQueue,
+		Active:           q.active,
+		Queued:           len(q.waiting),
+		QueuedByPriority: map[string]int{},
+		Requests:         q.requests,
+		Rejected:         q.rejected,
+		MaxWaitMs:        milliseconds(q.maxWait),
+		LastWaitMs:       milliseconds(q.lastWait),
+	}
+	for _, w := range q.waiting {
+		st.QueuedByPriority[w.priority.String()]++
+	}
+	if q.requests > 0 {
+		st.AvgWaitMs = milliseconds(q.totalWait) / float64(q.requests)
+	}
+	return st
+}
+
+// QueuesStatus returns the state of the request queues of all the models that received requests
+func QueuesStatus() []QueueStatus {
+	queuesMu.Lock()
+	res := make([]QueueStatus, 0, len(queues))
+	for m, q := range queues {
+		res = append(res, q.status(m))
+	}
+	queuesMu.Unlock()
+
+	sort.Slice(res, func(i, j int) bool { return res[i].Model < res[j].Model })
+	return res
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
@@ -0,0 +1,162 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	config "github.com/go-skynet/LocalAI/api/config"
+	"github.com/go-skynet/LocalAI/api/options"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Request scheduler", func() {
+	var o *options.Option
+	var c config.Config
+
+	BeforeEach(func() {
+		o = options.NewOptions()
+		c = config.Config{}
+		c.Model = CurrentSpecReport().FullText()
+	})
+
+	acquireAsync := func(ctx context.Context) chan func() {
+		granted := make(chan func(), 1)
+		go func() {
+			release, err := acquireSlot(ctx, c.Model, c, o)
+			if err == nil {
+				granted <- release
+			}
+		}()
+		return granted
+	}
+
+	It("serves one request at a time by default", func() {
+		release, err := acquireSlot(context.Background(), c.Model, c, o)
+		Expect(err).ToNot(HaveOccurred())
+
+		second := acquireAsync(context.Background())
+		Consistently(second, 50*time.Millisecond).ShouldNot(Receive())
+
+		release()
+		var releaseSecond func()
+		Eventually(second).Should(Receive(&releaseSecond))
+		releaseSecond()
+
+		st := getQueue(c.Model, 1, 0, 0).status(c.Model)
+		Expect(st.Requests).To(Equal(uint64(2)))
+		Expect(st.Active).To(Equal(0))
+		Expect(st.MaxWaitMs).To(BeNumerically(">=", 50))
+	})
+
+	It("serves parallel requests", func() {
+		c.ParallelRequests = 2
+		first, err := acquireSlot(context.Background(), c.Model, c, o)
+		Expect(err).ToNot(HaveOccurred())
+		second, err := acquireSlot(context.Background(), c.Model, c, o)
+		Expect(err).ToNot(HaveOccurred())
+		first()
+		second()
+	})
+
+	It("rejects requests when the queue is full", func() {
+		c.MaxQueueDepth = 1
+		release, err := acquireSlot(context.Background(), c.Model, c, o)
+		Expect(err).ToNot(HaveOccurred())
+
+		queued := acquireAsync(context.Background())
+		Eventually(func() int { return getQueue(c.Model, 1, 1, 0).status(c.Model).Queued }).Should(Equal(1))
+
+		_, err = acquireSlot(context.Background(), c.Model, c, o)
+		var queueFull *QueueFullError
+		Expect(errors.As(err, &queueFull)).To(BeTrue())
+		Expect(queueFull.RetryAfterSeconds()).To(BeNumerically(">=", 1))
+		Expect(CheckQueue(c, o)).To(HaveOccurred())
+		Expect(getQueue(c.Model, 1, 1, 0).status(c.Model).Rejected).To(Equal(uint64(1)))
+
+		release()
+		var releaseQueued func()
+		Eventually(queued).Should(Receive(&releaseQueued))
+		releaseQueued()
+		Expect(CheckQueue(c, o)).To(Succeed())
+	})
+
+	It("drops the requests canceled while waiting", func() {
+		release, err := acquireSlot(context.Background(), c.Model, c, o)
+		Expect(err).ToNot(HaveOccurred())
+
+		ctx, cancel := context.WithCancel(context.Background())
+		queued := acquireAsync(ctx)
+		Eventually(func() int { return getQueue(c.Model, 1, 0, 0).status(c.Model).Queued }).Should(Equal(1))
+		cancel()
+		Eventually(func() int { return getQueue(c.Model, 1, 0, 0).status(c.Model).Queued }).Should(Equal(0))
+		Consistently(queued, 50*time.Millisecond).ShouldNot(Receive())
+
+		release()
+		Expect(getQueue(c.Model, 1, 0, 0).status(c.Model).Active).To(Equal(0))
+	})
+
+	Context("priorities", func() {
+		// enqueue queues requests with the given priorities, in order
+		enqueue := func(priorities ...Priority) []chan func() {
+			granted := []chan func(){}
+			for _, p := range priorities {
+				queued := getQueue(c.Model, 1, 0, o.PriorityAging).status(c.Model).Queued
+				granted = append(granted, acquireAsync(WithPriority(context.Background(), p)))
+				Eventually(func() int { return getQueue(c.Model, 1, 0, o.PriorityAging).status(c.Model).Queued }).Should(Equal(queued + 1))
+			}
+			return granted
+		}
+
+		It("serves high priority requests first", func() {
+			o.PriorityAging = time.Hour
+			release, err := acquireSlot(context.Background(), c.Model, c, o)
+			Expect(err).ToNot(HaveOccurred())
+			granted := enqueue(PriorityLow, PriorityNormal, PriorityHigh)
+			Expect(getQueue(c.Model, 1, 0, o.PriorityAging).status(c.Model).QueuedByPriority).To(Equal(map[string]int{"low": 1, "normal": 1, "high": 1}))
+
+			for _, next := range []int{2, 1, 0} {
+				release()
+				Eventually(granted[next]).Should(Receive(&release))
+			}
+			release()
+		})
+
+		It("promotes the requests waiting for long", func() {
+			o.PriorityAging = 50 * time.Millisecond
+			release, err := acquireSlot(context.Background(), c.Model, c, o)
+			Expect(err).ToNot(HaveOccurred())
+			low := enqueue(PriorityLow)[0]
+			time.Sleep(150 * time.Millisecond)
+			high := enqueue(PriorityHigh)[0]
+
+			release()
+			Eventually(low).Should(Receive(&release))
+			release()
+			Eventually(high).Should(Receive(&release))
+			release()
+		})
+	})
+})
+
+var _ = DescribeTable("Request priority",
+	func(header, apiKey string, expected Priority, fails bool) {
+		p, err := RequestPriority(header, apiKey, map[string]string{"batch": "low", "chat": "high"})
+		if fails {
+			Expect(err).To(HaveOccurred())
+			return
+		}
+		Expect(err).ToNot(HaveOccurred())
+		Expect(p).To(Equal(expected))
+	},
+	Entry("defaults to normal", "", "", PriorityNormal, false),
+	Entry("is lowered by the header", "low", "", PriorityLow, false),
+	Entry("can't be raised above normal without an API key allowing it", "high", "", PriorityNormal, false),
+	Entry("can't be raised above normal by an API key without priority", "high", "other", PriorityNormal, false),
+	Entry("is raised by the header up to the API key one", "high", "chat", PriorityHigh, false),
+	Entry("is set by the API key", "", "batch", PriorityLow, false),
+	Entry("can be lowered by the header", "normal", "chat", PriorityNormal, false),
+	Entry("can't be raised above the API key one", "high", "batch", PriorityLow, false),
+	Entry("rejects invalid values", "urgent", "", PriorityNormal, true),
+)
@@ -7,6 +7,7 @@ import (
 	config "github.com/go-skynet/LocalAI/api/config"
 
 	"github.com/go-skynet/LocalAI/api/options"
+	"github.com/go-skynet/LocalAI/pkg/grpc"
 	"github.com/go-skynet/LocalAI/pkg/grpc/proto"
 	"github.com/go-skynet/LocalAI/pkg/grpc/whisper/api"
 	model "github.com/go-skynet/LocalAI/pkg/model"
@@ -19,6 +20,7 @@ func ModelTranscription(audio, language string, loader *model.ModelLoader, c con
 		model.WithContext(o.Context),
 		model.WithThreads(uint32(c.Threads)),
 		model.WithAssetDir(o.AssetsDestination),
+		model.WithCapability(grpc.CapabilityAudioTranscription),
 	}
 
 	if c.GRPC.Attempts != 0 {
@@ -32,6 +34,8 @@ func ModelTranscription(audio, language string, loader *model.ModelLoader, c con
 		opts = append(opts, model.WithExternalBackend(k, v))
 	}
 
+	opts = append(opts, watchdogOpts(c)...)
+
 	whisperModel, err := o.Loader.BackendLoader(opts...)
 	if err != nil {
 		return nil, err
@@ -41,6 +45,12 @@ func ModelTranscription(audio, language string, loader *model.ModelLoader, c con
 		return nil, fmt.Errorf("could not load whisper model")
 	}
 
+	if err := checkCapability(whisperModel, c.Model, grpc.CapabilityAudioTranscription); err != nil {
+		return nil, err
+	}
+
+	defer markBusy(o.Loader, c.Model)()
+
 	return whisperModel.AudioTranscription(context.Background(), &proto.TranscriptRequest{
 		Dst:      audio,
 		Language: language,
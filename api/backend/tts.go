@@ -7,6 +7,7 @@ import (
 	"path/filepath"
 
 	"github.com/go-skynet/LocalAI/api/options"
+	"github.com/go-skynet/LocalAI/pkg/grpc"
 	"github.com/go-skynet/LocalAI/pkg/grpc/proto"
 	model "github.com/go-skynet/LocalAI/pkg/model"
 	"github.com/go-skynet/LocalAI/pkg/utils"
@@ -38,6 +39,7 @@ func ModelTTS(backend, text, modelFile string, loader *model.ModelLoader, o *opt
 		model.WithModel(modelFile),
 		model.WithContext(o.Context),
 		model.WithAssetDir(o.AssetsDestination),
+		model.WithCapability(grpc.CapabilityTTS),
 	}
 
 	for k, v := range o.ExternalGRPCBackends {
@@ -53,6 +55,10 @@ func ModelTTS(backend, text, modelFile string, loader *model.ModelLoader, o *opt
 		return "", nil, fmt.Errorf("could not load piper model")
 	}
 
+	if err := checkCapability(piperModel, modelFile, grpc.CapabilityTTS); err != nil {
+		return "", nil, err
+	}
+
 	if err := os.MkdirAll(o.AudioDir, 0755); err != nil {
 		return "", nil, fmt.Errorf("failed creating audio directory: %s", err)
 	}
@@ -69,6 +75,9 @@ func ModelTTS(backend, text, modelFile string, loader *model.ModelLoader, o *opt
 		}
 	}
 
+	// without a config, the model is loaded by the name of its file
+	defer markBusy(o.Loader, modelFile)()
+
 	res, err := piperModel.TTS(context.Background(), &proto.TTSRequest{
 		Text:  text,
 		Model: modelPath,
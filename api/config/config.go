@@ -5,9 +5,11 @@ import (
 	"io/fs"
 	"os"
 	"path/filepath"
-	"strings"
 	"sync"
 
+	"github.com/go-skynet/LocalAI/pkg/grammar"
+	"github.com/go-skynet/LocalAI/pkg/model/gguf"
+	"github.com/rs/zerolog/log"
 	"gopkg.in/yaml.v3"
 )
 
@@ -26,6 +28,8 @@ type Config struct {
 	PromptStrings, InputStrings                []string `yaml:"-"`
 	InputToken                                 [][]int  `yaml:"-"`
 	functionCallString, functionCallNameString string   `yaml:"-"`
+	// strictGrammar is set when the output must follow the grammar, as requested by a response format
+	strictGrammar bool
 
 	FunctionsConfig Functions `yaml:"function"`
 
@@ -42,6 +46,14 @@ type Config struct {
 
 	// GRPC Options
 	GRPC GRPC `yaml:"grpc"`
+
+	// Watchdog timeouts (e.g. "15m"), overriding the global ones for this model
+	IdleTimeout string `yaml:"idle_timeout"`
+	BusyTimeout string `yaml:"busy_timeout"`
+
+	// Number of requests served at the same time, and how many more can wait for a slot
+	ParallelRequests int `yaml:"parallel_requests"`
+	MaxQueueDepth    int `yaml:"max_queue_depth"`
 }
 
 type GRPC struct {
@@ -96,6 +108,12 @@ type Functions struct {
 	DisableNoAction         bool   `yaml:"disable_no_action"`
 	NoActionFunctionName    string `yaml:"no_action_function_name"`
 	NoActionDescriptionName string `yaml:"no_action_description_name"`
+	// DisableParallelCalls restricts the model to one tool call per response
+	DisableParallelCalls bool `yaml:"disable_parallel_calls"`
+	// ValidationRetries is how many times the function calls are computed again, each time at half the
+	// temperature, when their arguments don't match the parameters of the functions. The streamed calls can't be:
+	// the stream ends with an error instead.
+	ValidationRetries int `yaml:"validation_retries"`
 }
 
 type TemplateConfig struct {
@@ -104,10 +122,15 @@ type TemplateConfig struct {
 	Completion  string `yaml:"completion"`
 	Edit        string `yaml:"edit"`
 	Functions   string `yaml:"function"`
+	// ChatTemplate is a Hugging Face (Jinja) chat template, rendered from the messages instead of the templates above:
+	// "gguf" to use the one of the model file, the name of a .jinja file in the model path, or the template itself
+	ChatTemplate string `yaml:"chat_template"`
 }
 
 type ConfigLoader struct {
 	configs map[string]Config
+	// sources maps each model to the file its config was read from, to reload it when the file changes
+	sources map[string]string
 	sync.Mutex
 }
 
@@ -119,10 +142,24 @@ func (c *Config) SetFunctionCallNameString(s string) {
 	c.functionCallNameString = s
 }
 
+// SetStrictGrammar requires the backend to enforce the grammar, rather than using it as a hint
+func (c *Config) SetStrictGrammar(b bool) {
+	c.strictGrammar = b
+}
+
+func (c *Config) StrictGrammar() bool {
+	return c.strictGrammar
+}
+
 func (c *Config) ShouldUseFunctions() bool {
 	return ((c.functionCallString != "none" || c.functionCallString == "") || c.ShouldCallSpecificFunction())
 }
 
+// ShouldRequireFunctionCall is true when the model must call a function instead of answering
+func (c *Config) ShouldRequireFunctionCall() bool {
+	return c.functionCallString == "required"
+}
+
 func (c *Config) ShouldCallSpecificFunction() bool {
 	return len(c.functionCallNameString) > 0
 }
@@ -131,6 +168,43 @@ func (c *Config) FunctionToCall() string {
 	return c.functionCallNameString
 }
 
+// Validate checks the parts of the config the backends would fail on
+func (c *Config) Validate() error {
+	if c.Grammar != "" {
+		if _, err := grammar.ParseGrammar(c.Grammar); err != nil {
+			return fmt.Errorf("invalid grammar of model %s: %w", c.Name, err)
+		}
+	}
+	return nil
+}
+
+// validateConfigs checks the configs read from a file
+func validateConfigs(file string, configs ...*Config) error {
+	for _, c := range configs {
+		if err := c.Validate(); err != nil {
+			return fmt.Errorf("invalid config file %s: %w", file, err)
+		}
+	}
+	return nil
+}
+
+// SetDefaultsFromMetadata fills the unset fields of the config with the values
+// found in the header of the model file
+func (c *Config) SetDefaultsFromMetadata(m *gguf.Metadata) {
+	if c.ContextSize == 0 {
+		c.ContextSize = int(m.ContextLength)
+	}
+	if c.RopeFreqBase == 0 {
+		c.RopeFreqBase = m.RopeFreqBase
+	}
+	if c.RopeFreqScale == 0 && m.RopeScaleLinear > 0 {
+		c.RopeFreqScale = 1 / m.RopeScaleLinear
+	}
+	if c.NGQA == 0 {
+		c.NGQA = int32(m.GQA())
+	}
+}
+
 func defaultPredictOptions(modelFile string) PredictionOptions {
 	return PredictionOptions{
 		TopP:        0.7,
@@ -141,6 +215,9 @@ func defaultPredictOptions(modelFile string) PredictionOptions {
 	}
 }
 
+// DefaultContextSize is the context size of the models when neither the operator nor the model set one
+const DefaultContextSize = 512
+
 func DefaultConfig(modelFile string) *Config {
 	return &Config{
 		PredictionOptions: defaultPredictOptions(modelFile),
@@ -150,6 +227,7 @@ func DefaultConfig(modelFile string) *Config {
 func NewConfigLoader() *ConfigLoader {
 	return &ConfigLoader{
 		configs: make(map[string]Config),
+		sources: make(map[string]string),
 	}
 }
 func ReadConfigFile(file string) ([]*Config, error) {
@@ -161,6 +239,9 @@ func ReadConfigFile(file string) ([]*Config, error) {
 	if err := yaml.Unmarshal(f, c); err != nil {
 		return nil, fmt.Errorf("cannot unmarshal config file: %w", err)
 	}
+	if err := validateConfigs(file, *c...); err != nil {
+		return nil, err
+	}
 
 	return *c, nil
 }
@@ -174,6 +255,9 @@ func ReadConfig(file string) (*Config, error) {
 	if err := yaml.Unmarshal(f, c); err != nil {
 		return nil, fmt.Errorf("cannot unmarshal config file: %w", err)
 	}
+	if err := validateConfigs(file, c); err != nil {
+		return nil, err
+	}
 
 	return c, nil
 }
@@ -188,6 +272,7 @@ func (cm *ConfigLoader) LoadConfigFile(file string) error {
 
 	for _, cc := range c {
 		cm.configs[cc.Name] = *cc
+		cm.sources[cc.Name] = file
 	}
 	return nil
 }
@@ -201,6 +286,7 @@ func (cm *ConfigLoader) LoadConfig(file string) error {
 	}
 
 	cm.configs[c.Name] = *c
+	cm.sources[c.Name] = file
 	return nil
 }
 
@@ -247,14 +333,16 @@ func (cm *ConfigLoader) LoadConfigs(path string) error {
 		files = append(files, info)
 	}
 	for _, file := range files {
-		// Skip templates, YAML and .keep files
-		if !strings.Contains(file.Name(), ".yaml") {
+		if !isConfigFile(file.Name()) {
 			continue
 		}
 		c, err := ReadConfig(filepath.Join(path, file.Name()))
-		if err == nil {
-			cm.configs[c.Name] = *c
+		if err != nil {
+			log.Error().Msgf("Skipping %s: %s", file.Name(), err.Error())
+			continue
 		}
+		cm.configs[c.Name] = *c
+		cm.sources[c.Name] = filepath.Join(path, file.Name())
 	}
 
 	return nil
@@ -18,6 +18,12 @@ var _ = Describe("Test cases for config related functions", func() {
 
 	Context("Test Read configuration functions", func() {
 		configFile = os.Getenv("CONFIG_FILE")
+		BeforeEach(func() {
+			if configFile == "" || os.Getenv("MODELS_PATH") == "" {
+				Skip("CONFIG_FILE and MODELS_PATH must point to the model fixtures")
+			}
+		})
+
 		It("Test ReadConfigFile", func() {
 			config, err := ReadConfigFile(configFile)
 			Expect(err).To(BeNil())
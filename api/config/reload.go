@@ -0,0 +1,168 @@
+package api_config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/hashicorp/go-multierror"
+	"github.com/rs/zerolog/log"
+	"gopkg.in/yaml.v3"
+)
+
+// reloadDelay lets editors finish writing a file before it is read again
+const reloadDelay = 200 * time.Millisecond
+
+// isConfigFile tells if a file of the models path holds configs: only the YAML files do,
+// while the models, the templates and the .keep files are skipped
+func isConfigFile(name string) bool {
+	ext := filepath.Ext(name)
+	return ext == ".yaml" || ext == ".yml"
+}
+
+// readConfigs reads a file holding either a list of configs, or a single one
+func readConfigs(file string) ([]*Config, error) {
+	configs, err := ReadConfigFile(file)
+	// a single config doesn't unmarshal as a list
+	var typeErr *yaml.TypeError
+	if !errors.As(err, &typeErr) {
+		return configs, err
+	}
+	c, err := ReadConfig(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	return []*Config{c}, nil
+}
+
+// ReloadConfigFile reads the configs of a file again, dropping the ones it doesn't define anymore
+// (all of them if the file was deleted). If the file can't be loaded, its previous configs are kept.
+func (cm *ConfigLoader) ReloadConfigFile(file string) error {
+	var configs []*Config
+	if _, err := os.Stat(file); err == nil {
+		configs, err = readConfigs(file)
+		if err != nil {
+			return err
+		}
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	cm.Lock()
+	defer cm.Unlock()
+	for m, source := range cm.sources {
+		if source == file {
+			delete(cm.configs, m)
+			delete(cm.sources, m)
+		}
+	}
+	for _, c := range configs {
+		cm.configs[c.Name] = *c
+		cm.sources[c.Name] = file
+	}
+	return nil
+}
+
+// ReloadConfigs reloads the YAML configs of the path: new and changed files are read again,
+// and the configs of the deleted ones are dropped. The errors of all the files that fail to load are returned.
+func (cm *ConfigLoader) ReloadConfigs(path string) error {
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return err
+	}
+
+	files := map[string]bool{}
+	for _, entry := range entries {
+		if !entry.IsDir() && isConfigFile(entry.Name()) {
+			files[filepath.Join(path, entry.Name())] = true
+		}
+	}
+	cm.Lock()
+	for _, source := range cm.sources {
+		if filepath.Dir(source) == filepath.Clean(path) {
+			files[source] = true
+		}
+	}
+	cm.Unlock()
+
+	var errs error
+	for file := range files {
+		if err := cm.ReloadConfigFile(file); err != nil {
+			errs = multierror.Append(errs, err)
+		}
+	}
+	return errs
+}
+
+// WatchConfigs reloads the YAML configs of the path when they are changed, added or deleted, until the context is canceled.
+// The other files that change, in the path or in its subdirectories, are passed to onChange
+// to let the caller drop what it cached from them.
+func (cm *ConfigLoader) WatchConfigs(ctx context.Context, path string, onChange func(file string)) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	if err := watcher.Add(path); err != nil {
+		watcher.Close()
+		return err
+	}
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		watcher.Close()
+		return err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			if err := watcher.Add(filepath.Join(path, entry.Name())); err != nil {
+				log.Warn().Msgf("Cannot watch %s: %s", entry.Name(), err.Error())
+			}
+		}
+	}
+
+	go func() {
+		defer watcher.Close()
+
+		pending := map[string]bool{}
+		timer := time.NewTimer(reloadDelay)
+		timer.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				log.Warn().Msgf("Watching %s: %s", path, err.Error())
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if event.Op == fsnotify.Chmod {
+					continue
+				}
+				pending[event.Name] = true
+				timer.Reset(reloadDelay)
+			case <-timer.C:
+				for file := range pending {
+					if filepath.Dir(file) != filepath.Clean(path) || !isConfigFile(filepath.Base(file)) {
+						onChange(file)
+						continue
+					}
+					if err := cm.ReloadConfigFile(file); err != nil {
+						log.Error().Msgf("Failed reloading config file %s: %s", file, err.Error())
+						continue
+					}
+					log.Info().Msgf("Reloaded config file %s", file)
+				}
+				pending = map[string]bool{}
+			}
+		}
+	}()
+	return nil
+}
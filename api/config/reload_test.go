@@ -0,0 +1,116 @@
+package api_config_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	. "github.com/go-skynet/LocalAI/api/config"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Reloading configs", func() {
+	var dir string
+	var cm *ConfigLoader
+
+	write := func(file, content string) {
+		Expect(os.WriteFile(filepath.Join(dir, file), []byte(content), 0644)).To(Succeed())
+	}
+
+	models := func() []string {
+		l := cm.ListConfigs()
+		sort.Strings(l)
+		return l
+	}
+
+	BeforeEach(func() {
+		dir = GinkgoT().TempDir()
+		cm = NewConfigLoader()
+		write("a.yaml", "name: a\nbackend: llama\n")
+		write("b.yaml", "name: b\n")
+		Expect(cm.LoadConfigs(dir)).To(Succeed())
+		Expect(models()).To(Equal([]string{"a", "b"}))
+	})
+
+	It("reloads changed, added and deleted files", func() {
+		write("a.yaml", "name: a\nbackend: rwkv\n")
+		write("c.yaml", "name: c\n")
+		Expect(os.Remove(filepath.Join(dir, "b.yaml"))).To(Succeed())
+
+		Expect(cm.ReloadConfigs(dir)).To(Succeed())
+		Expect(models()).To(Equal([]string{"a", "c"}))
+		a, _ := cm.GetConfig("a")
+		Expect(a.Backend).To(Equal("rwkv"))
+	})
+
+	It("drops the configs renamed in a file", func() {
+		write("a.yaml", "name: renamed\n")
+		Expect(cm.ReloadConfigFile(filepath.Join(dir, "a.yaml"))).To(Succeed())
+		Expect(models()).To(Equal([]string{"b", "renamed"}))
+	})
+
+	It("keeps the previous configs of the files that fail to load", func() {
+		write("a.yaml", "name: [a\n")
+		Expect(cm.ReloadConfigs(dir)).ToNot(Succeed())
+		a, exists := cm.GetConfig("a")
+		Expect(exists).To(BeTrue())
+		Expect(a.Backend).To(Equal("llama"))
+	})
+
+	It("rejects the configs with an invalid grammar", func() {
+		write("a.yaml", "name: a\ngrammar: |\n  root ::= item\n")
+		Expect(cm.ReloadConfigFile(filepath.Join(dir, "a.yaml"))).To(MatchError(ContainSubstring(`invalid grammar of model a: line 1, column 10: undefined rule "item"`)))
+		a, _ := cm.GetConfig("a")
+		Expect(a.Backend).To(Equal("llama"))
+
+		cm = NewConfigLoader()
+		Expect(cm.LoadConfigs(dir)).To(Succeed())
+		Expect(models()).To(Equal([]string{"b"}))
+	})
+
+	It("reloads lists of configs", func() {
+		write("list.yaml", "- name: l1\n- name: l2\n")
+		Expect(cm.ReloadConfigFile(filepath.Join(dir, "list.yaml"))).To(Succeed())
+		Expect(models()).To(Equal([]string{"a", "b", "l1", "l2"}))
+	})
+
+	It("rejects the lists of configs with an invalid grammar", func() {
+		write("list.yaml", "- name: l1\n- name: l2\n  grammar: |\n    root ::= item\n")
+		Expect(cm.ReloadConfigFile(filepath.Join(dir, "list.yaml"))).To(MatchError(ContainSubstring(`invalid grammar of model l2`)))
+		Expect(models()).To(Equal([]string{"a", "b"}))
+	})
+
+	It("only reads the YAML files", func() {
+		write("c.yml", "name: c\n")
+		write("d.yaml.bak", "name: d\n")
+		Expect(cm.ReloadConfigs(dir)).To(Succeed())
+		Expect(models()).To(Equal([]string{"a", "b", "c"}))
+	})
+
+	It("watches the path for changes", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		var mu sync.Mutex
+		changed := []string{}
+		Expect(cm.WatchConfigs(ctx, dir, func(file string) {
+			mu.Lock()
+			defer mu.Unlock()
+			changed = append(changed, filepath.Base(file))
+		})).To(Succeed())
+
+		write("c.yaml", "name: c\n")
+		Expect(os.Remove(filepath.Join(dir, "a.yaml"))).To(Succeed())
+		write("c.tmpl", "{{.Input}}")
+
+		Eventually(models).Should(Equal([]string{"b", "c"}))
+		Eventually(func() []string {
+			mu.Lock()
+			defer mu.Unlock()
+			return changed
+		}).Should(Equal([]string{"c.tmpl"}))
+	})
+})
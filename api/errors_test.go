@@ -0,0 +1,37 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+
+	"github.com/go-skynet/LocalAI/api/backend"
+	"github.com/go-skynet/LocalAI/api/openai"
+	"github.com/go-skynet/LocalAI/pkg/jinja"
+	"github.com/gofiber/fiber/v2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = DescribeTable("Error responses",
+	func(err error, code int, errorType string) {
+		app := fiber.New(fiber.Config{ErrorHandler: errorHandler})
+		app.Get("/", func(c *fiber.Ctx) error { return err })
+
+		resp, e := app.Test(httptest.NewRequest("GET", "/", nil))
+		Expect(e).ToNot(HaveOccurred())
+		defer resp.Body.Close()
+		Expect(resp.StatusCode).To(Equal(code))
+
+		var res openai.ErrorResponse
+		Expect(json.NewDecoder(resp.Body).Decode(&res)).To(Succeed())
+		Expect(res.Error.Message).To(Equal(err.Error()))
+		Expect(res.Error.Code).To(BeEquivalentTo(code))
+		Expect(res.Error.Type).To(Equal(errorType))
+	},
+	Entry("internal errors", fmt.Errorf("backend error"), 500, ""),
+	Entry("fiber errors", fiber.NewError(404, "not found"), 404, ""),
+	Entry("exceptions raised by the chat template", fmt.Errorf("rendering: %w", &jinja.RaisedError{Message: "roles must alternate"}), 400, ""),
+	Entry("full queues", &backend.QueueFullError{Model: "m"}, 429, ""),
+	Entry("invalid function calls", fmt.Errorf("chat: %w", &openai.FunctionCallError{Function: "f", Attempts: 2, Err: fmt.Errorf("unknown function")}), 422, "invalid_function_call"),
+)
@@ -0,0 +1,110 @@
+package localai
+
+import (
+	"fmt"
+
+	"github.com/go-skynet/LocalAI/api/backend"
+	config "github.com/go-skynet/LocalAI/api/config"
+	"github.com/go-skynet/LocalAI/api/options"
+	model "github.com/go-skynet/LocalAI/pkg/model"
+	"github.com/gofiber/fiber/v2"
+)
+
+type BackendRequest struct {
+	Model   string `json:"model" yaml:"model"`
+	Backend string `json:"backend" yaml:"backend"`
+}
+
+// BackendLoadEndpoint loads a model in memory, so it is ready before receiving requests
+func BackendLoadEndpoint(cm *config.ConfigLoader, o *options.Option) func(c *fiber.Ctx) error {
+	return func(c *fiber.Ctx) error {
+		input := new(BackendRequest)
+		// Get input data from the request body
+		if err := c.BodyParser(input); err != nil {
+			return err
+		}
+
+		if input.Model == "" {
+			return fiber.NewError(fiber.StatusBadRequest, "no model specified")
+		}
+
+		cfg, exists := cm.GetConfig(input.Model)
+		if !exists {
+			cfg = *config.DefaultConfig(input.Model)
+			cfg.ContextSize = o.ContextSize
+			if cfg.ContextSize == 0 {
+				cfg.ContextSize = config.DefaultContextSize
+			}
+			cfg.F16 = o.F16
+			cfg.Debug = o.Debug
+		}
+		if cfg.Threads == 0 {
+			cfg.Threads = o.Threads
+		}
+		if input.Backend != "" {
+			cfg.Backend = input.Backend
+		}
+
+		if err := backend.ModelLoad(o.Loader, cfg, o); err != nil {
+			return fmt.Errorf("failed loading model %s: %w", input.Model, err)
+		}
+
+		status, err := o.Loader.BackendStatus(c.Context(), cfg.Model)
+		if err != nil {
+			return err
+		}
+		return c.JSON(status)
+	}
+}
+
+// BackendUnloadEndpoint stops the backend serving a model, freeing its resources
+func BackendUnloadEndpoint(cm *config.ConfigLoader, ml *model.ModelLoader) func(c *fiber.Ctx) error {
+	return func(c *fiber.Ctx) error {
+		input := new(BackendRequest)
+		// Get input data from the request body
+		if err := c.BodyParser(input); err != nil {
+			return err
+		}
+
+		modelFile := input.Model
+		if cfg, exists := cm.GetConfig(input.Model); exists {
+			modelFile = cfg.Model
+		}
+
+		if err := ml.ShutdownModel(modelFile); err != nil {
+			return fiber.NewError(fiber.StatusNotFound, err.Error())
+		}
+		return c.JSON(struct {
+			Message string `json:"message"`
+		}{Message: fmt.Sprintf("model %s unloaded", input.Model)})
+	}
+}
+
+// BackendStatusEndpoint lists the models loaded in memory and the status of their backends,
+// along with the circuit breaker state of the backends that crashed
+func BackendStatusEndpoint(ml *model.ModelLoader) func(c *fiber.Ctx) error {
+	return func(c *fiber.Ctx) error {
+		return c.JSON(struct {
+			Backends []model.BackendStatus `json:"backends"`
+			Circuits []model.CircuitStatus `json:"circuits"`
+		}{Backends: ml.BackendsStatus(c.Context()), Circuits: ml.Circuits()})
+	}
+}
+
+// BackendEvictionsEndpoint lists the models that were recently stopped to make room for others
+func BackendEvictionsEndpoint(ml *model.ModelLoader) func(c *fiber.Ctx) error {
+	return func(c *fiber.Ctx) error {
+		return c.JSON(struct {
+			Evictions []model.Eviction `json:"evictions"`
+		}{Evictions: ml.Evictions()})
+	}
+}
+
+// BackendQueuesEndpoint shows the requests running and waiting for each model, and how long they waited
+func BackendQueuesEndpoint() func(c *fiber.Ctx) error {
+	return func(c *fiber.Ctx) error {
+		return c.JSON(struct {
+			Queues []backend.QueueStatus `json:"queues"`
+		}{Queues: backend.QueuesStatus()})
+	}
+}
@@ -0,0 +1,45 @@
+package localai
+
+import (
+	"errors"
+	"sort"
+
+	config "github.com/go-skynet/LocalAI/api/config"
+	"github.com/go-skynet/LocalAI/api/options"
+	"github.com/gofiber/fiber/v2"
+	"github.com/hashicorp/go-multierror"
+)
+
+// ReloadResponse lists the models configured after a reload, and the config files that failed to load
+type ReloadResponse struct {
+	Models []string `json:"models"`
+	Errors []string `json:"errors,omitempty"`
+}
+
+// ReloadConfigsEndpoint reads the model configs and the prompt templates again.
+// It is meant for the environments where the models path can't be watched for changes.
+// The config files that fail to load are reported, and the configs loaded before from them are kept.
+func ReloadConfigsEndpoint(cm *config.ConfigLoader, o *options.Option) func(c *fiber.Ctx) error {
+	return func(c *fiber.Ctx) error {
+		var errs error
+		if err := cm.ReloadConfigs(o.Loader.ModelPath); err != nil {
+			errs = multierror.Append(errs, err)
+		}
+		if o.ConfigFile != "" {
+			if err := cm.ReloadConfigFile(o.ConfigFile); err != nil {
+				errs = multierror.Append(errs, err)
+			}
+		}
+		o.Loader.ResetTemplates()
+
+		res := ReloadResponse{Models: cm.ListConfigs()}
+		sort.Strings(res.Models)
+		var merr *multierror.Error
+		if errors.As(errs, &merr) {
+			for _, err := range merr.Errors {
+				res.Errors = append(res.Errors, err.Error())
+			}
+		}
+		return c.JSON(res)
+	}
+}
@@ -2,10 +2,12 @@ package openai
 
 import (
 	"context"
+	"encoding/json"
 
 	config "github.com/go-skynet/LocalAI/api/config"
 
 	"github.com/go-skynet/LocalAI/pkg/grammar"
+	"github.com/go-skynet/LocalAI/pkg/model/gguf"
 )
 
 // APIError provides error information returned by the OpenAI API.
@@ -62,11 +64,65 @@ type Message struct {
 	Content *string `json:"content" yaml:"content"`
 	// A result of a function call
 	FunctionCall interface{} `json:"function_call,omitempty" yaml:"function_call,omitempty"`
+	// The tools called by the assistant
+	ToolCalls []ToolCall `json:"tool_calls,omitempty" yaml:"tool_calls,omitempty"`
+	// The call a tool message is the result of
+	ToolCallID string `json:"tool_call_id,omitempty" yaml:"tool_call_id,omitempty"`
+	// The name of the author of the message, or of the function of a function message
+	Name string `json:"name,omitempty" yaml:"name,omitempty"`
+}
+
+// Tool is a tool the model may call. Functions are the only kind of tools
+type Tool struct {
+	Type     string           `json:"type" yaml:"type"`
+	Function grammar.Function `json:"function" yaml:"function"`
+}
+
+type ToolCall struct {
+	// Index is the position of the call in the response, set in the chunks of a stream only
+	Index    *int         `json:"index,omitempty" yaml:"index,omitempty"`
+	ID       string       `json:"id,omitempty" yaml:"id,omitempty"`
+	Type     string       `json:"type,omitempty" yaml:"type,omitempty"`
+	Function FunctionCall `json:"function" yaml:"function"`
+}
+
+type FunctionCall struct {
+	Name string `json:"name,omitempty" yaml:"name,omitempty"`
+	// Arguments is the JSON object of the arguments, as a string.
+	// The chunks of a stream hold the fragments of the arguments decoded since the previous chunk
+	Arguments string `json:"arguments" yaml:"arguments"`
+}
+
+// ResponseFormat is the format of the output. The whisper and image endpoints give only the type, as a string
+type ResponseFormat struct {
+	Type string `json:"type"`
+	// JSONSchema is the schema of the output, for the json_schema type
+	JSONSchema *JSONSchemaFormat `json:"json_schema,omitempty"`
+}
+
+type JSONSchemaFormat struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description,omitempty"`
+	Schema      map[string]interface{} `json:"schema"`
+	Strict      bool                   `json:"strict,omitempty"`
+}
+
+func (r *ResponseFormat) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*r = ResponseFormat{Type: s}
+		return nil
+	}
+	type responseFormat ResponseFormat
+	return json.Unmarshal(data, (*responseFormat)(r))
 }
 
 type OpenAIModel struct {
 	ID     string `json:"id"`
 	Object string `json:"object"`
+
+	// Metadata read from the model file, for models in GGUF format
+	Metadata *gguf.Metadata `json:"metadata,omitempty"`
 }
 
 type OpenAIRequest struct {
@@ -77,8 +133,8 @@ type OpenAIRequest struct {
 
 	// whisper
 	File string `json:"file" validate:"required"`
-	//whisper/image
-	ResponseFormat string `json:"response_format"`
+	//whisper/image, and chat/completion with a JSON format
+	ResponseFormat ResponseFormat `json:"response_format"`
 	// image
 	Size string `json:"size"`
 	// Prompt is read only by completion/image API calls
@@ -97,6 +153,12 @@ type OpenAIRequest struct {
 	Functions    []grammar.Function `json:"functions" yaml:"functions"`
 	FunctionCall interface{}        `json:"function_call" yaml:"function_call"` // might be a string or an object
 
+	// A list of available tools to call, and which one the model should call
+	Tools      []Tool      `json:"tools" yaml:"tools"`
+	ToolChoice interface{} `json:"tool_choice" yaml:"tool_choice"` // might be a string or an object
+	// Whether the model may call several tools in one response, true if unset
+	ParallelToolCalls *bool `json:"parallel_tool_calls" yaml:"parallel_tool_calls"`
+
 	Stream bool `json:"stream"`
 
 	// Image (not supported by OpenAI)
@@ -105,6 +167,9 @@ type OpenAIRequest struct {
 
 	// A grammar to constrain the LLM output
 	Grammar string `json:"grammar" yaml:"grammar"`
+	// Constrain the LLM output to match a regular expression, or to be one of the choices
+	Regex   string   `json:"regex" yaml:"regex"`
+	Choices []string `json:"choices" yaml:"choices"`
 
 	JSONFunctionGrammarObject *grammar.JSONFunctionStructure `json:"grammar_json_functions" yaml:"grammar_json_functions"`
 
@@ -4,15 +4,13 @@ import (
 	"bufio"
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"strings"
 
 	"github.com/go-skynet/LocalAI/api/backend"
 	config "github.com/go-skynet/LocalAI/api/config"
 	"github.com/go-skynet/LocalAI/api/options"
-	"github.com/go-skynet/LocalAI/pkg/grammar"
 	model "github.com/go-skynet/LocalAI/pkg/model"
-	"github.com/go-skynet/LocalAI/pkg/utils"
 	"github.com/gofiber/fiber/v2"
 	"github.com/rs/zerolog/log"
 	"github.com/valyala/fasthttp"
@@ -21,29 +19,102 @@ import (
 func ChatEndpoint(cm *config.ConfigLoader, o *options.Option) func(c *fiber.Ctx) error {
 	emptyMessage := ""
 
-	process := func(s string, req *OpenAIRequest, config *config.Config, loader *model.ModelLoader, responses chan OpenAIResponse) {
+	// process streams the message, and validates it against the response format of the request once complete
+	process := func(s string, req *OpenAIRequest, config *config.Config, loader *model.ModelLoader, responses chan OpenAIResponse, streamErr *error) {
 		initialMessage := OpenAIResponse{
 			Model:   req.Model, // we have to return what the user sent here, due to OpenAI spec.
 			Choices: []Choice{{Delta: &Message{Role: "assistant", Content: &emptyMessage}}},
 			Object:  "chat.completion.chunk",
 		}
-		responses <- initialMessage
+		if !sendResponse(req, responses, initialMessage) {
+			close(responses)
+			return
+		}
 
-		ComputeChoices(req, s, config, o, loader, func(s string, c *[]Choice) {}, func(s string) bool {
+		output := &streamedOutput{input: req}
+		_, err := ComputeChoices(req, s, config, o, loader, func(s string, c *[]Choice) {
+			output.validate()
+		}, func(s string) bool {
+			output.write(s)
 			resp := OpenAIResponse{
 				Model:   req.Model, // we have to return what the user sent here, due to OpenAI spec.
 				Choices: []Choice{{Delta: &Message{Content: &s}, Index: 0}},
 				Object:  "chat.completion.chunk",
 			}
 
-			responses <- resp
-			return true
+			return sendResponse(req, responses, resp)
 		})
+		*streamErr = streamResult(req, err, output)
+		close(responses)
+	}
+	// processTools streams the calls of an output constrained by the functions grammar, as tool calls or as a function call.
+	// If the model calls the no action function, its message is streamed as the content instead.
+	// The calls are streamed while generated, so unlike in the other requests they can't be computed again if invalid:
+	// they are validated once complete, and the invalid ones are reported as the error of the stream.
+	processTools := func(prompt *chatPrompt, s string, req *OpenAIRequest, config *config.Config, loader *model.ModelLoader, responses chan OpenAIResponse, finishReason *string, streamErr *error) {
+		send := func(delta *Message) bool {
+			return sendResponse(req, responses, OpenAIResponse{
+				Model:   req.Model, // we have to return what the user sent here, due to OpenAI spec.
+				Choices: []Choice{{Delta: delta, Index: 0}},
+				Object:  "chat.completion.chunk",
+			})
+		}
+		send(&Message{Role: "assistant", Content: &emptyMessage})
+
+		calls := newFunctionCallStream(prompt.noActionName)
+		calls.onCall = func(index int, name string) {
+			if prompt.tools {
+				send(&Message{ToolCalls: []ToolCall{{Index: &index, ID: newToolCallID(), Type: "function", Function: FunctionCall{Name: name}}}})
+			} else if index == 0 {
+				send(&Message{FunctionCall: map[string]interface{}{"name": name, "arguments": ""}})
+			}
+		}
+		calls.onArguments = func(index int, fragment string) {
+			if prompt.tools {
+				send(&Message{ToolCalls: []ToolCall{{Index: &index, Function: FunctionCall{Arguments: fragment}}}})
+			} else if index == 0 {
+				send(&Message{FunctionCall: map[string]interface{}{"arguments": fragment}})
+			}
+		}
+		calls.onMessage = func(fragment string) {
+			send(&Message{Content: &fragment})
+		}
+
+		var callErr *FunctionCallError
+		_, err := ComputeChoices(req, s, config, o, loader, func(s string, c *[]Choice) {
+			if callErr == nil {
+				callErr = validateFunctionCalls(prompt.functions, parseFunctionCalls(s))
+			}
+		}, func(s string) bool {
+			calls.Write(s)
+			// the calls are sent while written, and stop being sent once the client went away
+			return req.Context.Err() == nil
+		})
+		switch {
+		case req.Context.Err() != nil:
+			log.Debug().Msgf("Stream canceled: %v", req.Context.Err())
+		case err != nil:
+			log.Error().Msgf("inference error: %s", err.Error())
+			*streamErr = err
+		case callErr != nil:
+			callErr.Attempts = 1
+			log.Debug().Msgf("Invalid function call streamed: %v", callErr)
+			*streamErr = callErr
+		case calls.calls > 0 && prompt.tools:
+			*finishReason = "tool_calls"
+		case calls.calls > 0:
+			*finishReason = "function_call"
+		case !calls.answered:
+			log.Debug().Msgf("No action received from LLM, without a message, computing a reply")
+			// Otherwise ask the LLM to understand the JSON output and the context, and stream a message
+			config.Grammar = ""
+			ComputeChoices(req, s, config, o, loader, func(s string, c *[]Choice) {}, func(s string) bool {
+				return send(&Message{Content: &s})
+			})
+		}
 		close(responses)
 	}
 	return func(c *fiber.Ctx) error {
-		processFunctions := false
-		funcs := grammar.Functions{}
 		modelFile, input, err := readInput(c, o, true)
 		if err != nil {
 			return fmt.Errorf("failed reading parameters from request:%w", err)
@@ -55,142 +126,16 @@ func ChatEndpoint(cm *config.ConfigLoader, o *options.Option) func(c *fiber.Ctx)
 		}
 		log.Debug().Msgf("Configuration read: %+v", config)
 
-		// Allow the user to set custom actions via config file
-		// to be "embedded" in each model
-		noActionName := "answer"
-		noActionDescription := "use this action to answer without performing any action"
-
-		if config.FunctionsConfig.NoActionFunctionName != "" {
-			noActionName = config.FunctionsConfig.NoActionFunctionName
-		}
-		if config.FunctionsConfig.NoActionDescriptionName != "" {
-			noActionDescription = config.FunctionsConfig.NoActionDescriptionName
-		}
-
-		// process functions if we have any defined or if we have a function call string
-		if len(input.Functions) > 0 && config.ShouldUseFunctions() {
-			log.Debug().Msgf("Response needs to process functions")
-
-			processFunctions = true
-
-			noActionGrammar := grammar.Function{
-				Name:        noActionName,
-				Description: noActionDescription,
-				Parameters: map[string]interface{}{
-					"properties": map[string]interface{}{
-						"message": map[string]interface{}{
-							"type":        "string",
-							"description": "The message to reply the user with",
-						}},
-				},
-			}
-
-			// Append the no action function
-			funcs = append(funcs, input.Functions...)
-			if !config.FunctionsConfig.DisableNoAction {
-				funcs = append(funcs, noActionGrammar)
-			}
-
-			// Force picking one of the functions by the request
-			if config.FunctionToCall() != "" {
-				funcs = funcs.Select(config.FunctionToCall())
-			}
-
-			// Update input grammar
-			jsStruct := funcs.ToJSONStructure()
-			config.Grammar = jsStruct.Grammar("")
-		} else if input.JSONFunctionGrammarObject != nil {
-			config.Grammar = input.JSONFunctionGrammarObject.Grammar("")
+		prompt, err := buildChatPrompt(config, input, o.Loader)
+		if err != nil {
+			return err
 		}
+		processFunctions, predInput := prompt.processFunctions, prompt.prompt
 
-		// functions are not supported in stream mode (yet?)
-		toStream := input.Stream && !processFunctions
+		toStream := input.Stream
 
 		log.Debug().Msgf("Parameters: %+v", config)
 
-		var predInput string
-
-		suppressConfigSystemPrompt := false
-		mess := []string{}
-		for messageIndex, i := range input.Messages {
-			var content string
-			role := i.Role
-
-			// if function call, we might want to customize the role so we can display better that the "assistant called a json action"
-			// if an "assistant_function_call" role is defined, we use it, otherwise we use the role that is passed by in the request
-			if i.FunctionCall != nil && i.Role == "assistant" {
-				roleFn := "assistant_function_call"
-				r := config.Roles[roleFn]
-				if r != "" {
-					role = roleFn
-				}
-			}
-			r := config.Roles[role]
-			contentExists := i.Content != nil && *i.Content != ""
-			// First attempt to populate content via a chat message specific template
-			if config.TemplateConfig.ChatMessage != "" {
-				chatMessageData := model.ChatMessageTemplateData{
-					SystemPrompt: config.SystemPrompt,
-					Role:         r,
-					RoleName:     role,
-					Content:      *i.Content,
-					MessageIndex: messageIndex,
-				}
-				templatedChatMessage, err := o.Loader.EvaluateTemplateForChatMessage(config.TemplateConfig.ChatMessage, chatMessageData)
-				if err != nil {
-					log.Error().Msgf("error processing message %+v using template \"%s\": %v. Skipping!", chatMessageData, config.TemplateConfig.ChatMessage, err)
-				} else {
-					if templatedChatMessage == "" {
-						log.Warn().Msgf("template \"%s\" produced blank output for %+v. Skipping!", config.TemplateConfig.ChatMessage, chatMessageData)
-						continue // TODO: This continue is here intentionally to skip over the line `mess = append(mess, content)` below, and to prevent the sprintf
-					}
-					log.Debug().Msgf("templated message for chat: %s", templatedChatMessage)
-					content = templatedChatMessage
-				}
-			}
-			// If this model doesn't have such a template, or if that template fails to return a value, template at the message level.
-			if content == "" {
-				if r != "" {
-					if contentExists {
-						content = fmt.Sprint(r, " ", *i.Content)
-					}
-					if i.FunctionCall != nil {
-						j, err := json.Marshal(i.FunctionCall)
-						if err == nil {
-							if contentExists {
-								content += "\n" + fmt.Sprint(r, " ", string(j))
-							} else {
-								content = fmt.Sprint(r, " ", string(j))
-							}
-						}
-					}
-				} else {
-					if contentExists {
-						content = fmt.Sprint(*i.Content)
-					}
-					if i.FunctionCall != nil {
-						j, err := json.Marshal(i.FunctionCall)
-						if err == nil {
-							if contentExists {
-								content += "\n" + string(j)
-							} else {
-								content = string(j)
-							}
-						}
-					}
-				}
-				// Special Handling: System. We care if it was printed at all, not the r branch, so check seperately
-				if contentExists && role == "system" {
-					suppressConfigSystemPrompt = true
-				}
-			}
-
-			mess = append(mess, content)
-		}
-
-		predInput = strings.Join(mess, "\n")
-		log.Debug().Msgf("Prompt (before templating): %s", predInput)
-
 		if toStream {
 			log.Debug().Msgf("Stream request received")
 			c.Context().SetContentType("text/event-stream")
@@ -201,39 +146,27 @@ func ChatEndpoint(cm *config.ConfigLoader, o *options.Option) func(c *fiber.Ctx)
 			c.Set("Transfer-Encoding", "chunked")
 		}
 
-		templateFile := config.Model
-
-		if config.TemplateConfig.Chat != "" && !processFunctions {
-			templateFile = config.TemplateConfig.Chat
-		}
-
-		if config.TemplateConfig.Functions != "" && processFunctions {
-			templateFile = config.TemplateConfig.Functions
-		}
-
-		// A model can have a "file.bin.tmpl" file associated with a prompt template prefix
-		templatedInput, err := o.Loader.EvaluateTemplateForPrompt(model.ChatPromptTemplate, templateFile, model.PromptTemplateData{
-			SystemPrompt:         config.SystemPrompt,
-			SuppressSystemPrompt: suppressConfigSystemPrompt,
-			Input:                predInput,
-			Functions:            funcs,
-		})
-		if err == nil {
-			predInput = templatedInput
-			log.Debug().Msgf("Template found, input modified to: %s", predInput)
-		} else {
-			log.Debug().Msgf("Template failed loading: %s", err.Error())
-		}
-
 		log.Debug().Msgf("Prompt (after templating): %s", predInput)
 		if processFunctions {
 			log.Debug().Msgf("Grammar: %+v", config.Grammar)
 		}
 
 		if toStream {
+			// the status can't be changed once the stream started
+			if err := backend.CheckQueue(*config, o); err != nil {
+				return err
+			}
+
 			responses := make(chan OpenAIResponse)
+			// set once the responses are all sent
+			finishReason := "stop"
+			var streamErr error
 
-			go process(predInput, input, config, o.Loader, responses)
+			if processFunctions {
+				go processTools(prompt, predInput, input, config, o.Loader, responses, &finishReason, &streamErr)
+			} else {
+				go process(predInput, input, config, o.Loader, responses, &streamErr)
+			}
 
 			c.Context().SetBodyStreamWriter(fasthttp.StreamWriter(func(w *bufio.Writer) {
 
@@ -246,56 +179,63 @@ func ChatEndpoint(cm *config.ConfigLoader, o *options.Option) func(c *fiber.Ctx)
 					_, err := fmt.Fprintf(w, "data: %v\n", buf.String())
 					if err != nil {
 						log.Debug().Msgf("Sending chunk failed: %v", err)
+						// the producer stops once the request is canceled, then closes the responses
 						input.Cancel()
-						break
+						for range responses {
+						}
+						return
 					}
 					w.Flush()
 				}
 
-				resp := &OpenAIResponse{
-					Model: input.Model, // we have to return what the user sent here, due to OpenAI spec.
-					Choices: []Choice{
-						{
-							FinishReason: "stop",
-							Index:        0,
-							Delta:        &Message{Content: &emptyMessage},
-						}},
-					Object: "chat.completion.chunk",
-				}
-				respData, _ := json.Marshal(resp)
-
-				w.WriteString(fmt.Sprintf("data: %s\n\n", respData))
-				w.WriteString("data: [DONE]\n\n")
-				w.Flush()
+				writeStreamEnd(w, input.Model, finishReason, streamErr)
 			}))
 			return nil
 		}
 
+		// set if the model fails to call the functions as their parameters require
+		var callErr error
 		result, err := ComputeChoices(input, predInput, config, o, o.Loader, func(s string, c *[]Choice) {
 			if processFunctions {
 				// As we have to change the result before processing, we can't stream the answer (yet?)
-				ss := map[string]interface{}{}
-				// This prevent newlines to break JSON parsing for clients
-				s = utils.EscapeNewLines(s)
-				json.Unmarshal([]byte(s), &ss)
-				log.Debug().Msgf("Function return: %s %+v", s, ss)
-
-				// The grammar defines the function name as "function", while OpenAI returns "name"
-				func_name := ss["function"]
-				// Similarly, while here arguments is a map[string]interface{}, OpenAI actually want a stringified object
-				args := ss["arguments"] // arguments needs to be a string, but we return an object from the grammar result (TODO: fix)
-				d, _ := json.Marshal(args)
+				calls, err := computeFunctionCalls(s, prompt.functions, config.FunctionsConfig.ValidationRetries, config.Temperature, func(temperature float64) (string, error) {
+					retryConfig := *config
+					retryConfig.Temperature = temperature
+					predFunc, err := backend.ModelInference(input.Context, predInput, o.Loader, retryConfig, o, nil)
+					if err != nil {
+						return "", err
+					}
+					prediction, err := predFunc()
+					if err != nil {
+						return "", err
+					}
+					return backend.Finetune(retryConfig, predInput, prediction), nil
+				})
+				if err != nil {
+					if callErr == nil {
+						callErr = err
+					}
+					return
+				}
 
-				ss["arguments"] = string(d)
-				ss["name"] = func_name
+				// the no action function only means something when the model calls nothing else
+				actions := []FunctionCall{}
+				noAction := FunctionCall{}
+				for _, call := range calls {
+					if call.Name == prompt.noActionName {
+						noAction = call
+					} else {
+						actions = append(actions, call)
+					}
+				}
 
 				// if do nothing, reply with a message
-				if func_name == noActionName {
+				if len(actions) == 0 {
 					log.Debug().Msgf("nothing to do, computing a reply")
 
 					// If there is a message that the LLM already sends as part of the JSON reply, use it
 					arguments := map[string]interface{}{}
-					json.Unmarshal([]byte(d), &arguments)
+					json.Unmarshal([]byte(noAction.Arguments), &arguments)
 					m, exists := arguments["message"]
 					if exists {
 						switch message := m.(type) {
@@ -329,11 +269,26 @@ func ChatEndpoint(cm *config.ConfigLoader, o *options.Option) func(c *fiber.Ctx)
 
 					prediction = backend.Finetune(*config, predInput, prediction)
 					*c = append(*c, Choice{Message: &Message{Role: "assistant", Content: &prediction}})
+				} else if prompt.tools {
+					// otherwise reply with the tool calls
+					toolCalls := []ToolCall{}
+					for _, call := range actions {
+						toolCalls = append(toolCalls, ToolCall{ID: newToolCallID(), Type: "function", Function: call})
+					}
+					*c = append(*c, Choice{
+						FinishReason: "tool_calls",
+						Message:      &Message{Role: "assistant", ToolCalls: toolCalls},
+					})
 				} else {
-					// otherwise reply with the function call
+					// or with the function call, for requests using the functions API
+					call := actions[0]
 					*c = append(*c, Choice{
 						FinishReason: "function_call",
-						Message:      &Message{Role: "assistant", FunctionCall: ss},
+						Message: &Message{Role: "assistant", FunctionCall: map[string]interface{}{
+							"function":  call.Name,
+							"name":      call.Name,
+							"arguments": call.Arguments,
+						}},
 					})
 				}
 
@@ -344,6 +299,16 @@ func ChatEndpoint(cm *config.ConfigLoader, o *options.Option) func(c *fiber.Ctx)
 		if err != nil {
 			return err
 		}
+		if callErr != nil {
+			return callErr
+		}
+		if !processFunctions {
+			for _, choice := range result {
+				if err := validateResponseFormat(input, *choice.Message.Content); err != nil {
+					return err
+				}
+			}
+		}
 
 		resp := &OpenAIResponse{
 			Model:   input.Model, // we have to return what the user sent here, due to OpenAI spec.
@@ -357,3 +322,39 @@ func ChatEndpoint(cm *config.ConfigLoader, o *options.Option) func(c *fiber.Ctx)
 		return c.JSON(resp)
 	}
 }
+
+// writeStreamEnd ends a chat completion stream with the finish reason, or with the error that interrupted it,
+// as an error event as OpenAI sends them
+func writeStreamEnd(w *bufio.Writer, model, finishReason string, err error) {
+	var respData []byte
+	if err != nil {
+		respData = streamErrorData(err)
+	} else {
+		emptyMessage := ""
+		respData, _ = json.Marshal(&OpenAIResponse{
+			Model: model, // we have to return what the user sent here, due to OpenAI spec.
+			Choices: []Choice{
+				{
+					FinishReason: finishReason,
+					Index:        0,
+					Delta:        &Message{Content: &emptyMessage},
+				}},
+			Object: "chat.completion.chunk",
+		})
+	}
+
+	w.WriteString(fmt.Sprintf("data: %s\n\n", respData))
+	w.WriteString("data: [DONE]\n\n")
+	w.Flush()
+}
+
+// streamErrorData encodes the error that interrupted a stream as the error event
+func streamErrorData(err error) []byte {
+	apiErr := &APIError{Message: err.Error(), Code: fiber.StatusInternalServerError}
+	var callErr *FunctionCallError
+	if errors.As(err, &callErr) {
+		apiErr.Code, apiErr.Type = fiber.StatusUnprocessableEntity, InvalidFunctionCallType
+	}
+	respData, _ := json.Marshal(ErrorResponse{Error: apiErr})
+	return respData
+}
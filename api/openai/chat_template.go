@@ -0,0 +1,49 @@
+package openai
+
+import (
+	config "github.com/go-skynet/LocalAI/api/config"
+	model "github.com/go-skynet/LocalAI/pkg/model"
+	"github.com/rs/zerolog/log"
+)
+
+// chatTemplatePrompt builds the prompt from the messages with the Jinja chat template of the model.
+// The system prompt of the config is prepended if the request has none.
+func chatTemplatePrompt(config *config.Config, input *OpenAIRequest, loader *model.ModelLoader) (string, error) {
+	messages := []map[string]interface{}{}
+	if config.SystemPrompt != "" && (len(input.Messages) == 0 || input.Messages[0].Role != "system") {
+		messages = append(messages, map[string]interface{}{"role": "system", "content": config.SystemPrompt})
+	}
+	for _, m := range input.Messages {
+		message := map[string]interface{}{"role": m.Role, "content": ""}
+		if m.Content != nil {
+			message["content"] = *m.Content
+		}
+		if m.FunctionCall != nil {
+			message["function_call"] = m.FunctionCall
+		}
+		if len(m.ToolCalls) > 0 {
+			message["tool_calls"] = m.ToolCalls
+		}
+		if m.ToolCallID != "" {
+			message["tool_call_id"] = m.ToolCallID
+		}
+		if m.Name != "" {
+			message["name"] = m.Name
+		}
+		messages = append(messages, message)
+	}
+
+	vars := map[string]interface{}{
+		"messages":              messages,
+		"add_generation_prompt": true,
+	}
+	if len(input.Tools) > 0 {
+		vars["tools"] = input.Tools
+	}
+	prompt, err := loader.EvaluateJinjaChatTemplate(config.TemplateConfig.ChatTemplate, config.Model, vars)
+	if err != nil {
+		return "", err
+	}
+	log.Debug().Msgf("Prompt (chat template): %s", prompt)
+	return prompt, nil
+}
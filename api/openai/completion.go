@@ -7,6 +7,7 @@ import (
 	"errors"
 	"fmt"
 
+	"github.com/go-skynet/LocalAI/api/backend"
 	config "github.com/go-skynet/LocalAI/api/config"
 	"github.com/go-skynet/LocalAI/api/options"
 	model "github.com/go-skynet/LocalAI/pkg/model"
@@ -17,8 +18,13 @@ import (
 
 // https://platform.openai.com/docs/api-reference/completions
 func CompletionEndpoint(cm *config.ConfigLoader, o *options.Option) func(c *fiber.Ctx) error {
-	process := func(s string, req *OpenAIRequest, config *config.Config, loader *model.ModelLoader, responses chan OpenAIResponse) {
-		ComputeChoices(req, s, config, o, loader, func(s string, c *[]Choice) {}, func(s string) bool {
+	// process streams the text, and validates it against the response format of the request once complete
+	process := func(s string, req *OpenAIRequest, config *config.Config, loader *model.ModelLoader, responses chan OpenAIResponse, streamErr *error) {
+		output := &streamedOutput{input: req}
+		_, err := ComputeChoices(req, s, config, o, loader, func(s string, c *[]Choice) {
+			output.validate()
+		}, func(s string) bool {
+			output.write(s)
 			resp := OpenAIResponse{
 				Model: req.Model, // we have to return what the user sent here, due to OpenAI spec.
 				Choices: []Choice{
@@ -31,9 +37,9 @@ func CompletionEndpoint(cm *config.ConfigLoader, o *options.Option) func(c *fibe
 			}
 			log.Debug().Msgf("Sending goroutine: %s", s)
 
-			responses <- resp
-			return true
+			return sendResponse(req, responses, resp)
 		})
+		*streamErr = streamResult(req, err, output)
 		close(responses)
 	}
 
@@ -62,31 +68,25 @@ func CompletionEndpoint(cm *config.ConfigLoader, o *options.Option) func(c *fibe
 			c.Set("Transfer-Encoding", "chunked")
 		}
 
-		templateFile := config.Model
-
-		if config.TemplateConfig.Completion != "" {
-			templateFile = config.TemplateConfig.Completion
-		}
+		prompts, _, _ := buildCompletionPrompts(config, o.Loader)
 
 		if input.Stream {
-			if len(config.PromptStrings) > 1 {
+			if len(prompts) > 1 {
 				return errors.New("cannot handle more than 1 `PromptStrings` when Streaming")
 			}
 
-			predInput := config.PromptStrings[0]
+			predInput := prompts[0]
 
-			// A model can have a "file.bin.tmpl" file associated with a prompt template prefix
-			templatedInput, err := o.Loader.EvaluateTemplateForPrompt(model.CompletionPromptTemplate, templateFile, model.PromptTemplateData{
-				Input: predInput,
-			})
-			if err == nil {
-				predInput = templatedInput
-				log.Debug().Msgf("Template found, input modified to: %s", predInput)
+			// the status can't be changed once the stream started
+			if err := backend.CheckQueue(*config, o); err != nil {
+				return err
 			}
 
 			responses := make(chan OpenAIResponse)
+			// set once the responses are all sent
+			var streamErr error
 
-			go process(predInput, input, config, o.Loader, responses)
+			go process(predInput, input, config, o.Loader, responses, &streamErr)
 
 			c.Context().SetBodyStreamWriter(fasthttp.StreamWriter(func(w *bufio.Writer) {
 
@@ -96,47 +96,36 @@ func CompletionEndpoint(cm *config.ConfigLoader, o *options.Option) func(c *fibe
 					enc.Encode(ev)
 
 					log.Debug().Msgf("Sending chunk: %s", buf.String())
-					fmt.Fprintf(w, "data: %v\n", buf.String())
+					_, err := fmt.Fprintf(w, "data: %v\n", buf.String())
+					if err != nil {
+						log.Debug().Msgf("Sending chunk failed: %v", err)
+						// the producer stops once the request is canceled, then closes the responses
+						input.Cancel()
+						for range responses {
+						}
+						return
+					}
 					w.Flush()
 				}
 
-				resp := &OpenAIResponse{
-					Model: input.Model, // we have to return what the user sent here, due to OpenAI spec.
-					Choices: []Choice{
-						{
-							Index:        0,
-							FinishReason: "stop",
-						},
-					},
-					Object: "text_completion",
-				}
-				respData, _ := json.Marshal(resp)
-
-				w.WriteString(fmt.Sprintf("data: %s\n\n", respData))
-				w.WriteString("data: [DONE]\n\n")
-				w.Flush()
+				writeCompletionStreamEnd(w, input.Model, streamErr)
 			}))
 			return nil
 		}
 
 		var result []Choice
-		for k, i := range config.PromptStrings {
-			// A model can have a "file.bin.tmpl" file associated with a prompt template prefix
-			templatedInput, err := o.Loader.EvaluateTemplateForPrompt(model.CompletionPromptTemplate, templateFile, model.PromptTemplateData{
-				SystemPrompt: config.SystemPrompt,
-				Input:        i,
-			})
-			if err == nil {
-				i = templatedInput
-				log.Debug().Msgf("Template found, input modified to: %s", i)
-			}
-
+		for k, i := range prompts {
 			r, err := ComputeChoices(input, i, config, o, o.Loader, func(s string, c *[]Choice) {
 				*c = append(*c, Choice{Text: s, FinishReason: "stop", Index: k})
 			}, nil)
 			if err != nil {
 				return err
 			}
+			for _, choice := range r {
+				if err := validateResponseFormat(input, choice.Text); err != nil {
+					return err
+				}
+			}
 
 			result = append(result, r...)
 		}
@@ -154,3 +143,26 @@ func CompletionEndpoint(cm *config.ConfigLoader, o *options.Option) func(c *fibe
 		return c.JSON(resp)
 	}
 }
+
+// writeCompletionStreamEnd ends a text completion stream, with the error that interrupted it if any
+func writeCompletionStreamEnd(w *bufio.Writer, model string, err error) {
+	var respData []byte
+	if err != nil {
+		respData = streamErrorData(err)
+	} else {
+		respData, _ = json.Marshal(&OpenAIResponse{
+			Model: model, // we have to return what the user sent here, due to OpenAI spec.
+			Choices: []Choice{
+				{
+					Index:        0,
+					FinishReason: "stop",
+				},
+			},
+			Object: "text_completion",
+		})
+	}
+
+	w.WriteString(fmt.Sprintf("data: %s\n\n", respData))
+	w.WriteString("data: [DONE]\n\n")
+	w.Flush()
+}
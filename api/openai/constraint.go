@@ -0,0 +1,60 @@
+package openai
+
+import (
+	"fmt"
+	"strings"
+
+	config "github.com/go-skynet/LocalAI/api/config"
+	"github.com/go-skynet/LocalAI/pkg/grammar"
+	"github.com/gofiber/fiber/v2"
+)
+
+// setConstraintGrammar sets the grammar constraining the output to the regular expression or to the choices
+// of the request, if any. As for the response formats, the backend must enforce it.
+func setConstraintGrammar(config *config.Config, input *OpenAIRequest) error {
+	constraints := []string{}
+	if input.Grammar != "" {
+		constraints = append(constraints, "grammar")
+	}
+	if input.Regex != "" {
+		constraints = append(constraints, "regex")
+	}
+	if len(input.Choices) > 0 {
+		constraints = append(constraints, "choices")
+	}
+	if schema, _ := input.ResponseFormat.schema(); schema != nil {
+		constraints = append(constraints, "response_format")
+	}
+	// the grammar of the functions replaces the others when the model may call them
+	switch {
+	case len(input.functions()) > 0 && config.ShouldUseFunctions():
+		if len(input.Tools) > 0 {
+			constraints = append(constraints, "tools")
+		} else {
+			constraints = append(constraints, "functions")
+		}
+	case input.JSONFunctionGrammarObject != nil:
+		constraints = append(constraints, "grammar_json_functions")
+	}
+	if len(constraints) > 1 {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("only one of grammar, regex, choices, response_format and the functions can constrain the output, got %s", strings.Join(constraints, ", ")))
+	}
+
+	var g string
+	var err error
+	switch {
+	case input.Regex != "":
+		if g, err = grammar.RegexGrammar(input.Regex); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("regex: %v", err))
+		}
+	case len(input.Choices) > 0:
+		if g, err = grammar.ChoicesGrammar(input.Choices); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("choices: %v", err))
+		}
+	default:
+		return nil
+	}
+	config.Grammar = g
+	config.SetStrictGrammar(true)
+	return nil
+}
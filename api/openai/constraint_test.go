@@ -0,0 +1,61 @@
+package openai
+
+import (
+	config "github.com/go-skynet/LocalAI/api/config"
+	"github.com/go-skynet/LocalAI/pkg/grammar"
+	"github.com/gofiber/fiber/v2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Output constraints", func() {
+	It("sets the grammar of the regex or of the choices", func() {
+		c := &config.Config{}
+		Expect(setConstraintGrammar(c, &OpenAIRequest{Regex: `\d{3}-\d{4}`})).To(Succeed())
+		Expect(c.Grammar).To(Equal(`root ::= [0-9] [0-9] [0-9] "-" [0-9] [0-9] [0-9] [0-9]`))
+		Expect(c.StrictGrammar()).To(BeTrue())
+		_, err := grammar.ParseGrammar(c.Grammar)
+		Expect(err).ToNot(HaveOccurred())
+
+		c = &config.Config{}
+		Expect(setConstraintGrammar(c, &OpenAIRequest{Choices: []string{"yes", `"maybe"`}})).To(Succeed())
+		Expect(c.Grammar).To(Equal(`root ::= "yes" | "\"maybe\""`))
+		Expect(c.StrictGrammar()).To(BeTrue())
+
+		c = &config.Config{}
+		c.Grammar = `root ::= "x"`
+		Expect(setConstraintGrammar(c, &OpenAIRequest{})).To(Succeed())
+		Expect(c.Grammar).To(Equal(`root ::= "x"`))
+		Expect(c.StrictGrammar()).To(BeFalse())
+	})
+
+	It("rejects invalid or conflicting constraints", func() {
+		var e *fiber.Error
+		err := setConstraintGrammar(&config.Config{}, &OpenAIRequest{Regex: `a(`})
+		Expect(err).To(BeAssignableToTypeOf(e))
+		Expect(err).To(MatchError(ContainSubstring("regex: invalid pattern")))
+
+		err = setConstraintGrammar(&config.Config{}, &OpenAIRequest{Regex: `\bword\b`})
+		Expect(err).To(MatchError(ContainSubstring("word boundaries are not supported")))
+
+		err = setConstraintGrammar(&config.Config{}, &OpenAIRequest{Grammar: `root ::= "x"`, Regex: "x"})
+		Expect(err).To(BeAssignableToTypeOf(e))
+		Expect(err).To(MatchError(ContainSubstring("got grammar, regex")))
+
+		err = setConstraintGrammar(&config.Config{}, &OpenAIRequest{Choices: []string{"x"}, ResponseFormat: ResponseFormat{Type: ResponseFormatJSONObject}})
+		Expect(err).To(MatchError(ContainSubstring("got choices, response_format")))
+
+		tools := []Tool{{Type: "function", Function: grammar.Function{Name: "search"}}}
+		err = setConstraintGrammar(&config.Config{}, &OpenAIRequest{Tools: tools, ResponseFormat: ResponseFormat{Type: ResponseFormatJSONObject}})
+		Expect(err).To(BeAssignableToTypeOf(e))
+		Expect(err).To(MatchError(ContainSubstring("got response_format, tools")))
+
+		err = setConstraintGrammar(&config.Config{}, &OpenAIRequest{JSONFunctionGrammarObject: &grammar.JSONFunctionStructure{}, Regex: "x"})
+		Expect(err).To(MatchError(ContainSubstring("got regex, grammar_json_functions")))
+
+		// the functions don't constrain the output when the model must not call them
+		c := &config.Config{}
+		c.SetFunctionCallString("none")
+		Expect(setConstraintGrammar(c, &OpenAIRequest{Tools: tools, ResponseFormat: ResponseFormat{Type: ResponseFormatJSONObject}})).To(Succeed())
+	})
+})
@@ -0,0 +1,389 @@
+package openai
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/go-skynet/LocalAI/pkg/grammar"
+	"github.com/go-skynet/LocalAI/pkg/utils"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// functions returns the functions the model may call: the functions of the request, then the functions of its tools
+func (r *OpenAIRequest) functions() grammar.Functions {
+	functions := append(grammar.Functions{}, r.Functions...)
+	for _, t := range r.Tools {
+		if t.Type == "function" {
+			functions = append(functions, t.Function)
+		}
+	}
+	return functions
+}
+
+// parseFunctionCalls reads the calls in an output constrained by the functions grammar:
+// a single {"function": ..., "arguments": {...}} object, or an array of them when parallel calls are allowed
+func parseFunctionCalls(s string) []FunctionCall {
+	// This prevent newlines to break JSON parsing for clients
+	s = strings.TrimSpace(utils.EscapeNewLines(s))
+
+	var results []map[string]interface{}
+	if strings.HasPrefix(s, "[") {
+		if err := json.Unmarshal([]byte(s), &results); err != nil {
+			log.Debug().Msgf("Function return is not valid JSON: %s: %v", s, err)
+		}
+	} else {
+		ss := map[string]interface{}{}
+		if err := json.Unmarshal([]byte(s), &ss); err != nil {
+			log.Debug().Msgf("Function return is not valid JSON: %s: %v", s, err)
+		}
+		results = append(results, ss)
+	}
+	log.Debug().Msgf("Function return: %s %+v", s, results)
+
+	calls := []FunctionCall{}
+	for _, ss := range results {
+		// The grammar defines the function name as "function", while OpenAI returns "name"
+		name, _ := ss["function"].(string)
+		// Similarly, while here arguments is a map[string]interface{}, OpenAI actually want a stringified object
+		d, _ := json.Marshal(ss["arguments"])
+		calls = append(calls, FunctionCall{Name: name, Arguments: string(d)})
+	}
+	return calls
+}
+
+// InvalidFunctionCallType is the type of the API errors reporting a FunctionCallError
+const InvalidFunctionCallType = "invalid_function_call"
+
+// FunctionCallError is an output of the model that doesn't call the functions it was given as their parameters
+// require, even after the retries
+type FunctionCallError struct {
+	// Function is the name of the invalid call, empty if the output isn't a function call
+	Function string
+	Attempts int
+	Err      error
+}
+
+func (e *FunctionCallError) Error() string {
+	if e.Function == "" {
+		return fmt.Sprintf("invalid function call: %v (attempts: %d)", e.Err, e.Attempts)
+	}
+	return fmt.Sprintf("invalid call of function %s: %v (attempts: %d)", e.Function, e.Err, e.Attempts)
+}
+
+func (e *FunctionCallError) Unwrap() error {
+	return e.Err
+}
+
+// validateFunctionCalls checks that the calls are of the functions, with arguments matching their parameters
+func validateFunctionCalls(functions grammar.Functions, calls []FunctionCall) *FunctionCallError {
+	if len(calls) == 0 {
+		return &FunctionCallError{Err: fmt.Errorf("the output is not a function call")}
+	}
+	for _, call := range calls {
+		if call.Name == "" {
+			return &FunctionCallError{Err: fmt.Errorf("the output is not a function call")}
+		}
+		var function *grammar.Function
+		for i := range functions {
+			if functions[i].Name == call.Name {
+				function = &functions[i]
+				break
+			}
+		}
+		if function == nil {
+			return &FunctionCallError{Function: call.Name, Err: fmt.Errorf("unknown function")}
+		}
+		if function.Parameters == nil {
+			continue
+		}
+		if err := grammar.Validate(function.Parameters, []byte(call.Arguments)); err != nil {
+			return &FunctionCallError{Function: call.Name, Err: err}
+		}
+	}
+	return nil
+}
+
+// computeFunctionCalls returns the function calls of the output once they are valid. While they aren't, the output
+// is predicted again, at half the previous temperature, up to the number of retries.
+func computeFunctionCalls(output string, functions grammar.Functions, retries int, temperature float64, predict func(temperature float64) (string, error)) ([]FunctionCall, error) {
+	for attempt := 1; ; attempt++ {
+		calls := parseFunctionCalls(output)
+		err := validateFunctionCalls(functions, calls)
+		if err == nil {
+			return calls, nil
+		}
+		if attempt > retries {
+			err.Attempts = attempt
+			return nil, err
+		}
+
+		temperature /= 2
+		log.Debug().Msgf("Invalid function call (%v), computing it again at temperature %g", err, temperature)
+		var predictErr error
+		if output, predictErr = predict(temperature); predictErr != nil {
+			return nil, predictErr
+		}
+	}
+}
+
+// newToolCallID returns a unique ID for a tool call, for the tool message with its result to refer to
+func newToolCallID() string {
+	return "call_" + strings.ReplaceAll(uuid.New().String(), "-", "")
+}
+
+// functionCallStream decodes the calls of an output constrained by the functions grammar while it is generated.
+// Each call is reported as soon as its function name is decoded, then the fragments of its arguments as they come.
+// The arguments of the no action function are not reported: the fragments of its message are, decoded.
+type functionCallStream struct {
+	noActionName string
+	onCall       func(index int, name string)
+	onArguments  func(index int, fragment string)
+	onMessage    func(fragment string)
+
+	// pending is the start of a character at the end of the last token
+	pending string
+
+	stack []*jsonFrame
+	// callDepth is the depth of the call objects: 1 for a single call, 2 in an array of calls
+	callDepth int
+	// calls is the number of calls reported, the no action function aside
+	calls    int
+	noAction bool
+
+	inString, escape bool
+	// str is the string being read, as written in the JSON output
+	str      strings.Builder
+	strValue stringValue
+
+	arguments strings.Builder
+	// messageSent is the length of str already decoded and reported as message
+	messageSent int
+	// answered tells if the no action function was called with a message
+	answered bool
+}
+
+type jsonFrame struct {
+	object    bool
+	expectKey bool
+	key       string
+}
+
+// stringValue is what a JSON string of the output is
+type stringValue int
+
+const (
+	otherString stringValue = iota
+	keyString
+	functionNameString
+	messageString
+)
+
+func newFunctionCallStream(noActionName string) *functionCallStream {
+	return &functionCallStream{
+		noActionName: noActionName,
+		onCall:       func(int, string) {},
+		onArguments:  func(int, string) {},
+		onMessage:    func(string) {},
+	}
+}
+
+// Write decodes the next token of the output
+func (f *functionCallStream) Write(token string) {
+	// a token may end in the middle of a character, which is then decoded with the next token
+	token, f.pending = f.pending+token, ""
+	for i := len(token) - 1; i >= 0 && i >= len(token)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(token[i]) {
+			if !utf8.FullRuneInString(token[i:]) {
+				token, f.pending = token[:i], token[i:]
+			}
+			break
+		}
+	}
+
+	for _, c := range token {
+		f.read(c)
+	}
+	f.flushArguments()
+	f.flushMessage(false)
+}
+
+// callFrame is the call object being read, nil between calls
+func (f *functionCallStream) callFrame() *jsonFrame {
+	if f.callDepth == 0 || len(f.stack) < f.callDepth {
+		return nil
+	}
+	return f.stack[f.callDepth-1]
+}
+
+func (f *functionCallStream) read(c rune) {
+	call := f.callFrame()
+	inArguments := call != nil && call.key == "arguments" && !call.expectKey &&
+		(len(f.stack) > f.callDepth || c == '{')
+	if inArguments && !f.noAction {
+		switch {
+		// the model may write raw newlines in strings, which are not valid JSON
+		case f.inString && c == '\n':
+			f.arguments.WriteString(`\n`)
+		case f.inString && c == '\r':
+			f.arguments.WriteString(`\r`)
+		default:
+			f.arguments.WriteRune(c)
+		}
+	}
+
+	if f.inString {
+		switch {
+		case f.escape:
+			f.escape = false
+		case c == '\\':
+			f.escape = true
+		case c == '"':
+			f.inString = false
+			f.endString()
+			return
+		}
+		switch c {
+		case '\n':
+			f.str.WriteString(`\n`)
+		case '\r':
+			f.str.WriteString(`\r`)
+		default:
+			f.str.WriteRune(c)
+		}
+		return
+	}
+
+	var top *jsonFrame
+	if len(f.stack) > 0 {
+		top = f.stack[len(f.stack)-1]
+	}
+	switch c {
+	case '{', '[':
+		if len(f.stack) == 0 {
+			f.callDepth = 1
+			if c == '[' {
+				f.callDepth = 2
+			}
+		}
+		f.stack = append(f.stack, &jsonFrame{object: c == '{', expectKey: c == '{'})
+		if len(f.stack) == f.callDepth {
+			f.noAction = false
+		}
+	case '}', ']':
+		if len(f.stack) > 0 {
+			f.stack = f.stack[:len(f.stack)-1]
+		}
+	case ':':
+		if top != nil && top.object {
+			top.expectKey = false
+		}
+	case ',':
+		if top != nil && top.object {
+			top.expectKey, top.key = true, ""
+		}
+	case '"':
+		f.inString = true
+		f.str.Reset()
+		f.messageSent = 0
+		switch {
+		case top != nil && top.object && top.expectKey:
+			f.strValue = keyString
+		case call != nil && top == call && call.key == "function":
+			f.strValue = functionNameString
+		case f.noAction && call != nil && call.key == "arguments" && len(f.stack) == f.callDepth+1 && top.key == "message":
+			f.strValue = messageString
+		default:
+			f.strValue = otherString
+		}
+	}
+}
+
+func (f *functionCallStream) endString() {
+	switch f.strValue {
+	case keyString:
+		f.stack[len(f.stack)-1].key = decodeJSONString(f.str.String())
+	case functionNameString:
+		f.flushArguments()
+		name := decodeJSONString(f.str.String())
+		if name == f.noActionName {
+			f.noAction = true
+			return
+		}
+		f.onCall(f.calls, name)
+		f.calls++
+	case messageString:
+		f.flushMessage(true)
+	}
+}
+
+func (f *functionCallStream) flushArguments() {
+	if f.arguments.Len() == 0 {
+		return
+	}
+	f.onArguments(f.calls-1, f.arguments.String())
+	f.arguments.Reset()
+}
+
+// flushMessage reports the part of the message read since the last report. Unless the message is complete,
+// an escape sequence at its end is left for the next report, as it may be incomplete.
+func (f *functionCallStream) flushMessage(complete bool) {
+	if !f.inString && !complete || f.strValue != messageString {
+		return
+	}
+	raw := f.str.String()
+	end := len(raw)
+	if !complete {
+		end = completeEscapes(raw)
+	}
+	if end > f.messageSent {
+		fragment := decodeJSONString(raw[f.messageSent:end])
+		f.messageSent = end
+		if fragment != "" {
+			f.answered = true
+			f.onMessage(fragment)
+		}
+	}
+}
+
+// completeEscapes returns the length of the longest prefix of the content of a JSON string that does not end
+// with an incomplete escape sequence
+func completeEscapes(raw string) int {
+	for i := 0; i < len(raw); {
+		if raw[i] != '\\' {
+			i++
+			continue
+		}
+		if i+1 >= len(raw) {
+			return i
+		}
+		if raw[i+1] != 'u' {
+			i += 2
+			continue
+		}
+		if i+6 > len(raw) {
+			return i
+		}
+		// the high half of a surrogate pair is followed by the low half
+		if r, err := strconv.ParseUint(raw[i+2:i+6], 16, 16); err == nil && r >= 0xd800 && r < 0xdc00 {
+			if i+12 > len(raw) {
+				return i
+			}
+			i += 12
+			continue
+		}
+		i += 6
+	}
+	return len(raw)
+}
+
+func decodeJSONString(raw string) string {
+	var s string
+	if err := json.Unmarshal([]byte(`"`+raw+`"`), &s); err != nil {
+		return raw
+	}
+	return s
+}
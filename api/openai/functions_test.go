@@ -0,0 +1,189 @@
+package openai
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/go-skynet/LocalAI/pkg/grammar"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Function calls", func() {
+	Context("parseFunctionCalls()", func() {
+		It("reads a single call", func() {
+			Expect(parseFunctionCalls(`{"function": "search", "arguments": {"query": "a\nb"}}`)).To(Equal([]FunctionCall{
+				{Name: "search", Arguments: `{"query":"a\nb"}`},
+			}))
+		})
+		It("reads parallel calls", func() {
+			Expect(parseFunctionCalls(` [{"function": "search", "arguments": {"query": "a"}}, {"function": "answer", "arguments": {}}]`)).To(Equal([]FunctionCall{
+				{Name: "search", Arguments: `{"query":"a"}`},
+				{Name: "answer", Arguments: `{}`},
+			}))
+		})
+	})
+
+	Context("computeFunctionCalls()", func() {
+		functions := grammar.Functions{
+			{Name: "search", Parameters: map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{"query": map[string]interface{}{"type": "string"}},
+				"required":   []interface{}{"query"},
+			}},
+			{Name: "answer"},
+		}
+		var temperatures []float64
+		predict := func(outputs ...string) func(float64) (string, error) {
+			temperatures = nil
+			return func(temperature float64) (string, error) {
+				temperatures = append(temperatures, temperature)
+				output := outputs[0]
+				outputs = outputs[1:]
+				return output, nil
+			}
+		}
+
+		It("returns the valid calls", func() {
+			calls, err := computeFunctionCalls(`{"function": "search", "arguments": {"query": "a"}}`, functions, 2, 0.8, predict())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(calls).To(Equal([]FunctionCall{{Name: "search", Arguments: `{"query":"a"}`}}))
+			Expect(temperatures).To(BeEmpty())
+		})
+		It("computes the invalid calls again at a lower temperature", func() {
+			calls, err := computeFunctionCalls(`{"function": "search", "arguments": {}}`, functions, 2, 0.8, predict(
+				`{"function": "search", "arguments": {"query": 1}}`,
+				`[{"function": "search", "arguments": {"query": "a"}}, {"function": "answer", "arguments": {}}]`,
+			))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(calls).To(HaveLen(2))
+			Expect(temperatures).To(Equal([]float64{0.4, 0.2}))
+		})
+		It("returns an error once the retries are over", func() {
+			_, err := computeFunctionCalls(`{"function": "search", "arguments": {}}`, functions, 1, 0.8, predict(`{"function": "delete", "arguments": {}}`))
+			var callErr *FunctionCallError
+			Expect(errors.As(err, &callErr)).To(BeTrue())
+			Expect(callErr.Function).To(Equal("delete"))
+			Expect(err).To(MatchError(`invalid call of function delete: unknown function (attempts: 2)`))
+
+			_, err = computeFunctionCalls(`{"function": "search", "arguments": {}}`, functions, 0, 0.8, predict())
+			Expect(err).To(MatchError(`invalid call of function search: $: missing required property "query" (attempts: 1)`))
+
+			_, err = computeFunctionCalls(`not JSON`, functions, 0, 0.8, predict())
+			Expect(err).To(MatchError(`invalid function call: the output is not a function call (attempts: 1)`))
+		})
+		It("returns the errors of the predictions", func() {
+			_, err := computeFunctionCalls(`{}`, functions, 1, 0.8, func(float64) (string, error) {
+				return "", errors.New("backend error")
+			})
+			Expect(err).To(MatchError("backend error"))
+		})
+	})
+
+	Context("functionCallStream", func() {
+		type call struct {
+			name      string
+			arguments string
+		}
+		var calls []call
+		var message string
+		var stream *functionCallStream
+
+		BeforeEach(func() {
+			calls, message = nil, ""
+			stream = newFunctionCallStream("answer")
+			stream.onCall = func(index int, name string) {
+				Expect(index).To(Equal(len(calls)))
+				calls = append(calls, call{name: name})
+			}
+			stream.onArguments = func(index int, fragment string) {
+				Expect(index).To(Equal(len(calls) - 1))
+				calls[index].arguments += fragment
+			}
+			stream.onMessage = func(fragment string) {
+				message += fragment
+			}
+		})
+
+		// write sends the output in tokens of the given size
+		write := func(output string, size int) {
+			for len(output) > size {
+				stream.Write(output[:size])
+				output = output[size:]
+			}
+			stream.Write(output)
+		}
+
+		for _, size := range []int{1, 3, 1000} {
+			size := size
+			It("streams a call", func() {
+				write(`{"function": "get_weather", "arguments": {"city": "Rome", "days": [1, 2], "unit": {"name": "C"}}}`, size)
+				Expect(calls).To(Equal([]call{{name: "get_weather", arguments: `{"city": "Rome", "days": [1, 2], "unit": {"name": "C"}}`}}))
+				Expect(stream.answered).To(BeFalse())
+			})
+			It("streams parallel calls, leaving out the no action function", func() {
+				write(`[{"function": "get_weather", "arguments": {"city": "Rome"}}, {"function": "answer", "arguments": {"message": "ok"}}, {"function": "get_weather", "arguments": {"city": "Pa\"ris,}"}}]`, size)
+				Expect(calls).To(Equal([]call{
+					{name: "get_weather", arguments: `{"city": "Rome"}`},
+					{name: "get_weather", arguments: `{"city": "Pa\"ris,}"}`},
+				}))
+			})
+			It("streams the message of the no action function", func() {
+				write(`{"function": "answer", "arguments": {"message": "Hi\n\"you\" é😀\\o/"}}`, size)
+				Expect(calls).To(BeEmpty())
+				Expect(message).To(Equal("Hi\n\"you\" é😀\\o/"))
+				Expect(stream.answered).To(BeTrue())
+			})
+		}
+
+		It("escapes the raw newlines of the arguments", func() {
+			write("{\"function\": \"search\", \"arguments\": {\"query\": \"a\nb\"}}", 1)
+			Expect(calls).To(HaveLen(1))
+			var arguments map[string]string
+			Expect(json.Unmarshal([]byte(calls[0].arguments), &arguments)).To(Succeed())
+			Expect(arguments).To(Equal(map[string]string{"query": "a\nb"}))
+		})
+
+		It("reports a no action function without a message", func() {
+			write(`{"function": "answer", "arguments": {}}`, 2)
+			Expect(calls).To(BeEmpty())
+			Expect(message).To(BeEmpty())
+			Expect(stream.answered).To(BeFalse())
+		})
+	})
+})
+
+var _ = Describe("Chat completion streams", func() {
+	end := func(finishReason string, err error) []string {
+		var buf bytes.Buffer
+		w := bufio.NewWriter(&buf)
+		writeStreamEnd(w, "model", finishReason, err)
+		return strings.Split(strings.TrimSuffix(buf.String(), "\n\n"), "\n\n")
+	}
+
+	It("end with the finish reason", func() {
+		events := end("tool_calls", nil)
+		Expect(events).To(HaveLen(2))
+		var resp OpenAIResponse
+		Expect(json.Unmarshal([]byte(strings.TrimPrefix(events[0], "data: ")), &resp)).To(Succeed())
+		Expect(resp.Model).To(Equal("model"))
+		Expect(resp.Choices[0].FinishReason).To(Equal("tool_calls"))
+		Expect(events[1]).To(Equal("data: [DONE]"))
+	})
+
+	It("end with the invalid function calls as an error event", func() {
+		events := end("tool_calls", &FunctionCallError{Function: "delete", Attempts: 1, Err: errors.New("unknown function")})
+		Expect(events).To(HaveLen(2))
+		var resp ErrorResponse
+		Expect(json.Unmarshal([]byte(strings.TrimPrefix(events[0], "data: ")), &resp)).To(Succeed())
+		Expect(resp.Error.Message).To(Equal("invalid call of function delete: unknown function (attempts: 1)"))
+		Expect(resp.Error.Code).To(BeEquivalentTo(422))
+		Expect(resp.Error.Type).To(Equal(InvalidFunctionCallType))
+		Expect(events[1]).To(Equal("data: [DONE]"))
+
+		Expect(end("stop", errors.New("backend error"))[0]).To(Equal(`data: {"error":{"code":500,"message":"backend error","type":""}}`))
+	})
+})
@@ -5,8 +5,34 @@ import (
 	config "github.com/go-skynet/LocalAI/api/config"
 	"github.com/go-skynet/LocalAI/api/options"
 	model "github.com/go-skynet/LocalAI/pkg/model"
+	"github.com/rs/zerolog/log"
 )
 
+// sendResponse sends a response to the writer of the stream, unless the request is canceled as the client went away
+func sendResponse(req *OpenAIRequest, responses chan<- OpenAIResponse, resp OpenAIResponse) bool {
+	select {
+	case responses <- resp:
+		return true
+	case <-req.Context.Done():
+		return false
+	}
+}
+
+// streamResult returns the error to end a stream with, once the choices are computed and streamed
+func streamResult(req *OpenAIRequest, err error, output *streamedOutput) error {
+	switch {
+	case req.Context.Err() != nil:
+		log.Debug().Msgf("Stream canceled: %v", req.Context.Err())
+	case err != nil:
+		log.Error().Msgf("inference error: %s", err.Error())
+		return err
+	case output.err != nil:
+		log.Debug().Msgf("Invalid output streamed: %v", output.err)
+		return output.err
+	}
+	return nil
+}
+
 func ComputeChoices(req *OpenAIRequest, predInput string, config *config.Config, o *options.Option, loader *model.ModelLoader, cb func(string, *[]Choice), tokenCallback func(string) bool) ([]Choice, error) {
 	n := req.N
 	result := []Choice{}
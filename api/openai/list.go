@@ -5,6 +5,7 @@ import (
 
 	config "github.com/go-skynet/LocalAI/api/config"
 	model "github.com/go-skynet/LocalAI/pkg/model"
+	"github.com/go-skynet/LocalAI/pkg/model/gguf"
 	"github.com/gofiber/fiber/v2"
 )
 
@@ -45,7 +46,7 @@ func ListModelsEndpoint(loader *model.ModelLoader, cm *config.ConfigLoader) func
 			}
 
 			if filterFn(c.Name) {
-				dataModels = append(dataModels, OpenAIModel{ID: c.Name, Object: "model"})
+				dataModels = append(dataModels, OpenAIModel{ID: c.Name, Object: "model", Metadata: modelMetadata(loader, c.Model)})
 			}
 		}
 
@@ -53,7 +54,7 @@ func ListModelsEndpoint(loader *model.ModelLoader, cm *config.ConfigLoader) func
 		for _, m := range models {
 			// And only adds them if they shouldn't be skipped.
 			if _, exists := mm[m]; !exists && filterFn(m) {
-				dataModels = append(dataModels, OpenAIModel{ID: m, Object: "model"})
+				dataModels = append(dataModels, OpenAIModel{ID: m, Object: "model", Metadata: modelMetadata(loader, m)})
 			}
 		}
 
@@ -66,3 +67,15 @@ func ListModelsEndpoint(loader *model.ModelLoader, cm *config.ConfigLoader) func
 		})
 	}
 }
+
+// modelMetadata returns the metadata of the model file, or nil if it is not available
+func modelMetadata(loader *model.ModelLoader, modelFile string) *gguf.Metadata {
+	if modelFile == "" {
+		return nil
+	}
+	m, err := loader.ModelMetadata(modelFile)
+	if err != nil {
+		return nil
+	}
+	return m
+}
@@ -0,0 +1,297 @@
+package openai
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	config "github.com/go-skynet/LocalAI/api/config"
+	"github.com/go-skynet/LocalAI/pkg/grammar"
+	model "github.com/go-skynet/LocalAI/pkg/model"
+	"github.com/rs/zerolog/log"
+)
+
+// functionsPropOrder makes the model write the name of the function before its arguments, for the calls to be streamed
+const functionsPropOrder = "function,arguments"
+
+// chatPrompt is the prompt built from a chat request, with what is needed to read the function calls in the response
+type chatPrompt struct {
+	prompt string
+	// template is the template the prompt was rendered with, empty if the model has none
+	template string
+	// templateErr is the error of the template, if it failed and the messages were sent without it
+	templateErr      error
+	processFunctions bool
+	functions        grammar.Functions
+	noActionName     string
+	// tools is set when the functions were given as tools, to reply with tool calls instead of a function call
+	tools bool
+	// parallel is set when the grammar allows the model to call several functions at once
+	parallel bool
+}
+
+// buildChatPrompt builds the prompt of a chat request from its messages and the templates of the model.
+// If functions or tools are given, the grammar constraining the model to call them is set in the config.
+func buildChatPrompt(config *config.Config, input *OpenAIRequest, loader *model.ModelLoader) (*chatPrompt, error) {
+	processFunctions := false
+	funcs := grammar.Functions{}
+
+	inputFunctions := input.functions()
+	useTools := len(input.Tools) > 0
+	parallel := useTools && !config.FunctionsConfig.DisableParallelCalls &&
+		(input.ParallelToolCalls == nil || *input.ParallelToolCalls)
+
+	// Allow the user to set custom actions via config file
+	// to be "embedded" in each model
+	noActionName := "answer"
+	noActionDescription := "use this action to answer without performing any action"
+
+	if config.FunctionsConfig.NoActionFunctionName != "" {
+		noActionName = config.FunctionsConfig.NoActionFunctionName
+	}
+	if config.FunctionsConfig.NoActionDescriptionName != "" {
+		noActionDescription = config.FunctionsConfig.NoActionDescriptionName
+	}
+
+	// process functions if we have any defined or if we have a function call string
+	if len(inputFunctions) > 0 && config.ShouldUseFunctions() {
+		log.Debug().Msgf("Response needs to process functions")
+
+		processFunctions = true
+
+		noActionGrammar := grammar.Function{
+			Name:        noActionName,
+			Description: noActionDescription,
+			Parameters: map[string]interface{}{
+				"properties": map[string]interface{}{
+					"message": map[string]interface{}{
+						"type":        "string",
+						"description": "The message to reply the user with",
+					}},
+			},
+		}
+
+		// Append the no action function
+		funcs = append(funcs, inputFunctions...)
+		if !config.FunctionsConfig.DisableNoAction && !config.ShouldRequireFunctionCall() {
+			funcs = append(funcs, noActionGrammar)
+		}
+
+		// Force picking one of the functions by the request
+		if config.FunctionToCall() != "" {
+			funcs = funcs.Select(config.FunctionToCall())
+			parallel = false
+		}
+
+		// Update input grammar
+		jsStruct := funcs.ToJSONStructure()
+		if parallel {
+			config.Grammar = jsStruct.ParallelGrammar(functionsPropOrder)
+		} else {
+			config.Grammar = jsStruct.Grammar(functionsPropOrder)
+		}
+	} else if input.JSONFunctionGrammarObject != nil {
+		config.Grammar = input.JSONFunctionGrammarObject.Grammar("")
+	}
+
+	p := &chatPrompt{processFunctions: processFunctions, functions: funcs, noActionName: noActionName, tools: useTools, parallel: processFunctions && parallel}
+
+	// a chat template renders the messages itself, unless the functions have a template of their own
+	if config.TemplateConfig.ChatTemplate != "" && !(processFunctions && config.TemplateConfig.Functions != "") {
+		prompt, err := chatTemplatePrompt(config, input, loader)
+		if err != nil {
+			return nil, err
+		}
+		p.prompt, p.template = prompt, model.ChatTemplateName(config.TemplateConfig.ChatTemplate)
+		return p, nil
+	}
+
+	var predInput string
+	suppressConfigSystemPrompt := false
+	mess := []string{}
+	for messageIndex, i := range input.Messages {
+		var content string
+		role := i.Role
+
+		// tool calls are shown to the model the same way as function calls
+		functionCall := i.FunctionCall
+		if len(i.ToolCalls) > 0 {
+			functionCall = i.ToolCalls
+		}
+
+		// if function call, we might want to customize the role so we can display better that the "assistant called a json action"
+		// if an "assistant_function_call" role is defined, we use it, otherwise we use the role that is passed by in the request
+		if functionCall != nil && i.Role == "assistant" {
+			roleFn := "assistant_function_call"
+			r := config.Roles[roleFn]
+			if r != "" {
+				role = roleFn
+			}
+		}
+		// the results of tool calls are the results of function calls for the models configured with a function role
+		if role == "tool" && config.Roles["tool"] == "" && config.Roles["function"] != "" {
+			role = "function"
+		}
+		r := config.Roles[role]
+		contentExists := i.Content != nil && *i.Content != ""
+		// First attempt to populate content via a chat message specific template
+		if config.TemplateConfig.ChatMessage != "" {
+			chatMessageData := model.ChatMessageTemplateData{
+				SystemPrompt: config.SystemPrompt,
+				Role:         r,
+				RoleName:     role,
+				FunctionCall: functionCall,
+				MessageIndex: messageIndex,
+			}
+			if i.Content != nil {
+				chatMessageData.Content = *i.Content
+			}
+			templatedChatMessage, err := loader.EvaluateTemplateForChatMessage(config.TemplateConfig.ChatMessage, chatMessageData)
+			if err != nil {
+				log.Error().Msgf("error processing message %+v using template \"%s\": %v. Skipping!", chatMessageData, config.TemplateConfig.ChatMessage, err)
+				if p.templateErr == nil {
+					p.templateErr = err
+				}
+			} else {
+				if templatedChatMessage == "" {
+					log.Warn().Msgf("template \"%s\" produced blank output for %+v. Skipping!", config.TemplateConfig.ChatMessage, chatMessageData)
+					continue // TODO: This continue is here intentionally to skip over the line `mess = append(mess, content)` below, and to prevent the sprintf
+				}
+				log.Debug().Msgf("templated message for chat: %s", templatedChatMessage)
+				content = templatedChatMessage
+			}
+		}
+		// If this model doesn't have such a template, or if that template fails to return a value, template at the message level.
+		if content == "" {
+			if r != "" {
+				if contentExists {
+					content = fmt.Sprint(r, " ", *i.Content)
+				}
+				if functionCall != nil {
+					j, err := json.Marshal(functionCall)
+					if err == nil {
+						if contentExists {
+							content += "\n" + fmt.Sprint(r, " ", string(j))
+						} else {
+							content = fmt.Sprint(r, " ", string(j))
+						}
+					}
+				}
+			} else {
+				if contentExists {
+					content = fmt.Sprint(*i.Content)
+				}
+				if functionCall != nil {
+					j, err := json.Marshal(functionCall)
+					if err == nil {
+						if contentExists {
+							content += "\n" + string(j)
+						} else {
+							content = string(j)
+						}
+					}
+				}
+			}
+			// Special Handling: System. We care if it was printed at all, not the r branch, so check seperately
+			if contentExists && role == "system" {
+				suppressConfigSystemPrompt = true
+			}
+		}
+
+		mess = append(mess, content)
+	}
+
+	predInput = strings.Join(mess, "\n")
+	log.Debug().Msgf("Prompt (before templating): %s", predInput)
+
+	templateFile := config.Model
+
+	if config.TemplateConfig.Chat != "" && !processFunctions {
+		templateFile = config.TemplateConfig.Chat
+	}
+
+	if config.TemplateConfig.Functions != "" && processFunctions {
+		templateFile = config.TemplateConfig.Functions
+	}
+
+	// A model can have a "file.bin.tmpl" file associated with a prompt template prefix
+	var err error
+	p.prompt, p.template, err = templatePrompt(loader, model.ChatPromptTemplate, templateFile, model.PromptTemplateData{
+		SystemPrompt:         config.SystemPrompt,
+		SuppressSystemPrompt: suppressConfigSystemPrompt,
+		Input:                predInput,
+		Functions:            funcs,
+	})
+	if p.templateErr == nil {
+		p.templateErr = err
+	}
+	return p, nil
+}
+
+// buildCompletionPrompts renders the prompts of a completion request with the completion template of the model.
+// If the template fails, the prompts are the inputs as is, along with the error of the template.
+func buildCompletionPrompts(config *config.Config, loader *model.ModelLoader) ([]string, string, error) {
+	templateFile := config.Model
+	if config.TemplateConfig.Completion != "" {
+		templateFile = config.TemplateConfig.Completion
+	}
+
+	var prompts []string
+	var template string
+	var templateErr error
+	for _, i := range config.PromptStrings {
+		// A model can have a "file.bin.tmpl" file associated with a prompt template prefix
+		prompt, t, err := templatePrompt(loader, model.CompletionPromptTemplate, templateFile, model.PromptTemplateData{
+			SystemPrompt: config.SystemPrompt,
+			Input:        i,
+		})
+		if err != nil && templateErr == nil {
+			templateErr = err
+		}
+		prompts, template = append(prompts, prompt), t
+	}
+	return prompts, template, templateErr
+}
+
+// buildEditPrompts renders the inputs of an edit request with the edit template of the model.
+// If the template fails, the prompts are the inputs as is, along with the error of the template.
+func buildEditPrompts(config *config.Config, input *OpenAIRequest, loader *model.ModelLoader) ([]string, string, error) {
+	templateFile := config.Model
+	if config.TemplateConfig.Edit != "" {
+		templateFile = config.TemplateConfig.Edit
+	}
+
+	var prompts []string
+	var template string
+	var templateErr error
+	for _, i := range config.InputStrings {
+		// A model can have a "file.bin.tmpl" file associated with a prompt template prefix
+		prompt, t, err := templatePrompt(loader, model.EditPromptTemplate, templateFile, model.PromptTemplateData{
+			Input:        i,
+			Instruction:  input.Instruction,
+			SystemPrompt: config.SystemPrompt,
+		})
+		if err != nil && templateErr == nil {
+			templateErr = err
+		}
+		prompts, template = append(prompts, prompt), t
+	}
+	return prompts, template, templateErr
+}
+
+// templatePrompt renders a prompt template, returning the prompt along with the template file.
+// The input is returned as is if the model has no such template, or along with the error if the template fails.
+func templatePrompt(loader *model.ModelLoader, templateType model.TemplateType, templateFile string, data model.PromptTemplateData) (string, string, error) {
+	templatedInput, err := loader.EvaluateTemplateForPrompt(templateType, templateFile, data)
+	if errors.Is(err, model.ErrTemplateNotFound) {
+		log.Debug().Msgf("Template failed loading: %s", err.Error())
+		return data.Input, "", nil
+	}
+	if err != nil {
+		log.Warn().Msgf("Template %s.tmpl failed, using the input as is: %s", templateFile, err.Error())
+		return data.Input, templateFile + ".tmpl", err
+	}
+	log.Debug().Msgf("Template found, input modified to: %s", templatedInput)
+	return templatedInput, templateFile + ".tmpl", nil
+}
@@ -0,0 +1,95 @@
+package openai
+
+import (
+	"fmt"
+
+	config "github.com/go-skynet/LocalAI/api/config"
+	"github.com/go-skynet/LocalAI/api/options"
+	"github.com/gofiber/fiber/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// Kinds of requests the prompt can be rendered for
+const (
+	RenderChat       = "chat"
+	RenderCompletion = "completion"
+	RenderEdit       = "edit"
+)
+
+// PromptRenderResponse is the prompt that a request would send to the backend
+type PromptRenderResponse struct {
+	Model string `json:"model"`
+	// Type is the kind of request: chat, completion or edit
+	Type string `json:"type"`
+	// Prompts holds one prompt per input (completion and edit requests accept several)
+	Prompts []string `json:"prompts"`
+	// Template is the template the prompts were rendered with, empty if the model has none
+	Template string `json:"template,omitempty"`
+	// TemplateError is the error of the template, if it failed: the requests would then send the inputs as is
+	TemplateError string `json:"template_error,omitempty"`
+	Grammar       string `json:"grammar,omitempty"`
+	// Config is the configuration of the model, with the parameters of the request applied, as written in a YAML config file
+	Config map[string]interface{} `json:"config"`
+}
+
+// PromptRenderEndpoint renders the prompt of a chat, completion or edit request without running the model.
+// The kind of request is given by the type query parameter, and otherwise guessed from the body:
+// messages for chat, instruction for edit, completion otherwise.
+func PromptRenderEndpoint(cm *config.ConfigLoader, o *options.Option) func(c *fiber.Ctx) error {
+	return func(c *fiber.Ctx) error {
+		modelFile, input, err := readInput(c, o, true)
+		if err != nil {
+			return fmt.Errorf("failed reading parameters from request:%w", err)
+		}
+		defer input.Cancel()
+
+		config, input, err := readConfig(modelFile, input, cm, o.Loader, o.Debug, o.Threads, o.ContextSize, o.F16)
+		if err != nil {
+			return fmt.Errorf("failed reading parameters from request:%w", err)
+		}
+
+		kind := c.Query("type")
+		if kind == "" {
+			switch {
+			case len(input.Messages) > 0:
+				kind = RenderChat
+			case input.Instruction != "":
+				kind = RenderEdit
+			default:
+				kind = RenderCompletion
+			}
+		}
+
+		resp := PromptRenderResponse{Model: input.Model, Type: kind}
+		var templateErr error
+		switch kind {
+		case RenderChat:
+			prompt, err := buildChatPrompt(config, input, o.Loader)
+			if err != nil {
+				return err
+			}
+			resp.Prompts, resp.Template, templateErr = []string{prompt.prompt}, prompt.template, prompt.templateErr
+		case RenderCompletion:
+			resp.Prompts, resp.Template, templateErr = buildCompletionPrompts(config, o.Loader)
+		case RenderEdit:
+			resp.Prompts, resp.Template, templateErr = buildEditPrompts(config, input, o.Loader)
+		default:
+			return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("invalid type %q: must be one of chat, completion or edit", kind))
+		}
+		if templateErr != nil {
+			resp.TemplateError = templateErr.Error()
+		}
+		resp.Grammar = config.Grammar
+
+		// the config is returned with the names of the YAML files
+		dat, err := yaml.Marshal(config)
+		if err != nil {
+			return err
+		}
+		if err := yaml.Unmarshal(dat, &resp.Config); err != nil {
+			return err
+		}
+
+		return c.JSON(resp)
+	}
+}
@@ -8,13 +8,28 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/go-skynet/LocalAI/api/backend"
 	config "github.com/go-skynet/LocalAI/api/config"
 	options "github.com/go-skynet/LocalAI/api/options"
+	"github.com/go-skynet/LocalAI/pkg/grammar"
 	model "github.com/go-skynet/LocalAI/pkg/model"
 	"github.com/gofiber/fiber/v2"
 	"github.com/rs/zerolog/log"
 )
 
+// requestPriority returns the priority to queue the request with, set by the client or assigned to its API key.
+// Only the valid API keys are given their priority: otherwise, without authentication, any client could send a key to jump the queues.
+func requestPriority(c *fiber.Ctx, o *options.Option) (backend.Priority, error) {
+	bearer := strings.TrimPrefix(c.Get("authorization"), "Bearer ")
+	apiKey := ""
+	for _, key := range o.ApiKeys {
+		if bearer == key {
+			apiKey = key
+		}
+	}
+	return backend.RequestPriority(c.Get(backend.PriorityHeader), apiKey, o.ApiKeyPriorities)
+}
+
 func readInput(c *fiber.Ctx, o *options.Option, randomModel bool) (string, *OpenAIRequest, error) {
 	loader := o.Loader
 	input := new(OpenAIRequest)
@@ -38,6 +53,13 @@ func readInput(c *fiber.Ctx, o *options.Option, randomModel bool) (string, *Open
 
 	// Set model from bearer token, if available
 	bearer := strings.TrimLeft(c.Get("authorization"), "Bearer ")
+
+	priority, err := requestPriority(c, o)
+	if err != nil {
+		return "", nil, fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	input.Context = backend.WithPriority(input.Context, priority)
+
 	bearerExists := bearer != "" && loader.ExistsInModelPath(bearer)
 
 	// If no model was specified, take the first available
@@ -206,6 +228,20 @@ func updateConfig(config *config.Config, input *OpenAIRequest) {
 		config.SetFunctionCallNameString(name)
 	}
 
+	// Can be either "none", "auto", "required" or {"type": "function", "function": {"name": "my_function"}}
+	switch tc := input.ToolChoice.(type) {
+	case string:
+		if tc != "" {
+			config.SetFunctionCallString(tc)
+		}
+	case map[string]interface{}:
+		if fn, ok := tc["function"].(map[string]interface{}); ok {
+			if name, ok := fn["name"].(string); ok {
+				config.SetFunctionCallNameString(name)
+			}
+		}
+	}
+
 	switch p := input.Prompt.(type) {
 	case string:
 		config.PromptStrings = append(config.PromptStrings, p)
@@ -226,7 +262,18 @@ func readConfig(modelFile string, input *OpenAIRequest, cm *config.ConfigLoader,
 
 	defaults := func() {
 		cfg = config.DefaultConfig(modelFile)
+		// the context size set by the operator comes first, as models are often trained with a context
+		// too large for the memory of the host
 		cfg.ContextSize = ctx
+		// without a YAML file, the model header tells the parameters it was trained with
+		if m, err := loader.ModelMetadata(modelFile); err != nil {
+			log.Debug().Msgf("cannot read metadata of model %s: %s", modelFile, err.Error())
+		} else if m != nil {
+			cfg.SetDefaultsFromMetadata(m)
+		}
+		if cfg.ContextSize == 0 {
+			cfg.ContextSize = config.DefaultContextSize
+		}
 		cfg.Threads = threads
 		cfg.F16 = f16
 		cfg.Debug = debug
@@ -251,8 +298,27 @@ func readConfig(modelFile string, input *OpenAIRequest, cm *config.ConfigLoader,
 		cfg = &cfgExisting
 	}
 
+	// A grammar the backend can't parse would fail the prediction
+	if input.Grammar != "" {
+		if _, err := grammar.ParseGrammar(input.Grammar); err != nil {
+			return nil, nil, fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("invalid grammar: %s", err.Error()))
+		}
+	}
+	// The calls are checked against the parameters, which must be schemas the validator and the grammars support
+	if functions := input.functions(); len(functions) > 0 {
+		if err := functions.CheckParameters(); err != nil {
+			return nil, nil, fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("invalid tools: %s", err.Error()))
+		}
+	}
+
 	// Set the parameters for the language model prediction
 	updateConfig(cfg, input)
+	if err := setResponseFormatGrammar(cfg, input); err != nil {
+		return nil, nil, err
+	}
+	if err := setConstraintGrammar(cfg, input); err != nil {
+		return nil, nil, err
+	}
 
 	// Don't allow 0 as setting
 	if cfg.Threads == 0 {
@@ -0,0 +1,97 @@
+package openai
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+
+	"github.com/go-skynet/LocalAI/api/backend"
+	config "github.com/go-skynet/LocalAI/api/config"
+	"github.com/go-skynet/LocalAI/api/options"
+	model "github.com/go-skynet/LocalAI/pkg/model"
+	"github.com/go-skynet/LocalAI/pkg/model/gguf"
+	"github.com/gofiber/fiber/v2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("readConfig()", func() {
+	var loader *model.ModelLoader
+
+	BeforeEach(func() {
+		// a GGUF header with a context length of 32768
+		var header bytes.Buffer
+		write := func(v interface{}) { binary.Write(&header, binary.LittleEndian, v) }
+		str := func(s string) {
+			write(uint64(len(s)))
+			header.WriteString(s)
+		}
+		write(gguf.Magic)
+		write(uint32(2))
+		write(uint64(0))
+		write(uint64(2))
+		str("general.architecture")
+		write(uint32(8))
+		str("llama")
+		str("llama.context_length")
+		write(uint32(4))
+		write(uint32(32768))
+
+		dir := GinkgoT().TempDir()
+		Expect(os.WriteFile(filepath.Join(dir, "model.gguf"), header.Bytes(), 0644)).To(Succeed())
+		loader = model.NewModelLoader(dir)
+	})
+
+	It("prefers the context size of the operator to the one of the model", func() {
+		cfg, _, err := readConfig("model.gguf", &OpenAIRequest{}, config.NewConfigLoader(), loader, false, 4, 512, false)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cfg.ContextSize).To(Equal(512))
+	})
+
+	It("uses the context size of the model by default", func() {
+		o := options.NewOptions()
+		cfg, _, err := readConfig("model.gguf", &OpenAIRequest{}, config.NewConfigLoader(), loader, false, 4, o.ContextSize, false)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cfg.ContextSize).To(Equal(32768))
+	})
+
+	It("falls back to the default context size when neither the operator nor the model set one", func() {
+		o := options.NewOptions()
+		cfg, _, err := readConfig("model.bin", &OpenAIRequest{}, config.NewConfigLoader(), loader, false, 4, o.ContextSize, false)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cfg.ContextSize).To(Equal(config.DefaultContextSize))
+	})
+})
+
+var _ = Describe("requestPriority()", func() {
+	priority := func(o *options.Option, apiKey, header string) backend.Priority {
+		var p backend.Priority
+		app := fiber.New()
+		app.Get("/", func(c *fiber.Ctx) error {
+			var err error
+			p, err = requestPriority(c, o)
+			return err
+		})
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+		req.Header.Set(backend.PriorityHeader, header)
+		_, err := app.Test(req)
+		Expect(err).ToNot(HaveOccurred())
+		return p
+	}
+
+	It("gives the valid API keys their priority", func() {
+		o := options.NewOptions(options.WithApiKeys([]string{"chat"}), options.WithApiKeyPriority("chat", "high"))
+		Expect(priority(o, "chat", "")).To(Equal(backend.PriorityHigh))
+		Expect(priority(o, "other", "high")).To(Equal(backend.PriorityNormal))
+	})
+
+	It("ignores the priorities of the API keys without authentication", func() {
+		o := options.NewOptions(options.WithApiKeyPriority("chat", "high"))
+		Expect(priority(o, "chat", "")).To(Equal(backend.PriorityNormal))
+		Expect(priority(o, "chat", "high")).To(Equal(backend.PriorityNormal))
+		Expect(priority(o, "chat", "low")).To(Equal(backend.PriorityLow))
+	})
+})
@@ -0,0 +1,85 @@
+package openai
+
+import (
+	"fmt"
+	"strings"
+
+	config "github.com/go-skynet/LocalAI/api/config"
+	"github.com/go-skynet/LocalAI/pkg/grammar"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Response formats constraining the output to JSON
+const (
+	ResponseFormatJSONObject = "json_object"
+	ResponseFormatJSONSchema = "json_schema"
+)
+
+// schema returns the JSON schema the output must match, nil if the format doesn't constrain the output to JSON
+func (r ResponseFormat) schema() (map[string]interface{}, error) {
+	switch r.Type {
+	case ResponseFormatJSONObject:
+		return map[string]interface{}{"type": "object"}, nil
+	case ResponseFormatJSONSchema:
+		if r.JSONSchema == nil || r.JSONSchema.Schema == nil {
+			return nil, fiber.NewError(fiber.StatusBadRequest, "response_format: the json_schema format requires a schema")
+		}
+		return r.JSONSchema.Schema, nil
+	}
+	return nil, nil
+}
+
+// setResponseFormatGrammar sets the grammar constraining the output to the JSON response format of the request, if any.
+// Unlike the other grammars, the backend must enforce it.
+func setResponseFormatGrammar(config *config.Config, input *OpenAIRequest) error {
+	schema, err := input.ResponseFormat.schema()
+	if err != nil || schema == nil {
+		return err
+	}
+	// the converter accepts some schemas it can't build a valid grammar from, as the left recursive references
+	g, err := grammar.JSONSchemaGrammar(schema, "")
+	if err == nil {
+		_, err = grammar.ParseGrammar(g)
+	}
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("response_format: %v", err))
+	}
+	config.Grammar = g
+	config.SetStrictGrammar(true)
+	return nil
+}
+
+// validateResponseFormat checks that the outputs match the JSON response format of the request
+func validateResponseFormat(input *OpenAIRequest, outputs ...string) error {
+	schema, _ := input.ResponseFormat.schema()
+	if schema == nil {
+		return nil
+	}
+	for _, output := range outputs {
+		if err := grammar.Validate(schema, []byte(output)); err != nil {
+			return fmt.Errorf("the output of the model does not match the response format: %w", err)
+		}
+	}
+	return nil
+}
+
+// streamedOutput collects the tokens streamed for each choice, to validate the outputs once complete.
+// The streamed tokens can't be computed again, so an invalid output is reported as the error of the stream.
+type streamedOutput struct {
+	input  *OpenAIRequest
+	output strings.Builder
+	err    error
+}
+
+// write collects a token of the current choice
+func (s *streamedOutput) write(token string) {
+	s.output.WriteString(token)
+}
+
+// validate validates the current choice once streamed, keeping the first error
+func (s *streamedOutput) validate() {
+	if s.err == nil {
+		s.err = validateResponseFormat(s.input, s.output.String())
+	}
+	s.output.Reset()
+}
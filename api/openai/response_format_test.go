@@ -0,0 +1,131 @@
+package openai
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	config "github.com/go-skynet/LocalAI/api/config"
+	"github.com/gofiber/fiber/v2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Response format", func() {
+	request := func(body string) *OpenAIRequest {
+		input := &OpenAIRequest{}
+		Expect(json.Unmarshal([]byte(body), input)).To(Succeed())
+		return input
+	}
+
+	It("reads the format as a string or as an object", func() {
+		Expect(request(`{"response_format": "b64_json"}`).ResponseFormat).To(Equal(ResponseFormat{Type: "b64_json"}))
+
+		input := request(`{"response_format": {"type": "json_schema", "json_schema": {"name": "city", "schema": {"type": "object"}}}}`)
+		Expect(input.ResponseFormat.Type).To(Equal(ResponseFormatJSONSchema))
+		Expect(input.ResponseFormat.JSONSchema.Name).To(Equal("city"))
+		Expect(input.ResponseFormat.JSONSchema.Schema).To(Equal(map[string]interface{}{"type": "object"}))
+	})
+
+	It("sets a grammar the backend must enforce", func() {
+		c := &config.Config{}
+		Expect(setResponseFormatGrammar(c, request(`{"response_format": {"type": "json_object"}}`))).To(Succeed())
+		Expect(c.Grammar).To(ContainSubstring("root ::= object"))
+		Expect(c.StrictGrammar()).To(BeTrue())
+
+		c = &config.Config{}
+		Expect(setResponseFormatGrammar(c, request(`{"response_format": {"type": "text"}}`))).To(Succeed())
+		Expect(c.Grammar).To(BeEmpty())
+		Expect(c.StrictGrammar()).To(BeFalse())
+	})
+
+	It("rejects invalid formats", func() {
+		var e *fiber.Error
+		err := setResponseFormatGrammar(&config.Config{}, request(`{"response_format": {"type": "json_schema"}}`))
+		Expect(err).To(BeAssignableToTypeOf(e))
+		Expect(err).To(MatchError(ContainSubstring("requires a schema")))
+
+		err = setResponseFormatGrammar(&config.Config{}, request(`{"response_format": {"type": "json_schema", "json_schema": {"schema": {"type": "tuple"}}}}`))
+		Expect(err).To(BeAssignableToTypeOf(e))
+		Expect(err).To(MatchError(ContainSubstring("unsupported JSON schema")))
+
+		err = setResponseFormatGrammar(&config.Config{}, request(`{"response_format": {"type": "json_schema", "json_schema": {"schema": {"type": "object", "properties": {"x": {"$ref": "#/properties/x"}}}}}}`))
+		Expect(err).To(BeAssignableToTypeOf(e))
+		Expect(err.(*fiber.Error).Code).To(Equal(fiber.StatusBadRequest))
+		Expect(err).To(MatchError(ContainSubstring("is left recursive")))
+	})
+
+	It("validates the output", func() {
+		input := request(`{"response_format": {"type": "json_schema", "json_schema": {"schema": {"type": "object", "properties": {"city": {"type": "string"}}, "required": ["city"]}}}}`)
+		Expect(validateResponseFormat(input, `{"city": "Rome"}`)).To(Succeed())
+		Expect(validateResponseFormat(input, `{"city": "Rome"}`, `{}`)).To(MatchError(`the output of the model does not match the response format: $: missing required property "city"`))
+		Expect(validateResponseFormat(request(`{"response_format": {"type": "json_object"}}`), `[1]`)).To(MatchError(ContainSubstring("expected object, got array")))
+		Expect(validateResponseFormat(request(`{}`), `not JSON`)).To(Succeed())
+	})
+
+	Context("streamed", func() {
+		input := func() *OpenAIRequest {
+			input := request(`{"response_format": {"type": "json_object"}}`)
+			input.Context = context.Background()
+			return input
+		}
+		// stream writes the tokens of the choices as the streaming endpoints do, and returns the error the stream ends with
+		stream := func(input *OpenAIRequest, choices ...[]string) error {
+			output := &streamedOutput{input: input}
+			for _, tokens := range choices {
+				for _, token := range tokens {
+					output.write(token)
+				}
+				output.validate()
+			}
+			return streamResult(input, nil, output)
+		}
+
+		It("validates each streamed choice", func() {
+			Expect(stream(input(), []string{`{"city": `, `"Rome"}`})).To(Succeed())
+			Expect(stream(input(), []string{`{"city": "Rome"}`}, []string{`[1]`})).To(MatchError(ContainSubstring("expected object, got array")))
+			Expect(stream(input(), []string{`not `, `JSON`}, []string{`{}`})).To(MatchError(ContainSubstring("does not match the response format")))
+		})
+
+		It("reports the errors of the backend before the output", func() {
+			err := streamResult(input(), errors.New("backend error"), &streamedOutput{err: errors.New("invalid output")})
+			Expect(err).To(MatchError("backend error"))
+		})
+
+		It("ignores the output of the canceled requests", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			canceled := input()
+			canceled.Context = ctx
+			Expect(stream(canceled, []string{`{"city"`})).To(Succeed())
+		})
+
+		end := func(write func(w *bufio.Writer, err error), err error) string {
+			var buf bytes.Buffer
+			w := bufio.NewWriter(&buf)
+			write(w, err)
+			return buf.String()
+		}
+
+		It("ends the chat completion stream with the invalid output as an error event", func() {
+			err := stream(input(), []string{`[1]`})
+			Expect(end(func(w *bufio.Writer, err error) { writeStreamEnd(w, "model", "stop", err) }, err)).To(Equal(
+				`data: {"error":{"code":500,"message":"the output of the model does not match the response format: $: expected object, got array","type":""}}` + "\n\ndata: [DONE]\n\n"))
+		})
+
+		It("ends the completion stream with the invalid output as an error event", func() {
+			err := stream(input(), []string{`[1]`})
+			Expect(end(func(w *bufio.Writer, err error) { writeCompletionStreamEnd(w, "model", err) }, err)).To(Equal(
+				`data: {"error":{"code":500,"message":"the output of the model does not match the response format: $: expected object, got array","type":""}}` + "\n\ndata: [DONE]\n\n"))
+
+			data := end(func(w *bufio.Writer, err error) { writeCompletionStreamEnd(w, "model", err) }, nil)
+			var resp OpenAIResponse
+			Expect(json.Unmarshal([]byte(strings.TrimSuffix(strings.TrimPrefix(data, "data: "), "\n\ndata: [DONE]\n\n")), &resp)).To(Succeed())
+			Expect(resp.Object).To(Equal("text_completion"))
+			Expect(resp.Choices[0].FinishReason).To(Equal("stop"))
+		})
+	})
+})
@@ -4,6 +4,7 @@ import (
 	"context"
 	"embed"
 	"encoding/json"
+	"time"
 
 	"github.com/go-skynet/LocalAI/pkg/gallery"
 	model "github.com/go-skynet/LocalAI/pkg/model"
@@ -33,6 +34,33 @@ type Option struct {
 	ExternalGRPCBackends map[string]string
 
 	AutoloadGalleries bool
+
+	MaxLoadedModels int
+	MemoryBudgetMB  int
+
+	WatchDogIdleTimeout time.Duration
+	WatchDogBusyTimeout time.Duration
+
+	BackendsTCP bool
+
+	BackendMaxCrashes      int
+	BackendRestartBackoff  time.Duration
+	BackendCircuitCooldown time.Duration
+
+	ParallelRequests int
+	MaxQueueDepth    int
+
+	// ApiKeyPriorities maps API keys to the priority class of their requests
+	ApiKeyPriorities map[string]string
+	PriorityAging    time.Duration
+
+	// WatchConfigs reloads the model configs and the prompt templates when they change
+	WatchConfigs bool
+
+	// DrainTimeout is how long the running requests have to complete when shutting down
+	DrainTimeout time.Duration
+	// ReadinessDelay is how long the requests are still accepted when shutting down, after /readyz reports not ready
+	ReadinessDelay time.Duration
 }
 
 type AppOption func(*Option)
@@ -42,9 +70,9 @@ func NewOptions(o ...AppOption) *Option {
 		Context:        context.Background(),
 		UploadLimitMB:  15,
 		Threads:        1,
-		ContextSize:    512,
 		Debug:          true,
 		DisableMessage: true,
+		DrainTimeout:   20 * time.Second,
 	}
 	for _, oo := range o {
 		oo(opt)
@@ -71,6 +99,92 @@ func WithExternalBackend(name string, uri string) AppOption {
 	}
 }
 
+func WithMaxLoadedModels(n int) AppOption {
+	return func(o *Option) {
+		o.MaxLoadedModels = n
+	}
+}
+
+func WithMemoryBudgetMB(mb int) AppOption {
+	return func(o *Option) {
+		o.MemoryBudgetMB = mb
+	}
+}
+
+func WithWatchDogIdleTimeout(d time.Duration) AppOption {
+	return func(o *Option) {
+		o.WatchDogIdleTimeout = d
+	}
+}
+
+func WithWatchDogBusyTimeout(d time.Duration) AppOption {
+	return func(o *Option) {
+		o.WatchDogBusyTimeout = d
+	}
+}
+
+func WithBackendsTCP(b bool) AppOption {
+	return func(o *Option) {
+		o.BackendsTCP = b
+	}
+}
+
+// WithBackendRestart sets how crashed backends are restarted: with an exponential backoff starting at backoff,
+// until they crash maxCrashes times in a row. Then, requests for the model fail for the cooldown period.
+func WithBackendRestart(maxCrashes int, backoff, cooldown time.Duration) AppOption {
+	return func(o *Option) {
+		o.BackendMaxCrashes = maxCrashes
+		o.BackendRestartBackoff = backoff
+		o.BackendCircuitCooldown = cooldown
+	}
+}
+
+func WithParallelRequests(n int) AppOption {
+	return func(o *Option) {
+		o.ParallelRequests = n
+	}
+}
+
+func WithMaxQueueDepth(n int) AppOption {
+	return func(o *Option) {
+		o.MaxQueueDepth = n
+	}
+}
+
+// WithApiKeyPriority sets the priority class (low, normal or high) of the requests authenticated with the API key
+func WithApiKeyPriority(apiKey, priority string) AppOption {
+	return func(o *Option) {
+		if o.ApiKeyPriorities == nil {
+			o.ApiKeyPriorities = make(map[string]string)
+		}
+		o.ApiKeyPriorities[apiKey] = priority
+	}
+}
+
+func WithPriorityAging(d time.Duration) AppOption {
+	return func(o *Option) {
+		o.PriorityAging = d
+	}
+}
+
+func WithWatchConfigs(b bool) AppOption {
+	return func(o *Option) {
+		o.WatchConfigs = b
+	}
+}
+
+func WithDrainTimeout(d time.Duration) AppOption {
+	return func(o *Option) {
+		o.DrainTimeout = d
+	}
+}
+
+func WithReadinessDelay(d time.Duration) AppOption {
+	return func(o *Option) {
+		o.ReadinessDelay = d
+	}
+}
+
 func WithCorsAllowOrigins(b string) AppOption {
 	return func(o *Option) {
 		o.CORSAllowOrigins = b
@@ -109,6 +223,7 @@ func WithGalleries(galleries []gallery.Gallery) AppOption {
 	}
 }
 
+// WithContext sets the context of the application. Canceling it shuts the API down gracefully, see DrainTimeout.
 func WithContext(ctx context.Context) AppOption {
 	return func(o *Option) {
 		o.Context = ctx
@@ -0,0 +1,86 @@
+package api
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-skynet/LocalAI/api/options"
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog/log"
+)
+
+// lifecycle tracks the graceful shutdown of the API.
+// Canceling the context given to App starts it: /readyz reports not ready right away, while the requests
+// are still served for ReadinessDelay, giving the load balancers time to stop sending new ones.
+// Then new requests are refused, and the running ones have up to DrainTimeout to complete. Only then the application context
+// (used by the requests, the gallery jobs and the backend supervision) is canceled and the backends are stopped.
+type lifecycle struct {
+	notReady atomic.Bool
+	draining atomic.Bool
+	cancel   context.CancelFunc
+	stopOnce sync.Once
+}
+
+// newLifecycle replaces the context of the options with the application context, canceled after the requests are drained
+func newLifecycle(app *fiber.App, o *options.Option) *lifecycle {
+	shutdown := o.Context
+	ctx, cancel := context.WithCancel(context.Background())
+	o.Context = ctx
+
+	l := &lifecycle{cancel: cancel}
+
+	go func() {
+		select {
+		case <-shutdown.Done():
+		case <-ctx.Done():
+			return
+		}
+		l.notReady.Store(true)
+		if o.ReadinessDelay > 0 {
+			log.Info().Msgf("Shutting down, reporting not ready for %s before refusing new requests", o.ReadinessDelay)
+			select {
+			case <-time.After(o.ReadinessDelay):
+			case <-ctx.Done():
+				return
+			}
+		}
+		l.draining.Store(true)
+		log.Info().Msgf("Shutting down, waiting up to %s for the running requests to complete", o.DrainTimeout)
+		if err := app.ShutdownWithTimeout(o.DrainTimeout); err != nil {
+			log.Warn().Msgf("Failed shutting down the API server: %s", err.Error())
+		}
+	}()
+
+	// Runs once the server stopped, either after draining or when app.Shutdown is called directly
+	app.Hooks().OnShutdown(func() error {
+		l.stopOnce.Do(func() {
+			l.notReady.Store(true)
+			l.draining.Store(true)
+			log.Debug().Msgf("Stopping gallery jobs and backends")
+			cancel()
+			o.Loader.StopGRPC()
+		})
+		return nil
+	})
+
+	return l
+}
+
+// reject refuses the requests received while shutting down, on connections kept alive
+func (l *lifecycle) reject(c *fiber.Ctx) error {
+	if l.draining.Load() && c.Path() != "/healthz" && c.Path() != "/readyz" {
+		c.Set(fiber.HeaderConnection, "close")
+		return fiber.NewError(fiber.StatusServiceUnavailable, "server is shutting down")
+	}
+	return c.Next()
+}
+
+// ready answers the readiness probes, failing as soon as the shutdown starts
+func (l *lifecycle) ready(c *fiber.Ctx) error {
+	if l.notReady.Load() {
+		return c.SendStatus(fiber.StatusServiceUnavailable)
+	}
+	return c.SendStatus(fiber.StatusOK)
+}
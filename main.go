@@ -1,11 +1,14 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"os"
 	"os/signal"
 	"path/filepath"
 	"strings"
 	"syscall"
+	"time"
 
 	api "github.com/go-skynet/LocalAI/api"
 	"github.com/go-skynet/LocalAI/api/options"
@@ -18,13 +21,9 @@ import (
 
 func main() {
 	log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stderr})
-	// clean up process
-	go func() {
-		c := make(chan os.Signal, 1) // we need to reserve to buffer size 1, so the notifier are not blocked
-		signal.Notify(c, os.Interrupt, syscall.SIGTERM)
-		<-c
-		os.Exit(1)
-	}()
+	// the first signal shuts LocalAI down gracefully, a second one terminates it right away
+	shutdown, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
 	path, err := os.Getwd()
 	if err != nil {
@@ -115,14 +114,13 @@ func main() {
 			},
 			&cli.StringSliceFlag{
 				Name:    "external-grpc-backends",
-				Usage:   "A list of external grpc backends",
+				Usage:   "A list of external grpc backends (name:host:port or name:/path/to/backend). Remote backends accept options to authenticate, e.g. name:host:port?ca=ca.pem&cert=cert.pem&key=key.pem&token_file=token",
 				EnvVars: []string{"EXTERNAL_GRPC_BACKENDS"},
 			},
 			&cli.IntFlag{
 				Name:    "context-size",
-				Usage:   "Default context size of the model",
+				Usage:   "Default context size of the model. If not set, models in GGUF format without a YAML configuration use the context size they were trained with, and the others 512",
 				EnvVars: []string{"CONTEXT_SIZE"},
-				Value:   512,
 			},
 			&cli.IntFlag{
 				Name:    "upload-limit",
@@ -135,6 +133,88 @@ func main() {
 				Usage:   "List of API Keys to enable API authentication. When this is set, all the requests must be authenticated with one of these API keys.",
 				EnvVars: []string{"API_KEY"},
 			},
+			&cli.IntFlag{
+				Name:    "max-loaded-models",
+				Usage:   "Maximum number of models kept loaded at the same time. When exceeded, the least recently used model is stopped. 0 means no limit.",
+				EnvVars: []string{"MAX_LOADED_MODELS"},
+			},
+			&cli.IntFlag{
+				Name:    "memory-budget",
+				Usage:   "Memory budget (MB) for the backend processes, measured by their RSS. When exceeded, the least recently used models are stopped. 0 means no limit.",
+				EnvVars: []string{"MEMORY_BUDGET"},
+			},
+			&cli.DurationFlag{
+				Name:    "watchdog-idle-timeout",
+				Usage:   "Stop the backends of models that were not used for longer than this duration (e.g. 15m). It can be overridden per model with idle_timeout. 0 disables it.",
+				EnvVars: []string{"WATCHDOG_IDLE_TIMEOUT"},
+			},
+			&cli.DurationFlag{
+				Name:    "watchdog-busy-timeout",
+				Usage:   "Stop the backends stuck serving a single request for longer than this duration (e.g. 5m). It can be overridden per model with busy_timeout. 0 disables it.",
+				EnvVars: []string{"WATCHDOG_BUSY_TIMEOUT"},
+			},
+			&cli.BoolFlag{
+				Name:    "backends-tcp",
+				Usage:   "Make the backends started by LocalAI listen on a local TCP port instead of a Unix socket. Needed for external backends that do not support Unix sockets.",
+				EnvVars: []string{"BACKENDS_TCP"},
+			},
+			&cli.IntFlag{
+				Name:    "backend-max-crashes",
+				Usage:   "Number of consecutive crashes after which a backend is not restarted anymore, and requests for its model fail until the cooldown expires. 0 disables automatic restarts.",
+				EnvVars: []string{"BACKEND_MAX_CRASHES"},
+				Value:   5,
+			},
+			&cli.DurationFlag{
+				Name:    "backend-restart-backoff",
+				Usage:   "Delay before restarting a crashed backend, doubled at each consecutive crash.",
+				EnvVars: []string{"BACKEND_RESTART_BACKOFF"},
+				Value:   time.Second,
+			},
+			&cli.DurationFlag{
+				Name:    "backend-circuit-cooldown",
+				Usage:   "How long requests for a model fail fast once its backend crashed too many times in a row.",
+				EnvVars: []string{"BACKEND_CIRCUIT_COOLDOWN"},
+				Value:   5 * time.Minute,
+			},
+			&cli.IntFlag{
+				Name:    "parallel-requests",
+				Usage:   "Default number of requests served at the same time by each model. It can be overridden per model with parallel_requests. Only increase it for backends that support concurrent requests.",
+				EnvVars: []string{"PARALLEL_REQUESTS"},
+				Value:   1,
+			},
+			&cli.IntFlag{
+				Name:    "max-queue-depth",
+				Usage:   "Default maximum number of requests waiting for a model. Further requests are rejected with status 429. It can be overridden per model with max_queue_depth. 0 means no limit.",
+				EnvVars: []string{"MAX_QUEUE_DEPTH"},
+			},
+			&cli.StringSliceFlag{
+				Name:    "api-key-priority",
+				Usage:   "Priority class (low, normal or high) of the requests made with an API key, as KEY:PRIORITY. The key must also be one of the --api-keys. Clients can lower the priority of a request with the X-Request-Priority header, but not raise it above the one of their key, or above normal for the keys without a priority.",
+				EnvVars: []string{"API_KEY_PRIORITY"},
+			},
+			&cli.DurationFlag{
+				Name:    "priority-aging",
+				Usage:   "Time after which a queued request is promoted to the next priority class, so that low priority requests are not starved. A negative value disables it.",
+				EnvVars: []string{"PRIORITY_AGING"},
+				Value:   10 * time.Second,
+			},
+			&cli.BoolFlag{
+				Name:    "watch-configs",
+				Usage:   "Reload the model YAML configs and the prompt templates when they change in the models path.",
+				EnvVars: []string{"WATCH_CONFIGS"},
+				Value:   true,
+			},
+			&cli.DurationFlag{
+				Name:    "shutdown-drain-timeout",
+				Usage:   "How long the running requests have to complete when shutting down, before the backends are stopped.",
+				EnvVars: []string{"SHUTDOWN_DRAIN_TIMEOUT"},
+				Value:   20 * time.Second,
+			},
+			&cli.DurationFlag{
+				Name:    "shutdown-readiness-delay",
+				Usage:   "How long the requests are still accepted when shutting down, once /readyz reports not ready, before closing the listener. Behind a load balancer, set it above the interval of its readiness probes, for it to stop sending requests first.",
+				EnvVars: []string{"SHUTDOWN_READINESS_DELAY"},
+			},
 		},
 		Description: `
 LocalAI is a drop-in replacement OpenAI API which runs inference locally.
@@ -153,12 +233,14 @@ For a list of compatible model, check out: https://localai.io/model-compatibilit
 		UsageText: `local-ai [options]`,
 		Copyright: "Ettore Di Giacinto",
 		Action: func(ctx *cli.Context) error {
+			loader := model.NewModelLoader(ctx.String("models-path"))
+			go terminateOnSecondSignal(shutdown, stop, loader)
 
 			opts := []options.AppOption{
 				options.WithConfigFile(ctx.String("config-file")),
 				options.WithJSONStringPreload(ctx.String("preload-models")),
 				options.WithYAMLConfigPreload(ctx.String("preload-models-config")),
-				options.WithModelLoader(model.NewModelLoader(ctx.String("models-path"))),
+				options.WithModelLoader(loader),
 				options.WithContextSize(ctx.Int("context-size")),
 				options.WithDebug(ctx.Bool("debug")),
 				options.WithImageDir(ctx.String("image-path")),
@@ -173,6 +255,27 @@ For a list of compatible model, check out: https://localai.io/model-compatibilit
 				options.WithBackendAssetsOutput(ctx.String("backend-assets-path")),
 				options.WithUploadLimitMB(ctx.Int("upload-limit")),
 				options.WithApiKeys(ctx.StringSlice("api-keys")),
+				options.WithMaxLoadedModels(ctx.Int("max-loaded-models")),
+				options.WithMemoryBudgetMB(ctx.Int("memory-budget")),
+				options.WithWatchDogIdleTimeout(ctx.Duration("watchdog-idle-timeout")),
+				options.WithWatchDogBusyTimeout(ctx.Duration("watchdog-busy-timeout")),
+				options.WithBackendsTCP(ctx.Bool("backends-tcp")),
+				options.WithParallelRequests(ctx.Int("parallel-requests")),
+				options.WithMaxQueueDepth(ctx.Int("max-queue-depth")),
+				options.WithPriorityAging(ctx.Duration("priority-aging")),
+				options.WithContext(shutdown),
+				options.WithWatchConfigs(ctx.Bool("watch-configs")),
+				options.WithDrainTimeout(ctx.Duration("shutdown-drain-timeout")),
+				options.WithReadinessDelay(ctx.Duration("shutdown-readiness-delay")),
+				options.WithBackendRestart(ctx.Int("backend-max-crashes"), ctx.Duration("backend-restart-backoff"), ctx.Duration("backend-circuit-cooldown")),
+			}
+
+			for _, v := range ctx.StringSlice("api-key-priority") {
+				key, priority, found := strings.Cut(v, ":")
+				if !found {
+					return fmt.Errorf("invalid API key priority %q, expected KEY:PRIORITY", v)
+				}
+				opts = append(opts, options.WithApiKeyPriority(key, priority))
 			}
 
 			externalgRPC := ctx.StringSlice("external-grpc-backends")
@@ -192,7 +295,18 @@ For a list of compatible model, check out: https://localai.io/model-compatibilit
 				return err
 			}
 
-			return app.Listen(ctx.String("address"))
+			// Listen returns as soon as the shutdown starts: wait for the requests to be drained and the backends stopped
+			stopped := make(chan struct{})
+			app.Hooks().OnShutdown(func() error {
+				close(stopped)
+				return nil
+			})
+
+			if err := app.Listen(ctx.String("address")); err != nil {
+				return err
+			}
+			<-stopped
+			return nil
 		},
 	}
 
@@ -202,3 +316,28 @@ For a list of compatible model, check out: https://localai.io/model-compatibilit
 		os.Exit(1)
 	}
 }
+
+// terminateOnSecondSignal exits as soon as a signal is received during the graceful shutdown,
+// stopping the backend processes first not to leave them running.
+func terminateOnSecondSignal(shutdown context.Context, stop context.CancelFunc, loader *model.ModelLoader) {
+	<-shutdown.Done()
+	terminate := make(chan os.Signal, 1)
+	signal.Notify(terminate, os.Interrupt, syscall.SIGTERM)
+	stop()
+
+	<-terminate
+	log.Warn().Msgf("Terminating without waiting for the running requests")
+
+	// a model being loaded holds the loader: don't wait for it forever
+	stopped := make(chan struct{})
+	go func() {
+		loader.StopGRPC()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		log.Warn().Msgf("Timed out stopping the backends")
+	}
+	os.Exit(1)
+}
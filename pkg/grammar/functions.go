@@ -2,6 +2,7 @@ package grammar
 
 import (
 	"encoding/json"
+	"fmt"
 )
 
 type Function struct {
@@ -26,6 +27,16 @@ func (f Functions) ToJSONStructure() JSONFunctionStructure {
 
 		json.Unmarshal(dat, &prop)
 		json.Unmarshal(dat2, &defsD)
+		var required []string
+		if r, ok := function.Parameters["required"].([]interface{}); ok {
+			for _, name := range r {
+				if name, ok := name.(string); ok {
+					required = append(required, name)
+				}
+			}
+		} else if r, ok := function.Parameters["required"].([]string); ok {
+			required = r
+		}
 		if js.Defs == nil {
 			js.Defs = defsD
 		}
@@ -36,6 +47,7 @@ func (f Functions) ToJSONStructure() JSONFunctionStructure {
 				Arguments: Argument{
 					Type:       "object",
 					Properties: prop,
+					Required:   required,
 				},
 			},
 		})
@@ -43,6 +55,28 @@ func (f Functions) ToJSONStructure() JSONFunctionStructure {
 	return js
 }
 
+// CheckParameters checks that the parameters of the functions convert to grammars that parse, as the
+// functions grammar constraining a model to call them is built from them
+func (f Functions) CheckParameters() error {
+	check := func(schema map[string]interface{}) error {
+		g, err := JSONSchemaGrammar(schema, "")
+		if err != nil {
+			return err
+		}
+		_, err = ParseGrammar(g)
+		return err
+	}
+	for _, function := range f {
+		if err := check(function.Parameters); err != nil {
+			return fmt.Errorf("function %q: %w", function.Name, err)
+		}
+	}
+	if err := check(f.ToJSONStructure().schema()); err != nil {
+		return fmt.Errorf("functions: %w", err)
+	}
+	return nil
+}
+
 // Select returns a list of functions containing the function with the given name
 func (f Functions) Select(name string) Functions {
 	var funcs Functions
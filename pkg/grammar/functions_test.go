@@ -21,6 +21,7 @@ var _ = Describe("LocalAI grammar functions", func() {
 								"type": "string",
 							},
 						},
+						"required": []interface{}{"event_name"},
 					},
 				},
 				{
@@ -40,6 +41,7 @@ var _ = Describe("LocalAI grammar functions", func() {
 			Expect(js.OneOf[0].Properties.Function.Const).To(Equal("create_event"))
 			Expect(js.OneOf[0].Properties.Arguments.Properties["event_name"].(map[string]interface{})["type"]).To(Equal("string"))
 			Expect(js.OneOf[0].Properties.Arguments.Properties["event_date"].(map[string]interface{})["type"]).To(Equal("string"))
+			Expect(js.OneOf[0].Properties.Arguments.Required).To(Equal([]string{"event_name"}))
 			Expect(js.OneOf[1].Properties.Function.Const).To(Equal("search"))
 			Expect(js.OneOf[1].Properties.Arguments.Properties["query"].(map[string]interface{})["type"]).To(Equal("string"))
 		})
@@ -60,4 +62,24 @@ var _ = Describe("LocalAI grammar functions", func() {
 			Expect(functions[0].Name).To(Equal("create_event"))
 		})
 	})
+	Context("CheckParameters()", func() {
+		It("rejects the parameters that don't convert to a valid grammar", func() {
+			functions := Functions{
+				{Name: "search", Parameters: map[string]interface{}{"type": "object", "properties": map[string]interface{}{"query": map[string]interface{}{"type": "string"}}}},
+				{Name: "noop"},
+			}
+			Expect(functions.CheckParameters()).To(Succeed())
+
+			functions = append(functions, Function{Name: "loop", Parameters: map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{"x": map[string]interface{}{"$ref": "#/properties/x"}},
+			}})
+			err := functions.CheckParameters()
+			Expect(err).To(MatchError(ContainSubstring(`function "loop": `)))
+			Expect(err).To(MatchError(ContainSubstring("is left recursive")))
+
+			functions[2].Parameters = map[string]interface{}{"type": "tuple"}
+			Expect(functions.CheckParameters()).To(MatchError(ContainSubstring(`function "loop": unsupported JSON schema`)))
+		})
+	})
 })
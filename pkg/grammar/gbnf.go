@@ -0,0 +1,511 @@
+package grammar
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// The grammars come from the requests: these limits bound the work parsing them, which is linear in their size
+const (
+	// maxGrammarSize is the size of the largest grammar, far above the grammars generated from JSON schemas
+	maxGrammarSize = 1024 * 1024
+	// maxGroupDepth is how deep the groups can be nested
+	maxGroupDepth = 256
+)
+
+// GrammarError is an error of a GBNF grammar, located in its source
+type GrammarError struct {
+	Line, Column int
+	Message      string
+}
+
+func (e *GrammarError) Error() string {
+	return fmt.Sprintf("line %d, column %d: %s", e.Line, e.Column, e.Message)
+}
+
+// Grammar is a GBNF grammar, as llama.cpp parses it
+type Grammar struct {
+	Rules []*Rule
+}
+
+// Rule is a rule of a GBNF grammar
+type Rule struct {
+	Name         string
+	Line, Column int
+
+	alternatives []sequence
+}
+
+type sequence []*element
+
+type elementType int
+
+const (
+	literalElement elementType = iota
+	charClassElement
+	ruleElement
+	groupElement
+)
+
+// element is an item of a sequence, with the repetition operator following it if any
+type element struct {
+	elementType elementType
+	// text is the decoded literal, or the name of the rule referred to
+	text         string
+	alternatives []sequence
+	// repetition are the operators following the element, as *
+	repetition string
+	pos        int
+}
+
+// ParseGrammar parses a GBNF grammar, checking that the rules it refers to are defined, that it has
+// a root rule and that none of its rules is left recursive, as the backends would fail on such grammars
+func ParseGrammar(src string) (*Grammar, error) {
+	if len(src) > maxGrammarSize {
+		return nil, &GrammarError{Line: 1, Column: 1, Message: fmt.Sprintf("the grammar is larger than %d bytes", maxGrammarSize)}
+	}
+	p := &gbnfParser{src: src, rules: map[string]*Rule{}}
+	g, err := p.parse()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, ref := range p.refs {
+		if _, exists := p.rules[ref.text]; !exists {
+			return nil, p.errorAt(ref.pos, "undefined rule %q", ref.text)
+		}
+	}
+	if _, exists := p.rules["root"]; !exists {
+		return nil, p.errorAt(0, "the grammar has no root rule")
+	}
+	if err := p.checkLeftRecursion(g); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+type gbnfParser struct {
+	src string
+	pos int
+	// rules are the rules by name, the last definition of a rule replacing the others
+	rules map[string]*Rule
+	refs  []*element
+	// lineStarts are the offsets of the lines of the source, indexed on first use
+	lineStarts []int
+}
+
+// position returns the line and the column of an offset of the source, counted from 1
+func (p *gbnfParser) position(pos int) (int, int) {
+	if p.lineStarts == nil {
+		p.lineStarts = []int{0}
+		for i := 0; i < len(p.src); i++ {
+			if p.src[i] == '\n' {
+				p.lineStarts = append(p.lineStarts, i+1)
+			}
+		}
+	}
+	line := sort.Search(len(p.lineStarts), func(i int) bool { return p.lineStarts[i] > pos }) - 1
+	return line + 1, utf8.RuneCountInString(p.src[p.lineStarts[line]:pos]) + 1
+}
+
+func (p *gbnfParser) errorAt(pos int, format string, a ...interface{}) *GrammarError {
+	line, column := p.position(pos)
+	return &GrammarError{Line: line, Column: column, Message: fmt.Sprintf(format, a...)}
+}
+
+func (p *gbnfParser) peek() byte {
+	if p.pos >= len(p.src) {
+		return 0
+	}
+	return p.src[p.pos]
+}
+
+// found describes what is at the current position, for the error messages
+func (p *gbnfParser) found() string {
+	if p.pos >= len(p.src) {
+		return "the end of the grammar"
+	}
+	r, _ := utf8.DecodeRuneInString(p.src[p.pos:])
+	switch r {
+	case '\n', '\r':
+		return "the end of the line"
+	}
+	return fmt.Sprintf("%q", r)
+}
+
+// skipSpace skips the spaces and the comments, and the new lines if they are allowed
+func (p *gbnfParser) skipSpace(newlines bool) {
+	for p.pos < len(p.src) {
+		switch c := p.src[p.pos]; {
+		case c == ' ' || c == '\t' || newlines && (c == '\r' || c == '\n'):
+			p.pos++
+		case c == '#':
+			for p.pos < len(p.src) && p.src[p.pos] != '\r' && p.src[p.pos] != '\n' {
+				p.pos++
+			}
+		default:
+			return
+		}
+	}
+}
+
+func isWordChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-'
+}
+
+func (p *gbnfParser) parseName() string {
+	start := p.pos
+	for p.pos < len(p.src) && isWordChar(p.src[p.pos]) {
+		p.pos++
+	}
+	return p.src[start:p.pos]
+}
+
+func (p *gbnfParser) parse() (*Grammar, error) {
+	g := &Grammar{}
+	p.skipSpace(true)
+	if p.pos >= len(p.src) {
+		return nil, p.errorAt(p.pos, "the grammar is empty")
+	}
+	for p.pos < len(p.src) {
+		rule, err := p.parseRule()
+		if err != nil {
+			return nil, err
+		}
+		g.Rules = append(g.Rules, rule)
+		p.rules[rule.Name] = rule
+		p.skipSpace(true)
+	}
+	return g, nil
+}
+
+func (p *gbnfParser) parseRule() (*Rule, error) {
+	start := p.pos
+	name := p.parseName()
+	if name == "" {
+		return nil, p.errorAt(p.pos, "expected a rule name, found %s", p.found())
+	}
+	p.skipSpace(false)
+	if !strings.HasPrefix(p.src[p.pos:], "::=") {
+		return nil, p.errorAt(p.pos, "expected ::= after the rule name, found %s", p.found())
+	}
+	p.pos += len("::=")
+	p.skipSpace(true)
+
+	alternatives, err := p.parseAlternatives(0)
+	if err != nil {
+		return nil, err
+	}
+	switch p.peek() {
+	case 0, '\r', '\n':
+	default:
+		return nil, p.errorAt(p.pos, "expected a new line after the rule, found %s", p.found())
+	}
+
+	rule := &Rule{Name: name, alternatives: alternatives}
+	rule.Line, rule.Column = p.position(start)
+	return rule, nil
+}
+
+// parseAlternatives parses sequences separated by |, which can span several lines in a group.
+// depth is the number of groups the sequences are in.
+func (p *gbnfParser) parseAlternatives(depth int) ([]sequence, error) {
+	var alternatives []sequence
+	for {
+		seq, err := p.parseSequence(depth)
+		if err != nil {
+			return nil, err
+		}
+		alternatives = append(alternatives, seq)
+		if p.peek() != '|' {
+			return alternatives, nil
+		}
+		p.pos++
+		p.skipSpace(true)
+	}
+}
+
+func (p *gbnfParser) parseSequence(depth int) (sequence, error) {
+	var seq sequence
+	for {
+		start := p.pos
+		c := p.peek()
+		switch {
+		case c == '"':
+			p.pos++
+			var literal strings.Builder
+			for p.peek() != '"' {
+				if p.pos >= len(p.src) {
+					return nil, p.errorAt(start, "unterminated literal")
+				}
+				r, err := p.parseChar()
+				if err != nil {
+					return nil, err
+				}
+				literal.WriteRune(r)
+			}
+			p.pos++
+			seq = append(seq, &element{elementType: literalElement, text: literal.String(), pos: start})
+		case c == '[':
+			p.pos++
+			if p.peek() == '^' {
+				p.pos++
+			}
+			for p.peek() != ']' {
+				if p.pos >= len(p.src) {
+					return nil, p.errorAt(start, "unterminated character class")
+				}
+				low, err := p.parseChar()
+				if err != nil {
+					return nil, err
+				}
+				if p.peek() == '-' && p.pos+1 < len(p.src) && p.src[p.pos+1] != ']' {
+					rangeStart := p.pos
+					p.pos++
+					high, err := p.parseChar()
+					if err != nil {
+						return nil, err
+					}
+					if high < low {
+						return nil, p.errorAt(rangeStart, "invalid range %q-%q", low, high)
+					}
+				}
+			}
+			p.pos++
+			seq = append(seq, &element{elementType: charClassElement, pos: start})
+		case isWordChar(c):
+			ref := &element{elementType: ruleElement, text: p.parseName(), pos: start}
+			p.refs = append(p.refs, ref)
+			seq = append(seq, ref)
+		case c == '(':
+			if depth >= maxGroupDepth {
+				return nil, p.errorAt(p.pos, "groups nested more than %d levels deep", maxGroupDepth)
+			}
+			p.pos++
+			p.skipSpace(true)
+			alternatives, err := p.parseAlternatives(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			if p.peek() != ')' {
+				line, column := p.position(start)
+				return nil, p.errorAt(p.pos, "expected ) to close the group opened at line %d, column %d, found %s", line, column, p.found())
+			}
+			p.pos++
+			seq = append(seq, &element{elementType: groupElement, alternatives: alternatives, pos: start})
+		case c == '*' || c == '+' || c == '?':
+			if len(seq) == 0 {
+				return nil, p.errorAt(p.pos, "%c must follow an item to repeat", c)
+			}
+			seq[len(seq)-1].repetition += string(c)
+			p.pos++
+		default:
+			return seq, nil
+		}
+		p.skipSpace(depth > 0)
+	}
+}
+
+// parseChar parses a character of a literal or of a character class, which may be escaped
+func (p *gbnfParser) parseChar() (rune, error) {
+	if p.peek() != '\\' {
+		r, size := utf8.DecodeRuneInString(p.src[p.pos:])
+		p.pos += size
+		return r, nil
+	}
+
+	start := p.pos
+	p.pos++
+	c := p.peek()
+	switch c {
+	case 'x', 'u', 'U':
+		size := map[byte]int{'x': 2, 'u': 4, 'U': 8}[c]
+		p.pos++
+		var r rune
+		for i := 0; i < size; i++ {
+			d := p.peek()
+			switch {
+			case d >= '0' && d <= '9':
+				r = r*16 + rune(d-'0')
+			case d >= 'a' && d <= 'f':
+				r = r*16 + rune(d-'a'+10)
+			case d >= 'A' && d <= 'F':
+				r = r*16 + rune(d-'A'+10)
+			default:
+				return 0, p.errorAt(start, `\%c must be followed by %d hexadecimal digits`, c, size)
+			}
+			p.pos++
+		}
+		return r, nil
+	case 't':
+		p.pos++
+		return '\t', nil
+	case 'r':
+		p.pos++
+		return '\r', nil
+	case 'n':
+		p.pos++
+		return '\n', nil
+	case '\\', '"', '[', ']':
+		p.pos++
+		return rune(c), nil
+	case 0:
+		return 0, p.errorAt(start, "unterminated escape sequence")
+	}
+	return 0, p.errorAt(start, `unknown escape sequence \%c`, c)
+}
+
+// nullNode is a rule, a group or a sequence, in the computation of the ones that can match the empty string
+type nullNode struct {
+	// sequence tells if the node is nullable when all its children are, rather than when one of them is
+	sequence bool
+	// remaining is the number of children of a sequence not known to be nullable yet
+	remaining int
+	nullable  bool
+	parents   []*nullNode
+}
+
+// nullable returns whether an element can match the empty string. The rules, groups and sequences that can
+// are found from the ones that trivially do, each reference being followed once, for the time to be linear.
+func (p *gbnfParser) nullable() func(e *element) bool {
+	rules := map[string]*nullNode{}
+	for name := range p.rules {
+		rules[name] = &nullNode{}
+	}
+	groups := map[*element]*nullNode{}
+	var queue []*nullNode
+
+	var build func(n *nullNode, alternatives []sequence)
+	build = func(n *nullNode, alternatives []sequence) {
+		for _, seq := range alternatives {
+			sn := &nullNode{sequence: true, parents: []*nullNode{n}}
+			for _, e := range seq {
+				var child *nullNode
+				switch e.elementType {
+				case ruleElement:
+					child = rules[e.text]
+				case groupElement:
+					child = &nullNode{}
+					groups[e] = child
+					build(child, e.alternatives)
+				}
+				switch {
+				case strings.ContainsAny(e.repetition, "*?"), e.elementType == literalElement && e.text == "":
+				case child != nil:
+					sn.remaining++
+					child.parents = append(child.parents, sn)
+				default:
+					// a character is never nullable: the sequence can't be
+					sn.remaining++
+				}
+			}
+			if sn.remaining == 0 {
+				sn.nullable = true
+				queue = append(queue, sn)
+			}
+		}
+	}
+	for name, rule := range p.rules {
+		build(rules[name], rule.alternatives)
+	}
+
+	for len(queue) > 0 {
+		n := queue[len(queue)-1]
+		queue = queue[:len(queue)-1]
+		for _, parent := range n.parents {
+			if parent.nullable {
+				continue
+			}
+			if parent.sequence {
+				if parent.remaining--; parent.remaining > 0 {
+					continue
+				}
+			}
+			parent.nullable = true
+			queue = append(queue, parent)
+		}
+	}
+
+	return func(e *element) bool {
+		if strings.ContainsAny(e.repetition, "*?") {
+			return true
+		}
+		switch e.elementType {
+		case literalElement:
+			return e.text == ""
+		case ruleElement:
+			return rules[e.text].nullable
+		case groupElement:
+			return groups[e].nullable
+		}
+		return false
+	}
+}
+
+// checkLeftRecursion returns an error for the first rule that can refer to itself before matching any
+// character: the backends would loop forever expanding it
+func (p *gbnfParser) checkLeftRecursion(g *Grammar) error {
+	elementNullable := p.nullable()
+
+	// the rules each rule can start with
+	var leftRules func(alternatives []sequence, refs []string) []string
+	leftRules = func(alternatives []sequence, refs []string) []string {
+		for _, seq := range alternatives {
+			for _, e := range seq {
+				switch e.elementType {
+				case ruleElement:
+					refs = append(refs, e.text)
+				case groupElement:
+					refs = leftRules(e.alternatives, refs)
+				}
+				if !elementNullable(e) {
+					break
+				}
+			}
+		}
+		return refs
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := map[string]int{}
+	var path []string
+	var visit func(name string) []string
+	visit = func(name string) []string {
+		state[name] = visiting
+		path = append(path, name)
+		for _, ref := range leftRules(p.rules[name].alternatives, nil) {
+			switch state[ref] {
+			case visiting:
+				for i, n := range path {
+					if n == ref {
+						return append(append([]string{}, path[i:]...), ref)
+					}
+				}
+			case unvisited:
+				if cycle := visit(ref); cycle != nil {
+					return cycle
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		state[name] = visited
+		return nil
+	}
+	for _, rule := range g.Rules {
+		if p.rules[rule.Name] != rule || state[rule.Name] != unvisited {
+			continue
+		}
+		if cycle := visit(rule.Name); cycle != nil {
+			r := p.rules[cycle[0]]
+			return &GrammarError{Line: r.Line, Column: r.Column,
+				Message: fmt.Sprintf("rule %q is left recursive: %s", r.Name, strings.Join(cycle, " -> "))}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package grammar_test
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	. "github.com/go-skynet/LocalAI/pkg/grammar"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("GBNF grammars", func() {
+	DescribeTable("parses valid grammars",
+		func(src string, rules []string) {
+			g, err := ParseGrammar(src)
+			Expect(err).ToNot(HaveOccurred())
+			names := []string{}
+			for _, r := range g.Rules {
+				names = append(names, r.Name)
+			}
+			Expect(names).To(Equal(rules))
+		},
+		Entry("single rule", `root ::= "yes" | "no"`, []string{"root"}),
+		Entry("comments, groups and repetitions", `
+# a list of numbers
+root ::= "[" ( num ("," num)* )? "]"   # the list
+num  ::= [1-9] [0-9]* | "0"
+`, []string{"root", "num"}),
+		Entry("alternatives over several lines", "root ::= (\n  \"a\" |\n  \"b\"\n) x\nx ::= \"c\"?", []string{"root", "x"}),
+		Entry("escapes", `root ::= "\x41é\U0001F600\t\r\n\\\"\[\]" [^\]\\\x00-\x1F"]`, []string{"root"}),
+		Entry("right recursion", `root ::= "a" root | ""`, []string{"root"}),
+		Entry("Windows line endings", "root ::= a\r\na ::= \"a\"\r\n", []string{"root", "a"}),
+	)
+
+	DescribeTable("reports the errors of invalid grammars",
+		func(src, expected string) {
+			_, err := ParseGrammar(src)
+			Expect(err).To(MatchError(expected))
+			var grammarErr *GrammarError
+			Expect(err).To(BeAssignableToTypeOf(grammarErr))
+		},
+		Entry("empty grammar", "  # nothing\n", "line 2, column 1: the grammar is empty"),
+		Entry("missing ::=", "root := \"a\"", `line 1, column 6: expected ::= after the rule name, found ':'`),
+		Entry("missing rule name", "root ::= \"a\"\n::= \"b\"", `line 2, column 1: expected a rule name, found ':'`),
+		Entry("unterminated literal", "root ::= \"a\" \"bc", "line 1, column 14: unterminated literal"),
+		Entry("unterminated class", "root ::= [a-z", "line 1, column 10: unterminated character class"),
+		Entry("unclosed group", "root ::= (\"a\" | \"b\"\n", "line 2, column 1: expected ) to close the group opened at line 1, column 10, found the end of the grammar"),
+		Entry("unknown escape", `root ::= "\d"`, `line 1, column 11: unknown escape sequence \d`),
+		Entry("short hexadecimal escape", `root ::= "\x4"`, `line 1, column 11: \x must be followed by 2 hexadecimal digits`),
+		Entry("invalid range", `root ::= [z-a]`, `line 1, column 12: invalid range 'z'-'a'`),
+		Entry("repetition of nothing", `root ::= * "a"`, "line 1, column 10: * must follow an item to repeat"),
+		Entry("rules on the same line", `root ::= "a" b ::= "b"`, `line 1, column 16: expected a new line after the rule, found ':'`),
+		Entry("undefined rule", "root ::= item+\nitem ::= strng", `line 2, column 10: undefined rule "strng"`),
+		Entry("missing root", `item ::= "a"`, "line 1, column 1: the grammar has no root rule"),
+		Entry("left recursion", "root ::= expr\nexpr ::= term | expr \"+\" term\nterm ::= [0-9]", `line 2, column 1: rule "expr" is left recursive: expr -> expr`),
+		Entry("indirect left recursion", "root ::= a\na ::= b? \"x\"\nb ::= \"\" c\nc ::= (a | \"y\")", `line 2, column 1: rule "a" is left recursive: a -> b -> c -> a`),
+		Entry("left recursion through nullable rules", "root ::= a\na ::= b c a \"x\"\nb ::= (\"b\" | e)\nc ::= e e\ne ::= \"\"", `line 2, column 1: rule "a" is left recursive: a -> a`),
+		Entry("groups nested too deeply", "root ::= \"a\"\nx ::= "+strings.Repeat("(", 300)+`"a"`+strings.Repeat(")", 300), "line 2, column 263: groups nested more than 256 levels deep"),
+		Entry("grammars too large", "root ::= \""+strings.Repeat("a", 1024*1024)+"\"", "line 1, column 1: the grammar is larger than 1048576 bytes"),
+	)
+
+	It("parses the nested groups and the long chains of rules in linear time", func() {
+		nested := "root ::= " + strings.Repeat("(", 256) + `"a"` + strings.Repeat(")", 256)
+		_, err := ParseGrammar(nested)
+		Expect(err).ToNot(HaveOccurred())
+
+		// the rules are declared backwards, for each rule to be found nullable only after the next one
+		var chain strings.Builder
+		chain.WriteString("r20000 ::= \"x\"?\n")
+		for i := 19999; i >= 0; i-- {
+			fmt.Fprintf(&chain, "r%d ::= r%d \"\"\n", i, i+1)
+		}
+		chain.WriteString("root ::= r0 root\n")
+		start := time.Now()
+		_, err = ParseGrammar(chain.String())
+		Expect(err).To(MatchError(`line 20002, column 1: rule "root" is left recursive: root -> root`))
+		Expect(time.Since(start)).To(BeNumerically("<", 5*time.Second))
+	})
+
+	It("parses the grammars generated from JSON schemas", func() {
+		for _, schema := range []string{testInput1, `{"type": "object"}`, `{"type": "string", "pattern": "^[^,]+(, [a-z]{2,3})*$"}`} {
+			_, err := ParseGrammar(NewJSONSchemaConverter("").GrammarFromBytes([]byte(schema)))
+			Expect(err).ToNot(HaveOccurred())
+		}
+	})
+})
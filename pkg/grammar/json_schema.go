@@ -1,8 +1,9 @@
 package grammar
 
-// a golang port of https://github.com/ggerganov/llama.cpp/pull/1887
+// a golang port of https://github.com/ggerganov/llama.cpp/blob/master/examples/json-schema-to-grammar.py
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"regexp"
@@ -17,50 +18,98 @@ var (
 		"boolean": `("true" | "false") space`,
 		"number":  `("-"? ([0-9] | [1-9] [0-9]*)) ("." [0-9]+)? ([eE] [-+]? [0-9]+)? space`,
 		"integer": `("-"? ([0-9] | [1-9] [0-9]*)) space`,
-		"string": `"\"" (
-			[^"\\] |
-			"\\" (["\\/bfnrt] | "u" [0-9a-fA-F] [0-9a-fA-F] [0-9a-fA-F] [0-9a-fA-F])
-		  )* "\"" space`,
-		"null": `"null" space`,
+		"value":   `object | array | string | number | boolean | null`,
+		"object":  `"{" space ( string ":" space value ("," space string ":" space value)* )? "}" space`,
+		"array":   `"[" space ( value ("," space value)* )? "]" space`,
+		"uuid": `"\"" ` + strings.Join([]string{
+			strings.Repeat("[0-9a-fA-F]", 8),
+			strings.Repeat("[0-9a-fA-F]", 4),
+			strings.Repeat("[0-9a-fA-F]", 4),
+			strings.Repeat("[0-9a-fA-F]", 4),
+			strings.Repeat("[0-9a-fA-F]", 12),
+		}, ` "-" `) + ` "\"" space`,
+		"char":   `[^"\\] | "\\" (["\\/bfnrt] | "u" [0-9a-fA-F] [0-9a-fA-F] [0-9a-fA-F] [0-9a-fA-F])`,
+		"string": `"\"" char* "\"" space`,
+		"null":   `"null" space`,
 	}
 
+	// STRING_FORMAT_RULES match the strings of the supported formats: the "-string" rules with their quotes
+	STRING_FORMAT_RULES = map[string]string{
+		"date":             `[0-9] [0-9] [0-9] [0-9] "-" ( "0" [1-9] | "1" [0-2] ) "-" ( "0" [1-9] | [1-2] [0-9] | "3" [0-1] )`,
+		"time":             `([01] [0-9] | "2" [0-3]) ":" [0-5] [0-9] ":" [0-5] [0-9] ( "." [0-9] [0-9] [0-9] )? ( "Z" | ( "+" | "-" ) ( [01] [0-9] | "2" [0-3] ) ":" [0-5] [0-9] )`,
+		"date-time":        `date "T" time`,
+		"email":            `[a-zA-Z0-9._%+-]+ "@" [a-zA-Z0-9-]+ ("." [a-zA-Z0-9-]+)+`,
+		"date-string":      `"\"" date "\"" space`,
+		"time-string":      `"\"" time "\"" space`,
+		"date-time-string": `"\"" date-time "\"" space`,
+		"email-string":     `"\"" email "\"" space`,
+	}
+
+	// builtinRuleDeps are the rules the builtin rules refer to
+	builtinRuleDeps = map[string][]string{
+		"value":            {"object", "array", "string", "number", "boolean", "null"},
+		"object":           {"string", "value"},
+		"array":            {"value"},
+		"string":           {"char"},
+		"date-time":        {"date", "time"},
+		"date-string":      {"date"},
+		"time-string":      {"time"},
+		"date-time-string": {"date-time"},
+		"email-string":     {"email"},
+	}
+
+	// annotationKeywords don't constrain the values of a schema
+	annotationKeywords = []string{"title", "description", "default", "examples", "$comment", "$schema", "$id", "$defs", "definitions"}
+
 	INVALID_RULE_CHARS_RE     = regexp.MustCompile(`[^a-zA-Z0-9-]+`)
-	GRAMMAR_LITERAL_ESCAPE_RE = regexp.MustCompile(`[\r\n"]`)
+	UUID_FORMAT_RE            = regexp.MustCompile(`^uuid[1-5]?$`)
+	GRAMMAR_LITERAL_ESCAPE_RE = regexp.MustCompile(`[\r\n"\\]`)
 	GRAMMAR_LITERAL_ESCAPES   = map[string]string{
 		"\r": `\r`,
 		"\n": `\n`,
 		`"`:  `\"`,
+		`\`:  `\\`,
 	}
 )
 
 type JSONSchemaConverter struct {
 	propOrder map[string]int
 	rules     map[string]string
+	// refsBeingResolved are the references being visited, for the recursive ones to refer to their rule
+	refsBeingResolved map[string]bool
 }
 
 func NewJSONSchemaConverter(propOrder string) *JSONSchemaConverter {
-	propOrderSlice := strings.Split(propOrder, ",")
 	propOrderMap := make(map[string]int)
-	for idx, name := range propOrderSlice {
-		propOrderMap[name] = idx
+	if propOrder != "" {
+		for idx, name := range strings.Split(propOrder, ",") {
+			propOrderMap[name] = idx
+		}
 	}
 
 	rules := make(map[string]string)
 	rules["space"] = SPACE_RULE
 
 	return &JSONSchemaConverter{
-		propOrder: propOrderMap,
-		rules:     rules,
+		propOrder:         propOrderMap,
+		rules:             rules,
+		refsBeingResolved: make(map[string]bool),
 	}
 }
 
 func (sc *JSONSchemaConverter) formatLiteral(literal interface{}) string {
-	escaped := GRAMMAR_LITERAL_ESCAPE_RE.ReplaceAllStringFunc(jsonString(literal), func(match string) string {
+	return escapeLiteral(jsonString(literal))
+}
+
+// escapeLiteral returns the GBNF literal of a string
+func escapeLiteral(s string) string {
+	escaped := GRAMMAR_LITERAL_ESCAPE_RE.ReplaceAllStringFunc(s, func(match string) string {
 		return GRAMMAR_LITERAL_ESCAPES[match]
 	})
 	return fmt.Sprintf(`"%s"`, escaped)
 }
 
+// addRule adds a rule under the given name, or under a numbered one if another rule has this name already
 func (sc *JSONSchemaConverter) addRule(name, rule string) string {
 	escName := INVALID_RULE_CHARS_RE.ReplaceAllString(name, "-")
 	key := escName
@@ -68,7 +117,7 @@ func (sc *JSONSchemaConverter) addRule(name, rule string) string {
 		i := 0
 		for {
 			key = fmt.Sprintf("%s%d", escName, i)
-			if _, ok := sc.rules[key]; !ok {
+			if existingRule, ok := sc.rules[key]; !ok || existingRule == rule {
 				break
 			}
 			i++
@@ -78,137 +127,495 @@ func (sc *JSONSchemaConverter) addRule(name, rule string) string {
 	return key
 }
 
+// addPrimitive adds a builtin rule under the given name, along with the rules it refers to
+func (sc *JSONSchemaConverter) addPrimitive(name, builtin string) string {
+	rule, ok := PRIMITIVE_RULES[builtin]
+	if !ok {
+		rule = STRING_FORMAT_RULES[builtin]
+	}
+	key := sc.addRule(name, rule)
+	for _, dep := range builtinRuleDeps[builtin] {
+		if _, exists := sc.rules[dep]; !exists {
+			sc.addPrimitive(dep, dep)
+		}
+	}
+	return key
+}
+
+// formatGrammar returns the rules sorted by name, for the same schema to always give the same grammar
 func (sc *JSONSchemaConverter) formatGrammar() string {
+	names := make([]string, 0, len(sc.rules))
+	for name := range sc.rules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
 	var lines []string
-	for name, rule := range sc.rules {
-		lines = append(lines, fmt.Sprintf("%s ::= %s", name, rule))
+	for _, name := range names {
+		lines = append(lines, fmt.Sprintf("%s ::= %s", name, sc.rules[name]))
 	}
 	return strings.Join(lines, "\n")
 }
 
-func (sc *JSONSchemaConverter) visit(schema map[string]interface{}, name string, rootSchema map[string]interface{}) string {
-	st, existType := schema["type"]
-	var schemaType string
-	if existType {
-		schemaType = st.(string)
-	}
+func (sc *JSONSchemaConverter) visit(schema map[string]interface{}, name string, rootSchema map[string]interface{}) (string, error) {
+	schemaType, _ := schema["type"].(string)
+	schemaFormat, _ := schema["format"].(string)
 	ruleName := name
-	if name == "" {
+	if _, reserved := reservedRuleNames[name]; reserved {
+		ruleName = name + "-"
+	} else if name == "" {
 		ruleName = "root"
 	}
-	_, oneOfExists := schema["oneOf"]
-	_, anyOfExists := schema["anyOf"]
-	if oneOfExists || anyOfExists {
-		var alternatives []string
-		oneOfSchemas, oneOfExists := schema["oneOf"].([]interface{})
-		anyOfSchemas, anyOfExists := schema["anyOf"].([]interface{})
-
-		if oneOfExists {
-			for i, altSchema := range oneOfSchemas {
-				alternative := sc.visit(altSchema.(map[string]interface{}), fmt.Sprintf("%s-%d", ruleName, i), rootSchema)
-				alternatives = append(alternatives, alternative)
-			}
-		} else if anyOfExists {
-			for i, altSchema := range anyOfSchemas {
-				alternative := sc.visit(altSchema.(map[string]interface{}), fmt.Sprintf("%s-%d", ruleName, i), rootSchema)
-				alternatives = append(alternatives, alternative)
+	is := func(types ...string) bool {
+		for _, t := range types {
+			if schemaType == t {
+				return true
 			}
 		}
+		return false
+	}
+	has := func(keyword string) bool {
+		_, exists := schema[keyword]
+		return exists
+	}
 
-		rule := strings.Join(alternatives, " | ")
-		return sc.addRule(ruleName, rule)
-	} else if ref, exists := schema["$ref"].(string); exists {
-		referencedSchema := sc.resolveReference(ref, rootSchema)
-		return sc.visit(referencedSchema, name, rootSchema)
-	} else if constVal, exists := schema["const"]; exists {
-		return sc.addRule(ruleName, sc.formatLiteral(constVal))
-	} else if enumVals, exists := schema["enum"].([]interface{}); exists {
+	switch {
+	case has("$ref"):
+		ref, _ := schema["$ref"].(string)
+		refRule, err := sc.resolveRef(ref, rootSchema)
+		if err != nil {
+			return "", err
+		}
+		return sc.addRule(ruleName, refRule), nil
+	case has("oneOf") || has("anyOf"):
+		alternatives, ok := schema["oneOf"].([]interface{})
+		if !ok {
+			alternatives, _ = schema["anyOf"].([]interface{})
+		}
+		rule, err := sc.unionRule(name, alternatives, rootSchema)
+		if err != nil {
+			return "", err
+		}
+		return sc.addRule(ruleName, rule), nil
+	case isList(schema["type"]):
+		alternatives := []interface{}{}
+		for _, t := range schema["type"].([]interface{}) {
+			alternatives = append(alternatives, map[string]interface{}{"type": t})
+		}
+		rule, err := sc.unionRule(name, alternatives, rootSchema)
+		if err != nil {
+			return "", err
+		}
+		return sc.addRule(ruleName, rule), nil
+	case schema["nullable"] == true:
+		// the OpenAPI way of allowing null
+		nonNullable := map[string]interface{}{}
+		for k, v := range schema {
+			if k != "nullable" {
+				nonNullable[k] = v
+			}
+		}
+		rule, err := sc.unionRule(name, []interface{}{nonNullable, map[string]interface{}{"type": "null"}}, rootSchema)
+		if err != nil {
+			return "", err
+		}
+		return sc.addRule(ruleName, rule), nil
+	case has("const"):
+		return sc.addRule(ruleName, sc.formatLiteral(schema["const"])), nil
+	case has("enum"):
+		values, ok := schema["enum"].([]interface{})
+		if !ok || len(values) == 0 {
+			return "", fmt.Errorf("enum must be a non-empty list: %s", jsonString(schema))
+		}
 		var enumRules []string
-		for _, enumVal := range enumVals {
-			enumRule := sc.formatLiteral(enumVal)
-			enumRules = append(enumRules, enumRule)
-		}
-		rule := strings.Join(enumRules, " | ")
-		return sc.addRule(ruleName, rule)
-	} else if properties, exists := schema["properties"].(map[string]interface{}); schemaType == "object" && exists {
-		propOrder := sc.propOrder
-		var propPairs []struct {
-			propName   string
-			propSchema map[string]interface{}
-		}
-
-		for propName, propSchema := range properties {
-			propPairs = append(propPairs, struct {
-				propName   string
-				propSchema map[string]interface{}
-			}{propName: propName, propSchema: propSchema.(map[string]interface{})})
-		}
-
-		sort.Slice(propPairs, func(i, j int) bool {
-			iOrder := propOrder[propPairs[i].propName]
-			jOrder := propOrder[propPairs[j].propName]
-			if iOrder != 0 && jOrder != 0 {
-				return iOrder < jOrder
+		for _, v := range values {
+			enumRules = append(enumRules, sc.formatLiteral(v))
+		}
+		return sc.addRule(ruleName, strings.Join(enumRules, " | ")), nil
+	case is("", "object") && (has("properties") || has("additionalProperties") && schema["additionalProperties"] != true):
+		properties, _ := schema["properties"].(map[string]interface{})
+		required := map[string]bool{}
+		if r, ok := schema["required"].([]interface{}); ok {
+			for _, name := range r {
+				if name, ok := name.(string); ok {
+					required[name] = true
+				}
+			}
+		}
+		rule, err := sc.objectRule(properties, required, name, schema["additionalProperties"], rootSchema)
+		if err != nil {
+			return "", err
+		}
+		return sc.addRule(ruleName, rule), nil
+	case is("", "object") && has("allOf"):
+		// the properties of the schemas are merged, those of the anyOf schemas in them being optional
+		properties := map[string]interface{}{}
+		required := map[string]bool{}
+		addComponent := func(component interface{}, isRequired bool) error {
+			c, _ := component.(map[string]interface{})
+			if ref, ok := c["$ref"].(string); ok {
+				var err error
+				if c, err = resolvePointer(ref, rootSchema); err != nil {
+					return err
+				}
+			}
+			props, _ := c["properties"].(map[string]interface{})
+			for name, propSchema := range props {
+				properties[name] = propSchema
+				if isRequired {
+					required[name] = true
+				}
+			}
+			return nil
+		}
+		all, _ := schema["allOf"].([]interface{})
+		for _, s := range all {
+			component, _ := s.(map[string]interface{})
+			alternatives, ok := component["anyOf"].([]interface{})
+			if !ok {
+				if err := addComponent(s, true); err != nil {
+					return "", err
+				}
+				continue
+			}
+			for _, alternative := range alternatives {
+				if err := addComponent(alternative, false); err != nil {
+					return "", err
+				}
+			}
+		}
+		rule, err := sc.objectRule(properties, required, name, nil, rootSchema)
+		if err != nil {
+			return "", err
+		}
+		return sc.addRule(ruleName, rule), nil
+	case is("", "array") && (has("items") || has("prefixItems")):
+		items, ok := schema["items"]
+		if !ok {
+			items = schema["prefixItems"]
+		}
+		if tuple, ok := items.([]interface{}); ok {
+			var itemRules []string
+			for i, item := range tuple {
+				itemSchema, _ := item.(map[string]interface{})
+				itemRule, err := sc.visit(itemSchema, fmt.Sprintf("%stuple-%d", prefix(name), i), rootSchema)
+				if err != nil {
+					return "", err
+				}
+				itemRules = append(itemRules, itemRule)
 			}
-			return propPairs[i].propName < propPairs[j].propName
-		})
+			return sc.addRule(ruleName, `"[" space `+strings.Join(itemRules, ` "," space `)+` "]" space`), nil
+		}
+		itemSchema, _ := items.(map[string]interface{})
+		itemRule, err := sc.visit(itemSchema, prefix(name)+"item", rootSchema)
+		if err != nil {
+			return "", err
+		}
+		minItems, maxItems := schemaCount(schema, "minItems", 0), schemaCount(schema, "maxItems", -1)
+		return sc.addRule(ruleName, `"[" space `+buildRepetition(itemRule, minItems, maxItems, `"," space`)+` "]" space`), nil
+	case is("", "string") && has("pattern"):
+		pattern, _ := schema["pattern"].(string)
+		expression, err := patternExpression(pattern, true)
+		if err != nil {
+			return "", err
+		}
+		return sc.addRule(ruleName, `"\"" `+expression+` "\"" space`), nil
+	case is("", "string") && UUID_FORMAT_RE.MatchString(schemaFormat):
+		if ruleName == "root" {
+			return sc.addPrimitive("root", "uuid"), nil
+		}
+		return sc.addPrimitive(schemaFormat, "uuid"), nil
+	case is("", "string") && STRING_FORMAT_RULES[schemaFormat+"-string"] != "":
+		primitiveName := schemaFormat + "-string"
+		return sc.addRule(ruleName, sc.addPrimitive(primitiveName, primitiveName)), nil
+	case is("string") && (has("minLength") || has("maxLength")):
+		charRule := sc.addPrimitive("char", "char")
+		minLength, maxLength := schemaCount(schema, "minLength", 0), schemaCount(schema, "maxLength", -1)
+		return sc.addRule(ruleName, `"\"" `+buildRepetition(charRule, minLength, maxLength, "")+` "\"" space`), nil
+	case is("object") || isEmptySchema(schema):
+		return sc.addRule(ruleName, sc.addPrimitive("object", "object")), nil
+	default:
+		if _, exists := PRIMITIVE_RULES[schemaType]; !exists {
+			return "", fmt.Errorf("unrecognized schema: %s", jsonString(schema))
+		}
+		if ruleName == "root" {
+			return sc.addPrimitive("root", schemaType), nil
+		}
+		return sc.addPrimitive(schemaType, schemaType), nil
+	}
+}
 
-		var rule strings.Builder
-		rule.WriteString(`"{" space`)
+// unionRule returns the rule matching any of the alternative schemas
+func (sc *JSONSchemaConverter) unionRule(name string, alternatives []interface{}, rootSchema map[string]interface{}) (string, error) {
+	var rules []string
+	for i, alternative := range alternatives {
+		altSchema, ok := alternative.(map[string]interface{})
+		if !ok {
+			return "", fmt.Errorf("unrecognized schema: %s", jsonString(alternative))
+		}
+		altName := fmt.Sprintf("%s-%d", name, i)
+		if name == "" {
+			altName = fmt.Sprintf("alternative-%d", i)
+		}
+		rule, err := sc.visit(altSchema, altName, rootSchema)
+		if err != nil {
+			return "", err
+		}
+		rules = append(rules, rule)
+	}
+	return strings.Join(rules, " | "), nil
+}
 
-		for i, propPair := range propPairs {
-			propName := propPair.propName
-			propSchema := propPair.propSchema
-			propRuleName := sc.visit(propSchema, fmt.Sprintf("%s-%s", ruleName, propName), rootSchema)
+// objectRule returns the rule of an object with the given properties, in the order they are requested:
+// the properties in the converter order come first, the others follow in alphabetical order.
+// An optional property may be left out, and the additional properties follow the declared ones.
+func (sc *JSONSchemaConverter) objectRule(properties map[string]interface{}, required map[string]bool, name string, additionalProperties interface{}, rootSchema map[string]interface{}) (string, error) {
+	names := make([]string, 0, len(properties))
+	for propName := range properties {
+		names = append(names, propName)
+	}
+	sort.Strings(names)
 
-			if i > 0 {
-				rule.WriteString(` "," space`)
-			}
+	kvRules := map[string]string{}
+	for _, propName := range names {
+		propSchema, ok := properties[propName].(map[string]interface{})
+		if !ok {
+			return "", fmt.Errorf("unrecognized schema of property %q: %s", propName, jsonString(properties[propName]))
+		}
+		propRule, err := sc.visit(propSchema, prefix(name)+propName, rootSchema)
+		if err != nil {
+			return "", err
+		}
+		kvRules[propName] = sc.addRule(prefix(name)+propName+"-kv", fmt.Sprintf(`%s space ":" space %s`, sc.formatLiteral(propName), propRule))
+	}
 
-			rule.WriteString(fmt.Sprintf(` %s space ":" space %s`, sc.formatLiteral(propName), propRuleName))
+	order := func(name string) int {
+		if idx, ok := sc.propOrder[name]; ok {
+			return idx
+		}
+		return len(sc.propOrder)
+	}
+	sort.SliceStable(names, func(i, j int) bool {
+		return order(names[i]) < order(names[j])
+	})
+	var requiredProps, optionalProps []string
+	for _, propName := range names {
+		if required[propName] {
+			requiredProps = append(requiredProps, propName)
+		} else {
+			optionalProps = append(optionalProps, propName)
 		}
+	}
 
-		rule.WriteString(` "}" space`)
-		return sc.addRule(ruleName, rule.String())
-	} else if items, exists := schema["items"].(map[string]interface{}); schemaType == "array" && exists {
-		itemRuleName := sc.visit(items, fmt.Sprintf("%s-item", ruleName), rootSchema)
-		rule := fmt.Sprintf(`"[" space (%s ("," space %s)*)? "]" space`, itemRuleName, itemRuleName)
-		return sc.addRule(ruleName, rule)
-	} else {
-		primitiveRule, exists := PRIMITIVE_RULES[schemaType]
-		if !exists {
-			panic(fmt.Sprintf("Unrecognized schema: %v", schema))
+	additionalSchema, isSchema := additionalProperties.(map[string]interface{})
+	if isSchema || additionalProperties == true {
+		subName := prefix(name) + "additional"
+		if !isSchema {
+			additionalSchema = map[string]interface{}{}
 		}
-		if ruleName == "root" {
-			schemaType = "root"
+		valueRule, err := sc.visit(additionalSchema, subName+"-value", rootSchema)
+		if err != nil {
+			return "", err
 		}
-		return sc.addRule(schemaType, primitiveRule)
+		kvRules["*"] = sc.addRule(subName+"-kv", sc.addPrimitive("string", "string")+` ":" space `+valueRule)
+		optionalProps = append(optionalProps, "*")
+	}
+
+	var requiredKVs []string
+	for _, propName := range requiredProps {
+		requiredKVs = append(requiredKVs, kvRules[propName])
 	}
+	rule := `"{" space ` + strings.Join(requiredKVs, ` "," space `)
+	if len(optionalProps) > 0 {
+		rule += " ("
+		if len(requiredProps) > 0 {
+			rule += ` "," space ( `
+		}
+		var alternatives []string
+		for i := range optionalProps {
+			alternatives = append(alternatives, sc.optionalPropsRule(optionalProps[i:], false, kvRules, name))
+		}
+		rule += strings.Join(alternatives, " | ")
+		if len(requiredProps) > 0 {
+			rule += " )"
+		}
+		rule += " )?"
+	}
+	rule += ` "}" space`
+	return rule, nil
 }
-func (sc *JSONSchemaConverter) resolveReference(ref string, rootSchema map[string]interface{}) map[string]interface{} {
-	if !strings.HasPrefix(ref, "#/$defs/") {
-		panic(fmt.Sprintf("Invalid reference format: %s", ref))
+
+// optionalPropsRule returns the rule of the optional properties starting with the first given one,
+// each of the following ones being optional
+func (sc *JSONSchemaConverter) optionalPropsRule(props []string, firstIsOptional bool, kvRules map[string]string, name string) string {
+	kvRule := kvRules[props[0]]
+	var rule string
+	switch {
+	case props[0] == "*":
+		rule = sc.addRule(prefix(name)+"additional-kvs", fmt.Sprintf(`%s ( "," space %s )*`, kvRule, kvRule))
+	case firstIsOptional:
+		rule = fmt.Sprintf(`( "," space %s )?`, kvRule)
+	default:
+		rule = kvRule
 	}
+	if len(props) > 1 {
+		rule += " " + sc.addRule(prefix(name)+props[0]+"-rest", sc.optionalPropsRule(props[1:], true, kvRules, name))
+	}
+	return rule
+}
+
+// resolveRef returns the rule of the schema a reference points to, named after the last part of the reference
+func (sc *JSONSchemaConverter) resolveRef(ref string, rootSchema map[string]interface{}) (string, error) {
+	target, err := resolvePointer(ref, rootSchema)
+	if err != nil {
+		return "", err
+	}
+	refName := ref[strings.LastIndex(ref, "/")+1:]
+	if _, exists := sc.rules[refName]; !exists && !sc.refsBeingResolved[ref] {
+		sc.refsBeingResolved[ref] = true
+		defer delete(sc.refsBeingResolved, ref)
+		return sc.visit(target, refName, rootSchema)
+	}
+	return refName, nil
+}
 
-	defKey := strings.TrimPrefix(ref, "#/$defs/")
-	definitions, exists := rootSchema["$defs"].(map[string]interface{})
-	if !exists {
-		fmt.Println(rootSchema)
+// resolvePointer returns the schema a local reference, as in #/$defs/name, points to
+func resolvePointer(ref string, rootSchema map[string]interface{}) (map[string]interface{}, error) {
+	if !strings.HasPrefix(ref, "#/") {
+		return nil, fmt.Errorf("unsupported reference: %s", ref)
+	}
+	var target interface{} = rootSchema
+	for _, part := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+		part = strings.ReplaceAll(strings.ReplaceAll(part, "~1", "/"), "~0", "~")
+		m, ok := target.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("definition not found: %s", ref)
+		}
+		if target, ok = m[part]; !ok {
+			return nil, fmt.Errorf("definition not found: %s", ref)
+		}
+	}
+	schema, ok := target.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("definition not found: %s", ref)
+	}
+	return schema, nil
+}
 
-		panic("No definitions found in the schema")
+// buildRepetition returns the expression repeating an item between min and max times, max being -1 for no limit.
+// The repetitions are expanded, for the grammar parsers that don't support the {min,max} notation.
+func buildRepetition(itemRule string, min, max int, separatorRule string) string {
+	if separatorRule == "" {
+		if min == 0 && max == 1 {
+			return itemRule + "?"
+		} else if min == 1 && max < 0 {
+			return itemRule + "+"
+		}
 	}
 
-	def, exists := definitions[defKey].(map[string]interface{})
-	if !exists {
-		fmt.Println(definitions)
+	var result string
+	if min > 0 {
+		separator := " "
+		if separatorRule != "" {
+			separator = " " + separatorRule + " "
+		}
+		items := make([]string, min)
+		for i := range items {
+			items[i] = itemRule
+		}
+		result = strings.Join(items, separator)
+	}
 
-		panic(fmt.Sprintf("Definition not found: %s", defKey))
+	var optRepetitions func(upTo int, prefixWithSeparator bool) string
+	optRepetitions = func(upTo int, prefixWithSeparator bool) string {
+		content := itemRule
+		if prefixWithSeparator && separatorRule != "" {
+			content = separatorRule + " " + itemRule
+		}
+		switch {
+		case upTo == 0:
+			return ""
+		case upTo == 1:
+			return "(" + content + ")?"
+		case separatorRule != "" && !prefixWithSeparator:
+			return "(" + content + " " + optRepetitions(upTo-1, true) + ")?"
+		}
+		return strings.TrimRight(strings.Repeat("("+content+" ", upTo), " ") + strings.Repeat(")?", upTo)
 	}
 
-	return def
+	if min > 0 && max != min {
+		result += " "
+	}
+	if max >= 0 {
+		result += optRepetitions(max-min, min > 0)
+	} else {
+		itemOperator := "(" + itemRule + ")"
+		if separatorRule != "" {
+			itemOperator = "(" + separatorRule + " " + itemRule + ")"
+		}
+		if min == 0 && separatorRule != "" {
+			result = "(" + itemRule + " " + itemOperator + "*)?"
+		} else {
+			result += itemOperator + "*"
+		}
+	}
+	return result
 }
+
+// reservedRuleNames are the names of the builtin rules, which the rules of the schema are not given
+var reservedRuleNames = func() map[string]struct{} {
+	names := map[string]struct{}{"root": {}}
+	for name := range PRIMITIVE_RULES {
+		names[name] = struct{}{}
+	}
+	for name := range STRING_FORMAT_RULES {
+		names[name] = struct{}{}
+	}
+	return names
+}()
+
+func prefix(name string) string {
+	if name == "" {
+		return ""
+	}
+	return name + "-"
+}
+
+func isList(v interface{}) bool {
+	_, ok := v.([]interface{})
+	return ok
+}
+
+// isEmptySchema tells if the schema has no keyword constraining the values, matching any value
+func isEmptySchema(schema map[string]interface{}) bool {
+	for keyword := range schema {
+		annotation := false
+		for _, a := range annotationKeywords {
+			if keyword == a {
+				annotation = true
+				break
+			}
+		}
+		if !annotation {
+			return false
+		}
+	}
+	return true
+}
+
+// schemaCount returns a count keyword of the schema, as minItems, or the default value if it isn't set
+func schemaCount(schema map[string]interface{}, keyword string, defaultValue int) int {
+	if n, ok := schemaNumber(schema, keyword); ok {
+		return int(n)
+	}
+	return defaultValue
+}
+
+// Grammar returns the grammar of the schema. It panics if the schema has features the converter doesn't
+// support: use JSONSchemaGrammar to get an error instead.
 func (sc *JSONSchemaConverter) Grammar(schema map[string]interface{}) string {
-	sc.visit(schema, "", schema)
+	if _, err := sc.visit(schema, "", schema); err != nil {
+		panic(err)
+	}
 	return sc.formatGrammar()
 }
 
@@ -218,9 +625,22 @@ func (sc *JSONSchemaConverter) GrammarFromBytes(b []byte) string {
 	return sc.Grammar(schema)
 }
 
+// JSONSchemaGrammar returns the grammar of the schema, or an error if the schema has features the converter doesn't support
+func JSONSchemaGrammar(schema map[string]interface{}, propOrder string) (string, error) {
+	sc := NewJSONSchemaConverter(propOrder)
+	if _, err := sc.visit(schema, "", schema); err != nil {
+		return "", fmt.Errorf("unsupported JSON schema: %w", err)
+	}
+	return sc.formatGrammar(), nil
+}
+
+// jsonString returns the JSON encoding of a value, leaving the HTML characters as they are
 func jsonString(v interface{}) string {
-	b, _ := json.Marshal(v)
-	return string(b)
+	var b bytes.Buffer
+	e := json.NewEncoder(&b)
+	e.SetEscapeHTML(false)
+	_ = e.Encode(v)
+	return strings.TrimSuffix(b.String(), "\n")
 }
 
 type FunctionName struct {
@@ -235,11 +655,13 @@ type Properties struct {
 type Argument struct {
 	Type       string                 `json:"type"`
 	Properties map[string]interface{} `json:"properties"`
+	Required   []string               `json:"required,omitempty"`
 }
 
 type Item struct {
 	Type       string     `json:"type"`
 	Properties Properties `json:"properties"`
+	Required   []string   `json:"required,omitempty"`
 }
 
 type JSONFunctionStructure struct {
@@ -249,6 +671,39 @@ type JSONFunctionStructure struct {
 }
 
 func (j JSONFunctionStructure) Grammar(propOrder string) string {
+	return NewJSONSchemaConverter(propOrder).Grammar(j.schema())
+}
+
+// schema returns the JSON schema of the calls, in which the function and its arguments are always required
+func (j JSONFunctionStructure) schema() map[string]interface{} {
+	withRequired := func(items []Item) []Item {
+		if items == nil {
+			return nil
+		}
+		result := make([]Item, len(items))
+		for i, item := range items {
+			item.Required = []string{"function", "arguments"}
+			result[i] = item
+		}
+		return result
+	}
+	j.OneOf, j.AnyOf = withRequired(j.OneOf), withRequired(j.AnyOf)
+
 	dat, _ := json.Marshal(j)
-	return NewJSONSchemaConverter(propOrder).GrammarFromBytes(dat)
+	var schema map[string]interface{}
+	_ = json.Unmarshal(dat, &schema)
+	return schema
+}
+
+// ParallelGrammar returns a grammar allowing the model to call one of the functions,
+// or several of them at once as a JSON array of calls
+func (j JSONFunctionStructure) ParallelGrammar(propOrder string) string {
+	schema := j.schema()
+	sc := NewJSONSchemaConverter(propOrder)
+	call, err := sc.visit(schema, "call", schema)
+	if err != nil {
+		panic(err)
+	}
+	sc.addRule("root", fmt.Sprintf(`"[" space %s ("," space %s)* "]" space | %s`, call, call, call))
+	return sc.formatGrammar()
 }
@@ -24,7 +24,8 @@ const (
 							"time": {"type": "string"}
 						}
 					}
-				}
+				},
+				"required": ["function", "arguments"]
 			},
 			{
 				"type": "object",
@@ -36,36 +37,39 @@ const (
 							"query": {"type": "string"}
 						}
 					}
-				}
+				},
+				"required": ["function", "arguments"]
 			}
 		]
 	}`
 
-	inputResult1 = `root-0-function ::= "\"create_event\""
-root-0 ::= "{" space "\"arguments\"" space ":" space root-0-arguments "," space "\"function\"" space ":" space root-0-function "}" space
-root-1-arguments ::= "{" space "\"query\"" space ":" space string "}" space
-root ::= root-0 | root-1
+	inputResult1 = `alternative-0 ::= "{" space alternative-0-arguments-kv "," space alternative-0-function-kv "}" space
+alternative-0-arguments ::= "{" space  (alternative-0-arguments-date-kv alternative-0-arguments-date-rest | alternative-0-arguments-time-kv alternative-0-arguments-time-rest | alternative-0-arguments-title-kv )? "}" space
+alternative-0-arguments-date-kv ::= "\"date\"" space ":" space string
+alternative-0-arguments-date-rest ::= ( "," space alternative-0-arguments-time-kv )? alternative-0-arguments-time-rest
+alternative-0-arguments-kv ::= "\"arguments\"" space ":" space alternative-0-arguments
+alternative-0-arguments-time-kv ::= "\"time\"" space ":" space string
+alternative-0-arguments-time-rest ::= ( "," space alternative-0-arguments-title-kv )?
+alternative-0-arguments-title-kv ::= "\"title\"" space ":" space string
+alternative-0-function ::= "\"create_event\""
+alternative-0-function-kv ::= "\"function\"" space ":" space alternative-0-function
+alternative-1 ::= "{" space alternative-1-arguments-kv "," space alternative-1-function-kv "}" space
+alternative-1-arguments ::= "{" space  (alternative-1-arguments-query-kv )? "}" space
+alternative-1-arguments-kv ::= "\"arguments\"" space ":" space alternative-1-arguments
+alternative-1-arguments-query-kv ::= "\"query\"" space ":" space string
+alternative-1-function ::= "\"search\""
+alternative-1-function-kv ::= "\"function\"" space ":" space alternative-1-function
+char ::= [^"\\] | "\\" (["\\/bfnrt] | "u" [0-9a-fA-F] [0-9a-fA-F] [0-9a-fA-F] [0-9a-fA-F])
+root ::= alternative-0 | alternative-1
 space ::= " "?
-root-0-arguments ::= "{" space "\"date\"" space ":" space string "," space "\"time\"" space ":" space string "," space "\"title\"" space ":" space string "}" space
-root-1 ::= "{" space "\"arguments\"" space ":" space root-1-arguments "," space "\"function\"" space ":" space root-1-function "}" space
-string ::= "\"" (
-	[^"\\] |
-	"\\" (["\\/bfnrt] | "u" [0-9a-fA-F] [0-9a-fA-F] [0-9a-fA-F] [0-9a-fA-F])
-)* "\"" space
-root-1-function ::= "\"search\""`
+string ::= "\"" char* "\"" space`
 )
 
 var _ = Describe("JSON schema grammar tests", func() {
 	Context("JSON", func() {
 		It("generates a valid grammar from JSON schema", func() {
 			grammar := NewJSONSchemaConverter("").GrammarFromBytes([]byte(testInput1))
-			results := strings.Split(inputResult1, "\n")
-			for _, r := range results {
-				if r != "" {
-					Expect(grammar).To(ContainSubstring(r))
-				}
-			}
-			Expect(len(results)).To(Equal(len(strings.Split(grammar, "\n"))))
+			Expect(grammar).To(Equal(inputResult1))
 		})
 		It("generates a valid grammar from JSON Objects", func() {
 
@@ -104,13 +108,325 @@ var _ = Describe("JSON schema grammar tests", func() {
 				}}
 
 			grammar := structuredGrammar.Grammar("")
-			results := strings.Split(inputResult1, "\n")
-			for _, r := range results {
-				if r != "" {
-					Expect(grammar).To(ContainSubstring(r))
-				}
+			Expect(grammar).To(Equal(inputResult1))
+		})
+		It("orders the properties as requested", func() {
+			grammar := NewJSONSchemaConverter("function,arguments").GrammarFromBytes([]byte(testInput1))
+			Expect(grammar).To(ContainSubstring(`alternative-0 ::= "{" space alternative-0-function-kv "," space alternative-0-arguments-kv "}" space`))
+			// the properties not in the order are sorted alphabetically
+			Expect(grammar).To(ContainSubstring(`alternative-0-arguments ::= "{" space  (alternative-0-arguments-date-kv alternative-0-arguments-date-rest | alternative-0-arguments-time-kv alternative-0-arguments-time-rest | alternative-0-arguments-title-kv )? "}" space`))
+
+			grammar = NewJSONSchemaConverter("title").GrammarFromBytes([]byte(testInput1))
+			Expect(grammar).To(ContainSubstring(`alternative-0-arguments ::= "{" space  (alternative-0-arguments-title-kv alternative-0-arguments-title-rest | alternative-0-arguments-date-kv alternative-0-arguments-date-rest | alternative-0-arguments-time-kv )? "}" space`))
+		})
+		It("generates a grammar for objects of any content", func() {
+			grammar, err := JSONSchemaGrammar(map[string]interface{}{"type": "object"}, "")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(grammar).To(ContainSubstring(`root ::= object`))
+			Expect(grammar).To(ContainSubstring(`object ::= "{" space ( string ":" space value ("," space string ":" space value)* )? "}" space`))
+			Expect(grammar).To(ContainSubstring(`value ::= object | array | string | number | boolean | null`))
+			Expect(grammar).ToNot(ContainSubstring(`integer`))
+
+			grammar, err = JSONSchemaGrammar(map[string]interface{}{"type": "object", "properties": map[string]interface{}{
+				"data": map[string]interface{}{"type": "array"},
+			}, "required": []interface{}{"data"}}, "")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(grammar).To(ContainSubstring(`root ::= "{" space data-kv "}" space`))
+			Expect(grammar).To(ContainSubstring(`data-kv ::= "\"data\"" space ":" space array`))
+		})
+		It("returns an error for unsupported schemas", func() {
+			_, err := JSONSchemaGrammar(map[string]interface{}{"type": "tuple"}, "")
+			Expect(err).To(MatchError(ContainSubstring("unsupported JSON schema")))
+		})
+		It("generates a grammar allowing several function calls at once", func() {
+			var functions Functions = []Function{
+				{
+					Name: "create_event",
+					Parameters: map[string]interface{}{
+						"properties": map[string]interface{}{
+							"title": map[string]interface{}{"type": "string"},
+						},
+					},
+				},
+				{
+					Name: "search",
+					Parameters: map[string]interface{}{
+						"properties": map[string]interface{}{
+							"query": map[string]interface{}{"type": "string"},
+						},
+					},
+				},
 			}
-			Expect(len(results)).To(Equal(len(strings.Split(grammar, "\n"))))
+
+			grammar := functions.ToJSONStructure().ParallelGrammar("")
+			Expect(grammar).To(ContainSubstring(`root ::= "[" space call ("," space call)* "]" space | call`))
+			Expect(grammar).To(ContainSubstring(`call ::= call-0 | call-1`))
+			Expect(grammar).To(ContainSubstring(`call-0-function ::= "\"create_event\""`))
+			Expect(grammar).To(ContainSubstring(`call-1-arguments ::= "{" space  (call-1-arguments-query-kv )? "}" space`))
+			Expect(grammar).ToNot(ContainSubstring(`alternative-0`))
 		})
 	})
 })
+
+// rules of the grammars of the llama.cpp converter (examples/json-schema-to-grammar.py), which the tables follow
+const (
+	spaceRule   = `space ::= " "?`
+	charRule    = `char ::= [^"\\] | "\\" (["\\/bfnrt] | "u" [0-9a-fA-F] [0-9a-fA-F] [0-9a-fA-F] [0-9a-fA-F])`
+	stringRule  = `string ::= "\"" char* "\"" space`
+	integerRule = `integer ::= ("-"? ([0-9] | [1-9] [0-9]*)) space`
+	numberRule  = `number ::= ("-"? ([0-9] | [1-9] [0-9]*)) ("." [0-9]+)? ([eE] [-+]? [0-9]+)? space`
+	booleanRule = `boolean ::= ("true" | "false") space`
+	nullRule    = `null ::= "null" space`
+)
+
+func rules(rules ...string) string {
+	return strings.Join(rules, "\n")
+}
+
+var _ = Describe("JSON schema conversion", func() {
+	DescribeTable("converts the schema as the llama.cpp converter",
+		func(schema, propOrder, expected string) {
+			grammar := NewJSONSchemaConverter(propOrder).GrammarFromBytes([]byte(schema))
+			Expect(grammar).To(Equal(expected))
+			_, err := ParseGrammar(grammar)
+			Expect(err).ToNot(HaveOccurred())
+		},
+		Entry("empty schema", `{}`, "", rules(
+			`array ::= "[" space ( value ("," space value)* )? "]" space`,
+			booleanRule,
+			charRule,
+			nullRule,
+			numberRule,
+			`object ::= "{" space ( string ":" space value ("," space string ":" space value)* )? "}" space`,
+			`root ::= object`,
+			spaceRule,
+			stringRule,
+			`value ::= object | array | string | number | boolean | null`,
+		)),
+		Entry("required properties", `{
+			"type": "object",
+			"properties": {"a": {"type": "string"}, "b": {"type": "string"}},
+			"required": ["a", "b"],
+			"additionalProperties": false
+		}`, "", rules(
+			`a-kv ::= "\"a\"" space ":" space string`,
+			`b-kv ::= "\"b\"" space ":" space string`,
+			charRule,
+			`root ::= "{" space a-kv "," space b-kv "}" space`,
+			spaceRule,
+			stringRule,
+		)),
+		Entry("optional properties", `{
+			"properties": {"a": {"type": "string"}, "b": {"type": "string"}, "c": {"type": "string"}},
+			"additionalProperties": false
+		}`, "", rules(
+			`a-kv ::= "\"a\"" space ":" space string`,
+			`a-rest ::= ( "," space b-kv )? b-rest`,
+			`b-kv ::= "\"b\"" space ":" space string`,
+			`b-rest ::= ( "," space c-kv )?`,
+			`c-kv ::= "\"c\"" space ":" space string`,
+			charRule,
+			`root ::= "{" space  (a-kv a-rest | b-kv b-rest | c-kv )? "}" space`,
+			spaceRule,
+			stringRule,
+		)),
+		Entry("required and optional properties in the requested order", `{
+			"properties": {"a": {"type": "string"}, "b": {"type": "string"}, "c": {"type": "string"}, "d": {"type": "string"}},
+			"required": ["b", "d"]
+		}`, "d,c", rules(
+			`a-kv ::= "\"a\"" space ":" space string`,
+			`b-kv ::= "\"b\"" space ":" space string`,
+			`c-kv ::= "\"c\"" space ":" space string`,
+			`c-rest ::= ( "," space a-kv )?`,
+			charRule,
+			`d-kv ::= "\"d\"" space ":" space string`,
+			`root ::= "{" space d-kv "," space b-kv ( "," space ( c-kv c-rest | a-kv ) )? "}" space`,
+			spaceRule,
+			stringRule,
+		)),
+		Entry("additional properties", `{
+			"type": "object",
+			"additionalProperties": {"type": "array", "items": {"type": "number"}}
+		}`, "", rules(
+			`additional-kv ::= string ":" space additional-value`,
+			`additional-kvs ::= additional-kv ( "," space additional-kv )*`,
+			`additional-value ::= "[" space (number ("," space number)*)? "]" space`,
+			charRule,
+			numberRule,
+			`root ::= "{" space  (additional-kvs )? "}" space`,
+			spaceRule,
+			stringRule,
+		)),
+		Entry("reference to definitions", `{
+			"$ref": "#/definitions/MyType",
+			"definitions": {
+				"MyType": {"type": "object", "properties": {"a": {"type": "string"}}, "required": ["a"], "additionalProperties": false}
+			}
+		}`, "", rules(
+			`MyType ::= "{" space MyType-a-kv "}" space`,
+			`MyType-a-kv ::= "\"a\"" space ":" space string`,
+			charRule,
+			`root ::= MyType`,
+			spaceRule,
+			stringRule,
+		)),
+		Entry("recursive reference to $defs", `{
+			"$ref": "#/$defs/node",
+			"$defs": {
+				"node": {
+					"type": "object",
+					"properties": {"name": {"type": "string"}, "children": {"type": "array", "items": {"$ref": "#/$defs/node"}}},
+					"required": ["name"]
+				}
+			}
+		}`, "", rules(
+			charRule,
+			`node ::= "{" space node-name-kv ( "," space ( node-children-kv ) )? "}" space`,
+			`node-children ::= "[" space (node-children-item ("," space node-children-item)*)? "]" space`,
+			`node-children-item ::= node`,
+			`node-children-kv ::= "\"children\"" space ":" space node-children`,
+			`node-name-kv ::= "\"name\"" space ":" space string`,
+			`root ::= node`,
+			spaceRule,
+			stringRule,
+		)),
+		Entry("allOf", `{
+			"allOf": [
+				{"$ref": "#/definitions/named"},
+				{"properties": {"age": {"type": "integer"}}},
+				{"anyOf": [{"properties": {"nick": {"type": "string"}}}]}
+			],
+			"definitions": {"named": {"properties": {"name": {"type": "string"}}}}
+		}`, "", rules(
+			`age-kv ::= "\"age\"" space ":" space integer`,
+			charRule,
+			integerRule,
+			`name-kv ::= "\"name\"" space ":" space string`,
+			`nick-kv ::= "\"nick\"" space ":" space string`,
+			`root ::= "{" space age-kv "," space name-kv ( "," space ( nick-kv ) )? "}" space`,
+			spaceRule,
+			stringRule,
+		)),
+		Entry("const and enum of mixed types", `{
+			"type": "object",
+			"properties": {"kind": {"const": "event"}, "level": {"enum": ["low", 1, 2.5, null, true]}},
+			"required": ["kind", "level"]
+		}`, "", rules(
+			`kind ::= "\"event\""`,
+			`kind-kv ::= "\"kind\"" space ":" space kind`,
+			`level ::= "\"low\"" | "1" | "2.5" | "null" | "true"`,
+			`level-kv ::= "\"level\"" space ":" space level`,
+			`root ::= "{" space kind-kv "," space level-kv "}" space`,
+			spaceRule,
+		)),
+		Entry("array items", `{"type": "array", "items": {"type": "string"}}`, "", rules(
+			charRule,
+			`root ::= "[" space (string ("," space string)*)? "]" space`,
+			spaceRule,
+			stringRule,
+		)),
+		Entry("minItems and maxItems", `{"type": "array", "items": {"type": "boolean"}, "minItems": 1, "maxItems": 3}`, "", rules(
+			booleanRule,
+			`root ::= "[" space boolean ("," space boolean ("," space boolean)?)? "]" space`,
+			spaceRule,
+		)),
+		Entry("minItems", `{"type": "array", "items": {"type": "boolean"}, "minItems": 2}`, "", rules(
+			booleanRule,
+			`root ::= "[" space boolean "," space boolean ("," space boolean)* "]" space`,
+			spaceRule,
+		)),
+		Entry("maxItems", `{"type": "array", "items": {"type": "boolean"}, "maxItems": 2}`, "", rules(
+			booleanRule,
+			`root ::= "[" space (boolean ("," space boolean)?)? "]" space`,
+			spaceRule,
+		)),
+		Entry("tuple", `{"prefixItems": [{"type": "string"}, {"type": "number"}]}`, "", rules(
+			charRule,
+			numberRule,
+			`root ::= "[" space string "," space number "]" space`,
+			spaceRule,
+			stringRule,
+		)),
+		Entry("string length", `{"type": "string", "minLength": 1, "maxLength": 3}`, "", rules(
+			charRule,
+			`root ::= "\"" char (char (char)?)? "\"" space`,
+			spaceRule,
+		)),
+		Entry("nullable types", `{
+			"type": "object",
+			"properties": {"nick": {"type": ["string", "null"]}, "age": {"type": "integer", "nullable": true}},
+			"required": ["nick", "age"]
+		}`, "", rules(
+			`age ::= integer | null`,
+			`age-kv ::= "\"age\"" space ":" space age`,
+			charRule,
+			integerRule,
+			`nick ::= string | null`,
+			`nick-kv ::= "\"nick\"" space ":" space nick`,
+			nullRule,
+			`root ::= "{" space age-kv "," space nick-kv "}" space`,
+			spaceRule,
+			stringRule,
+		)),
+		Entry("string formats", `{
+			"type": "object",
+			"properties": {
+				"day": {"type": "string", "format": "date"},
+				"id": {"type": "string", "format": "uuid"},
+				"mail": {"type": "string", "format": "email"}
+			},
+			"required": ["day", "id", "mail"]
+		}`, "", rules(
+			`date ::= [0-9] [0-9] [0-9] [0-9] "-" ( "0" [1-9] | "1" [0-2] ) "-" ( "0" [1-9] | [1-2] [0-9] | "3" [0-1] )`,
+			`date-string ::= "\"" date "\"" space`,
+			`day ::= date-string`,
+			`day-kv ::= "\"day\"" space ":" space day`,
+			`email ::= [a-zA-Z0-9._%+-]+ "@" [a-zA-Z0-9-]+ ("." [a-zA-Z0-9-]+)+`,
+			`email-string ::= "\"" email "\"" space`,
+			`id-kv ::= "\"id\"" space ":" space uuid`,
+			`mail ::= email-string`,
+			`mail-kv ::= "\"mail\"" space ":" space mail`,
+			`root ::= "{" space day-kv "," space id-kv "," space mail-kv "}" space`,
+			spaceRule,
+			`uuid ::= "\"" [0-9a-fA-F][0-9a-fA-F][0-9a-fA-F][0-9a-fA-F][0-9a-fA-F][0-9a-fA-F][0-9a-fA-F][0-9a-fA-F] "-" [0-9a-fA-F][0-9a-fA-F][0-9a-fA-F][0-9a-fA-F] "-" [0-9a-fA-F][0-9a-fA-F][0-9a-fA-F][0-9a-fA-F] "-" [0-9a-fA-F][0-9a-fA-F][0-9a-fA-F][0-9a-fA-F] "-" [0-9a-fA-F][0-9a-fA-F][0-9a-fA-F][0-9a-fA-F][0-9a-fA-F][0-9a-fA-F][0-9a-fA-F][0-9a-fA-F][0-9a-fA-F][0-9a-fA-F][0-9a-fA-F][0-9a-fA-F] "\"" space`,
+		)),
+		Entry("date-time format", `{"type": "string", "format": "date-time"}`, "", rules(
+			`date ::= [0-9] [0-9] [0-9] [0-9] "-" ( "0" [1-9] | "1" [0-2] ) "-" ( "0" [1-9] | [1-2] [0-9] | "3" [0-1] )`,
+			`date-time ::= date "T" time`,
+			`date-time-string ::= "\"" date-time "\"" space`,
+			`root ::= date-time-string`,
+			spaceRule,
+			`time ::= ([01] [0-9] | "2" [0-3]) ":" [0-5] [0-9] ":" [0-5] [0-9] ( "." [0-9] [0-9] [0-9] )? ( "Z" | ( "+" | "-" ) ( [01] [0-9] | "2" [0-3] ) ":" [0-5] [0-9] )`,
+		)),
+	)
+
+	DescribeTable("converts string patterns",
+		func(pattern, expected string) {
+			grammar, err := JSONSchemaGrammar(map[string]interface{}{"type": "string", "pattern": pattern}, "")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(grammar).To(ContainSubstring("root ::= " + expected + "\n"))
+		},
+		Entry("classes and repetitions", `^[A-Z]{2}-\d+$`, `"\"" [A-Z] [A-Z] "-" [0-9]+ "\"" space`),
+		Entry("bounded repetitions", `^a{1,3}$`, `"\"" "a" ("a" "a"?)? "\"" space`),
+		Entry("alternatives", `^(yes|no)$`, `"\"" ("yes" | "no") "\"" space`),
+		Entry("escaped characters", `^"\\?$`, `"\"" "\\\"" "\\\\"? "\"" space`),
+		Entry("any character", `^a.c$`, `"\"" "a" ([^\x00-\x1F\"\\] | "\\\"" | "\\\\") "c" "\"" space`),
+		Entry("negated class", `^[^,]+$`, `"\"" ([^\x00-\x1F\",\\] | "\\\"" | "\\\\")+ "\"" space`),
+		Entry("case insensitive", `^(?i)ok$`, `"\"" [Oo] [KkK] "\"" space`),
+	)
+
+	It("escapes the literals", func() {
+		grammar, err := JSONSchemaGrammar(map[string]interface{}{"const": `say "hi" \ <b>`}, "")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(grammar).To(ContainSubstring(`root ::= "\"say \\\"hi\\\" \\\\ <b>\""`))
+	})
+
+	It("returns an error for invalid references and patterns", func() {
+		_, err := JSONSchemaGrammar(map[string]interface{}{"$ref": "#/$defs/missing"}, "")
+		Expect(err).To(MatchError(ContainSubstring("definition not found: #/$defs/missing")))
+		_, err = JSONSchemaGrammar(map[string]interface{}{"$ref": "https://example.com/schema.json"}, "")
+		Expect(err).To(MatchError(ContainSubstring("unsupported reference")))
+		_, err = JSONSchemaGrammar(map[string]interface{}{"type": "string", "pattern": `^\bword$`}, "")
+		Expect(err).To(MatchError(ContainSubstring("word boundaries are not supported")))
+	})
+})
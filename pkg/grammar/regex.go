@@ -0,0 +1,258 @@
+package grammar
+
+import (
+	"fmt"
+	"regexp/syntax"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// regexConverter translates a regular expression to a GBNF expression matching the same strings.
+// In a JSON string, the quotes and backslashes matched by the expression are escaped, and the control
+// characters excluded, for the output to stay valid JSON.
+type regexConverter struct {
+	jsonString bool
+}
+
+// RegexGrammar returns the grammar of the outputs the regular expression matches as a whole
+func RegexGrammar(pattern string) (string, error) {
+	expression, err := patternExpression(pattern, false)
+	if err != nil {
+		return "", err
+	}
+	return "root ::= " + expression, nil
+}
+
+// ChoicesGrammar returns the grammar of the outputs that are one of the choices
+func ChoicesGrammar(choices []string) (string, error) {
+	if len(choices) == 0 {
+		return "", fmt.Errorf("no choice given")
+	}
+	alternatives := make([]string, len(choices))
+	for i, choice := range choices {
+		alternatives[i] = escapeLiteral(choice)
+	}
+	return "root ::= " + strings.Join(alternatives, " | "), nil
+}
+
+// patternExpression returns the GBNF expression of a pattern, which has to match the whole string:
+// the anchors of the pattern are ignored
+func patternExpression(pattern string, jsonString bool) (string, error) {
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return "", fmt.Errorf("invalid pattern %q: %w", pattern, err)
+	}
+	expression, err := regexConverter{jsonString: jsonString}.expression(re.Simplify())
+	if err != nil {
+		return "", fmt.Errorf("unsupported pattern %q: %w", pattern, err)
+	}
+	return expression, nil
+}
+
+func (rc regexConverter) expression(re *syntax.Regexp) (string, error) {
+	switch re.Op {
+	case syntax.OpNoMatch:
+		return "", fmt.Errorf("it matches nothing")
+	case syntax.OpEmptyMatch, syntax.OpBeginLine, syntax.OpEndLine, syntax.OpBeginText, syntax.OpEndText:
+		return `""`, nil
+	case syntax.OpWordBoundary, syntax.OpNoWordBoundary:
+		return "", fmt.Errorf("word boundaries are not supported")
+	case syntax.OpLiteral:
+		if re.Flags&syntax.FoldCase == 0 {
+			return rc.literal(string(re.Rune)), nil
+		}
+		parts := []string{}
+		for _, r := range re.Rune {
+			parts = append(parts, rc.charClass(foldRanges(r)))
+		}
+		return strings.Join(parts, " "), nil
+	case syntax.OpCharClass:
+		if len(re.Rune) == 0 {
+			return "", fmt.Errorf("it matches nothing")
+		}
+		return rc.charClass(re.Rune), nil
+	case syntax.OpAnyCharNotNL:
+		return rc.charClass([]rune{0, '\n' - 1, '\n' + 1, unicode.MaxRune}), nil
+	case syntax.OpAnyChar:
+		return rc.charClass([]rune{0, unicode.MaxRune}), nil
+	case syntax.OpCapture:
+		sub, err := rc.expression(re.Sub[0])
+		if err != nil || re.Sub[0].Op == syntax.OpAlternate {
+			return sub, err
+		}
+		return "(" + sub + ")", nil
+	case syntax.OpStar, syntax.OpPlus, syntax.OpQuest:
+		sub, err := rc.expression(re.Sub[0])
+		if err != nil {
+			return "", err
+		}
+		if !isAtom(re.Sub[0]) {
+			sub = "(" + sub + ")"
+		}
+		return sub + map[syntax.Op]string{syntax.OpStar: "*", syntax.OpPlus: "+", syntax.OpQuest: "?"}[re.Op], nil
+	case syntax.OpConcat, syntax.OpAlternate:
+		parts := []string{}
+		for _, s := range re.Sub {
+			sub, err := rc.expression(s)
+			if err != nil {
+				return "", err
+			}
+			if re.Op == syntax.OpConcat && sub == `""` {
+				continue
+			}
+			parts = append(parts, sub)
+		}
+		if re.Op == syntax.OpAlternate {
+			return "(" + strings.Join(parts, " | ") + ")", nil
+		}
+		if len(parts) == 0 {
+			return `""`, nil
+		}
+		return strings.Join(parts, " "), nil
+	}
+	return "", fmt.Errorf("%s is not supported", re)
+}
+
+// isAtom tells if the expression of the regular expression can be repeated without parentheses
+func isAtom(re *syntax.Regexp) bool {
+	switch re.Op {
+	case syntax.OpLiteral:
+		return len(re.Rune) == 1
+	case syntax.OpCharClass, syntax.OpAnyChar, syntax.OpAnyCharNotNL, syntax.OpCapture, syntax.OpAlternate:
+		return true
+	}
+	return false
+}
+
+func (rc regexConverter) literal(s string) string {
+	if rc.jsonString {
+		// the content of the JSON string, without its quotes
+		s = jsonString(s)
+		s = s[1 : len(s)-1]
+	}
+	return escapeLiteral(s)
+}
+
+// charClass returns the GBNF expression of a character class, given as sorted pairs of the bounds of its ranges
+func (rc regexConverter) charClass(ranges []rune) string {
+	var escaped []string
+	if rc.jsonString {
+		for _, c := range []rune{'"', '\\'} {
+			if inRanges(ranges, c) {
+				escaped = append(escaped, rc.literal(string(c)))
+			}
+		}
+		ranges = subtractRange(ranges, 0, 0x1f)
+		ranges = subtractRange(ranges, '"', '"')
+		ranges = subtractRange(ranges, '\\', '\\')
+	}
+
+	var class string
+	if complement := complementRanges(ranges); len(ranges) > 0 && len(complement) > 0 && len(complement) <= len(ranges) {
+		class = "[^" + formatRanges(complement) + "]"
+	} else if len(ranges) > 0 {
+		class = "[" + formatRanges(ranges) + "]"
+	}
+
+	alternatives := escaped
+	if class != "" {
+		alternatives = append([]string{class}, escaped...)
+	}
+	if len(alternatives) == 1 {
+		return alternatives[0]
+	}
+	return "(" + strings.Join(alternatives, " | ") + ")"
+}
+
+// foldRanges returns the ranges of the characters equal to r under case folding
+func foldRanges(r rune) []rune {
+	runes := []rune{r}
+	for f := unicode.SimpleFold(r); f != r; f = unicode.SimpleFold(f) {
+		runes = append(runes, f)
+	}
+	sort.Slice(runes, func(i, j int) bool { return runes[i] < runes[j] })
+	ranges := []rune{}
+	for _, r := range runes {
+		ranges = append(ranges, r, r)
+	}
+	return ranges
+}
+
+func inRanges(ranges []rune, c rune) bool {
+	for i := 0; i < len(ranges); i += 2 {
+		if ranges[i] <= c && c <= ranges[i+1] {
+			return true
+		}
+	}
+	return false
+}
+
+func subtractRange(ranges []rune, lo, hi rune) []rune {
+	result := []rune{}
+	for i := 0; i < len(ranges); i += 2 {
+		rlo, rhi := ranges[i], ranges[i+1]
+		if rhi < lo || rlo > hi {
+			result = append(result, rlo, rhi)
+			continue
+		}
+		if rlo < lo {
+			result = append(result, rlo, lo-1)
+		}
+		if rhi > hi {
+			result = append(result, hi+1, rhi)
+		}
+	}
+	return result
+}
+
+func complementRanges(ranges []rune) []rune {
+	result := []rune{}
+	next := rune(0)
+	for i := 0; i < len(ranges); i += 2 {
+		if ranges[i] > next {
+			result = append(result, next, ranges[i]-1)
+		}
+		next = ranges[i+1] + 1
+	}
+	if next <= unicode.MaxRune {
+		result = append(result, next, unicode.MaxRune)
+	}
+	return result
+}
+
+func formatRanges(ranges []rune) string {
+	var s strings.Builder
+	for i := 0; i < len(ranges); i += 2 {
+		s.WriteString(classChar(ranges[i]))
+		if ranges[i+1] != ranges[i] {
+			s.WriteString("-" + classChar(ranges[i+1]))
+		}
+	}
+	return s.String()
+}
+
+// classChar escapes a character of a GBNF character class
+func classChar(c rune) string {
+	switch c {
+	case '\\', ']', '[', '"':
+		return `\` + string(c)
+	case '-', '^':
+		return fmt.Sprintf(`\x%02X`, c)
+	case '\n':
+		return `\n`
+	case '\r':
+		return `\r`
+	case '\t':
+		return `\t`
+	}
+	switch {
+	case c < 0x20 || c == 0x7f:
+		return fmt.Sprintf(`\x%02X`, c)
+	case c > 0x7f && !unicode.IsPrint(c) && c > 0xffff:
+		return fmt.Sprintf(`\U%08X`, c)
+	case c > 0x7f && !unicode.IsPrint(c):
+		return fmt.Sprintf(`\u%04X`, c)
+	}
+	return string(c)
+}
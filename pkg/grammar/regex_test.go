@@ -0,0 +1,45 @@
+package grammar_test
+
+import (
+	. "github.com/go-skynet/LocalAI/pkg/grammar"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Output constraints", func() {
+	DescribeTable("compiles regular expressions to grammars",
+		func(pattern, expected string) {
+			g, err := RegexGrammar(pattern)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(g).To(Equal(expected))
+			_, err = ParseGrammar(g)
+			Expect(err).ToNot(HaveOccurred())
+		},
+		Entry("literal", `hello`, `root ::= "hello"`),
+		Entry("anchors", `^(positive|negative|neutral)$`, `root ::= ("positive" | "ne" ("gative" | "utral"))`),
+		Entry("classes and repetitions", `\d{3}-[A-Z]+`, `root ::= [0-9] [0-9] [0-9] "-" [A-Z]+`),
+		Entry("any character", `a.*`, `root ::= "a" [^\n]*`),
+		Entry("quotes and backslashes", `"[^"\\]*"`, `root ::= "\"" [^\"\\]* "\""`),
+		Entry("special characters in classes", `[\-^\]]`, `root ::= [\x2D\]-\x5E]`),
+		Entry("groups", `(ab)+c?`, `root ::= ("ab")+ "c"?`),
+		Entry("empty alternative", `a(b|)`, `root ::= "a" ("b" | "")`),
+	)
+
+	It("returns an error for invalid regular expressions", func() {
+		_, err := RegexGrammar(`(a`)
+		Expect(err).To(MatchError(ContainSubstring("invalid pattern")))
+		_, err = RegexGrammar(`\bword`)
+		Expect(err).To(MatchError(ContainSubstring("word boundaries are not supported")))
+	})
+
+	It("compiles choices to grammars", func() {
+		g, err := ChoicesGrammar([]string{"yes", "no", `say "maybe"`})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(g).To(Equal(`root ::= "yes" | "no" | "say \"maybe\""`))
+		_, err = ParseGrammar(g)
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = ChoicesGrammar(nil)
+		Expect(err).To(MatchError("no choice given"))
+	})
+})
@@ -0,0 +1,340 @@
+package grammar
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// ValidationError is a JSON value not matching its schema
+type ValidationError struct {
+	// Path locates the value in the document, as in $.items[0].name
+	Path    string
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Path, e.Message)
+}
+
+// Validate checks that the JSON document matches the schema.
+// The grammar of a schema constrains the output of a model, but not all the backends enforce grammars.
+func Validate(schema map[string]interface{}, document []byte) error {
+	d := json.NewDecoder(bytes.NewReader(document))
+	d.UseNumber()
+	var v interface{}
+	if err := d.Decode(&v); err != nil {
+		return &ValidationError{Path: "$", Message: fmt.Sprintf("invalid JSON: %v", err)}
+	}
+	if _, err := d.Token(); !errors.Is(err, io.EOF) {
+		return &ValidationError{Path: "$", Message: "invalid JSON: unexpected data after the value"}
+	}
+	return ValidateValue(schema, v)
+}
+
+// ValidateValue checks that a value decoded from JSON matches the schema
+func ValidateValue(schema map[string]interface{}, v interface{}) error {
+	return validate(schema, schema, v, "$", nil)
+}
+
+// validate checks the value against the schema. refs are the references being resolved for this value: the schemas
+// referring back to one of them never constrain the value, and would recurse forever.
+func validate(schema, rootSchema map[string]interface{}, v interface{}, path string, refs []string) error {
+	invalid := func(format string, a ...interface{}) error {
+		return &ValidationError{Path: path, Message: fmt.Sprintf(format, a...)}
+	}
+
+	if ref, ok := schema["$ref"].(string); ok {
+		for _, r := range refs {
+			if r == ref {
+				return invalid("circular reference %s", strings.Join(append(refs, ref), " -> "))
+			}
+		}
+		def, err := resolvePointer(ref, rootSchema)
+		if err != nil {
+			return invalid("%v", err)
+		}
+		return validate(def, rootSchema, v, path, append(refs, ref))
+	}
+
+	if all, ok := schema["allOf"].([]interface{}); ok {
+		for _, s := range all {
+			if err := validateSub(s, rootSchema, v, path, refs); err != nil {
+				return err
+			}
+		}
+	}
+	if alternatives, ok := schema["anyOf"].([]interface{}); ok {
+		if matches, firstErr := countMatches(alternatives, rootSchema, v, path, refs); matches == 0 {
+			return firstErr
+		}
+	}
+	if alternatives, ok := schema["oneOf"].([]interface{}); ok {
+		matches, firstErr := countMatches(alternatives, rootSchema, v, path, refs)
+		if matches == 0 {
+			return firstErr
+		}
+		if matches > 1 {
+			return invalid("matches %d of the oneOf schemas instead of one", matches)
+		}
+	}
+
+	if c, ok := schema["const"]; ok && !jsonEqual(c, v) {
+		return invalid("expected %s", jsonString(c))
+	}
+	if enum, ok := schema["enum"].([]interface{}); ok {
+		found := false
+		for _, e := range enum {
+			if jsonEqual(e, v) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			values := []string{}
+			for _, e := range enum {
+				values = append(values, jsonString(e))
+			}
+			return invalid("expected one of %s", strings.Join(values, ", "))
+		}
+	}
+
+	if t, ok := schema["type"]; ok {
+		types := []string{}
+		switch t := t.(type) {
+		case string:
+			types = append(types, t)
+		case []interface{}:
+			for _, tt := range t {
+				if s, ok := tt.(string); ok {
+					types = append(types, s)
+				}
+			}
+		}
+		matches := false
+		for _, t := range types {
+			if hasType(v, t) {
+				matches = true
+				break
+			}
+		}
+		if !matches {
+			return invalid("expected %s, got %s", strings.Join(types, " or "), jsonType(v))
+		}
+	}
+
+	switch v := v.(type) {
+	case map[string]interface{}:
+		return validateObject(schema, rootSchema, v, path)
+	case []interface{}:
+		if min, ok := schemaNumber(schema, "minItems"); ok && float64(len(v)) < min {
+			return invalid("expected at least %v items, got %d", min, len(v))
+		}
+		if max, ok := schemaNumber(schema, "maxItems"); ok && float64(len(v)) > max {
+			return invalid("expected at most %v items, got %d", max, len(v))
+		}
+		if items, ok := schema["items"]; ok {
+			for i, item := range v {
+				if err := validateSub(items, rootSchema, item, fmt.Sprintf("%s[%d]", path, i), nil); err != nil {
+					return err
+				}
+			}
+		}
+	case string:
+		length := utf8.RuneCountInString(v)
+		if min, ok := schemaNumber(schema, "minLength"); ok && float64(length) < min {
+			return invalid("expected at least %v characters, got %d", min, length)
+		}
+		if max, ok := schemaNumber(schema, "maxLength"); ok && float64(length) > max {
+			return invalid("expected at most %v characters, got %d", max, length)
+		}
+		if pattern, ok := schema["pattern"].(string); ok {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return invalid("invalid pattern %q: %v", pattern, err)
+			}
+			if !re.MatchString(v) {
+				return invalid("%q does not match the pattern %q", v, pattern)
+			}
+		}
+	default:
+		if n, ok := toNumber(v); ok {
+			if min, ok := schemaNumber(schema, "minimum"); ok && n < min {
+				return invalid("expected a number greater than or equal to %v, got %v", min, n)
+			}
+			if max, ok := schemaNumber(schema, "maximum"); ok && n > max {
+				return invalid("expected a number less than or equal to %v, got %v", max, n)
+			}
+			if min, ok := schemaNumber(schema, "exclusiveMinimum"); ok && n <= min {
+				return invalid("expected a number greater than %v, got %v", min, n)
+			}
+			if max, ok := schemaNumber(schema, "exclusiveMaximum"); ok && n >= max {
+				return invalid("expected a number less than %v, got %v", max, n)
+			}
+		}
+	}
+	return nil
+}
+
+func validateObject(schema, rootSchema map[string]interface{}, v map[string]interface{}, path string) error {
+	properties, _ := schema["properties"].(map[string]interface{})
+	if required, ok := schema["required"].([]interface{}); ok {
+		for _, r := range required {
+			if name, ok := r.(string); ok {
+				if _, exists := v[name]; !exists {
+					return &ValidationError{Path: path, Message: fmt.Sprintf("missing required property %q", name)}
+				}
+			}
+		}
+	}
+
+	// the properties are checked in order, for the error reported to be always the same
+	names := make([]string, 0, len(v))
+	for name := range v {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		propPath := path + "." + name
+		if propSchema, ok := properties[name]; ok {
+			if err := validateSub(propSchema, rootSchema, v[name], propPath, nil); err != nil {
+				return err
+			}
+			continue
+		}
+		switch additional := schema["additionalProperties"].(type) {
+		case bool:
+			if !additional {
+				return &ValidationError{Path: path, Message: fmt.Sprintf("unexpected property %q", name)}
+			}
+		case map[string]interface{}:
+			if err := validate(additional, rootSchema, v[name], propPath, nil); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// validateSub validates the value against a schema nested in another, which can also be a boolean
+func validateSub(schema interface{}, rootSchema map[string]interface{}, v interface{}, path string, refs []string) error {
+	switch s := schema.(type) {
+	case map[string]interface{}:
+		return validate(s, rootSchema, v, path, refs)
+	case bool:
+		if !s {
+			return &ValidationError{Path: path, Message: "no value is allowed"}
+		}
+	}
+	return nil
+}
+
+// countMatches returns how many of the schemas the value matches, and the error of the first it does not match
+func countMatches(schemas []interface{}, rootSchema map[string]interface{}, v interface{}, path string, refs []string) (int, error) {
+	matches := 0
+	var firstErr error
+	for _, s := range schemas {
+		if err := validateSub(s, rootSchema, v, path, refs); err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		matches++
+	}
+	return matches, firstErr
+}
+
+func hasType(v interface{}, t string) bool {
+	switch t {
+	case "integer":
+		n, ok := toNumber(v)
+		return ok && n == math.Trunc(n)
+	case "number":
+		_, ok := toNumber(v)
+		return ok
+	}
+	return jsonType(v) == t
+}
+
+func jsonType(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	}
+	if _, ok := toNumber(v); ok {
+		return "number"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+func toNumber(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	case int:
+		return float64(n), true
+	}
+	return 0, false
+}
+
+func schemaNumber(schema map[string]interface{}, key string) (float64, bool) {
+	v, ok := schema[key]
+	if !ok {
+		return 0, false
+	}
+	return toNumber(v)
+}
+
+// jsonEqual compares JSON values, whatever the type their numbers were decoded to
+func jsonEqual(a, b interface{}) bool {
+	if an, ok := toNumber(a); ok {
+		bn, ok := toNumber(b)
+		return ok && an == bn
+	}
+	switch a := a.(type) {
+	case map[string]interface{}:
+		b, ok := b.(map[string]interface{})
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for k, av := range a {
+			bv, ok := b[k]
+			if !ok || !jsonEqual(av, bv) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		b, ok := b.([]interface{})
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for i := range a {
+			if !jsonEqual(a[i], b[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	return reflect.DeepEqual(a, b)
+}
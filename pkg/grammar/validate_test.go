@@ -0,0 +1,84 @@
+package grammar_test
+
+import (
+	"encoding/json"
+
+	. "github.com/go-skynet/LocalAI/pkg/grammar"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+const validationSchema = `{
+	"type": "object",
+	"properties": {
+		"name": {"type": "string", "minLength": 2, "pattern": "^[A-Z]"},
+		"age": {"type": "integer", "minimum": 0},
+		"unit": {"enum": ["C", "F"]},
+		"tags": {"type": "array", "items": {"type": "string"}, "maxItems": 2},
+		"address": {"$ref": "#/$defs/address"},
+		"id": {"oneOf": [{"type": "string"}, {"type": "integer"}]}
+	},
+	"required": ["name"],
+	"additionalProperties": false,
+	"$defs": {
+		"address": {"type": "object", "properties": {"city": {"type": "string"}}, "required": ["city"]}
+	}
+}`
+
+var _ = Describe("JSON schema validation", func() {
+	var schema map[string]interface{}
+
+	BeforeEach(func() {
+		Expect(json.Unmarshal([]byte(validationSchema), &schema)).To(Succeed())
+	})
+
+	DescribeTable("Validate()",
+		func(document, expectedError string) {
+			err := Validate(schema, []byte(document))
+			if expectedError == "" {
+				Expect(err).ToNot(HaveOccurred())
+				return
+			}
+			Expect(err).To(MatchError(expectedError))
+		},
+		Entry("valid document", `{"name": "Ada", "age": 36, "unit": "C", "tags": ["a"], "address": {"city": "London"}, "id": 3}`, ""),
+		Entry("invalid JSON", `{"name": "Ada"`, "$: invalid JSON: unexpected EOF"),
+		Entry("trailing data", `{"name": "Ada"} {}`, "$: invalid JSON: unexpected data after the value"),
+		Entry("wrong type", `[]`, "$: expected object, got array"),
+		Entry("missing required property", `{"age": 3}`, `$: missing required property "name"`),
+		Entry("additional property", `{"name": "Ada", "extra": 1}`, `$: unexpected property "extra"`),
+		Entry("string too short", `{"name": "A"}`, "$.name: expected at least 2 characters, got 1"),
+		Entry("pattern", `{"name": "ada"}`, `$.name: "ada" does not match the pattern "^[A-Z]"`),
+		Entry("integer", `{"name": "Ada", "age": 3.5}`, "$.age: expected integer, got number"),
+		Entry("minimum", `{"name": "Ada", "age": -1}`, "$.age: expected a number greater than or equal to 0, got -1"),
+		Entry("enum", `{"name": "Ada", "unit": "K"}`, `$.unit: expected one of "C", "F"`),
+		Entry("array items", `{"name": "Ada", "tags": ["a", 1]}`, "$.tags[1]: expected string, got number"),
+		Entry("too many items", `{"name": "Ada", "tags": ["a", "b", "c"]}`, "$.tags: expected at most 2 items, got 3"),
+		Entry("reference", `{"name": "Ada", "address": {}}`, `$.address: missing required property "city"`),
+		Entry("oneOf", `{"name": "Ada", "id": true}`, "$.id: expected string, got boolean"),
+	)
+
+	It("validates decoded values", func() {
+		Expect(ValidateValue(schema, map[string]interface{}{"name": "Ada", "age": float64(3)})).To(Succeed())
+		Expect(ValidateValue(map[string]interface{}{"const": 1}, float64(2))).To(MatchError("$: expected 1"))
+	})
+
+	It("reports the circular references", func() {
+		cycle := map[string]interface{}{}
+		Expect(json.Unmarshal([]byte(`{"type": "object", "properties": {"x": {"$ref": "#/properties/x"}}}`), &cycle)).To(Succeed())
+		err := Validate(cycle, []byte(`{"x": 1}`))
+		Expect(err).To(MatchError("$.x: circular reference #/properties/x -> #/properties/x"))
+		var validationErr *ValidationError
+		Expect(err).To(BeAssignableToTypeOf(validationErr))
+
+		Expect(json.Unmarshal([]byte(`{"$ref": "#/$defs/a", "$defs": {"a": {"anyOf": [{"$ref": "#/$defs/b"}, {"type": "string"}]}, "b": {"allOf": [{"$ref": "#/$defs/a"}]}}}`), &cycle)).To(Succeed())
+		Expect(Validate(cycle, []byte(`1`))).To(MatchError("$: circular reference #/$defs/a -> #/$defs/b -> #/$defs/a"))
+	})
+
+	It("validates the recursive schemas", func() {
+		tree := map[string]interface{}{}
+		Expect(json.Unmarshal([]byte(`{"$ref": "#/$defs/node", "$defs": {"node": {"type": "object", "properties": {"children": {"type": "array", "items": {"$ref": "#/$defs/node"}}}}}}`), &tree)).To(Succeed())
+		Expect(Validate(tree, []byte(`{"children": [{"children": []}, {"children": [{}]}]}`))).To(Succeed())
+		Expect(Validate(tree, []byte(`{"children": [{"children": [1]}]}`))).To(MatchError("$.children[0].children[0]: expected object, got number"))
+	})
+})
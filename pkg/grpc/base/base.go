@@ -3,12 +3,18 @@ package base
 // This is a wrapper to statisfy the GRPC service interface
 // It is meant to be used by the main executable that is the server for the specific backend type (falcon, gpt3, etc)
 import (
+	"context"
+	"errors"
 	"fmt"
 
 	pb "github.com/go-skynet/LocalAI/pkg/grpc/proto"
 	"github.com/go-skynet/LocalAI/pkg/grpc/whisper/api"
 )
 
+// ErrNoContext is returned by the context-aware methods of Base, to tell the server
+// that the backend does not override them and the methods without a context must be used instead
+var ErrNoContext = errors.New("context not supported by the backend")
+
 type Base struct {
 }
 
@@ -25,6 +31,18 @@ func (llm *Base) PredictStream(opts *pb.PredictOptions, results chan string) err
 	return fmt.Errorf("unimplemented")
 }
 
+// PredictContext is Predict with a context that is canceled when the client goes away.
+// Backends that can stop generating early should override it.
+func (llm *Base) PredictContext(ctx context.Context, opts *pb.PredictOptions) (string, error) {
+	return "", ErrNoContext
+}
+
+// PredictStreamContext is PredictStream with a context that is canceled when the client goes away.
+// Backends that can stop generating early should override it.
+func (llm *Base) PredictStreamContext(ctx context.Context, opts *pb.PredictOptions, results chan string) error {
+	return ErrNoContext
+}
+
 func (llm *Base) Embeddings(opts *pb.PredictOptions) ([]float32, error) {
 	return []float32{}, fmt.Errorf("unimplemented")
 }
@@ -40,3 +58,17 @@ func (llm *Base) AudioTranscription(*pb.TranscriptRequest) (api.Result, error) {
 func (llm *Base) TTS(*pb.TTSRequest) error {
 	return fmt.Errorf("unimplemented")
 }
+
+// Capabilities reports every operation as available, as Base cannot know which ones
+// the backend embedding it implements. Backends should override it to let LocalAI
+// skip them for requests they cannot serve.
+func (llm *Base) Capabilities() *pb.CapabilitiesResult {
+	return &pb.CapabilitiesResult{
+		Predict:            true,
+		PredictStream:      true,
+		Embeddings:         true,
+		GenerateImage:      true,
+		TTS:                true,
+		AudioTranscription: true,
+	}
+}
@@ -0,0 +1,105 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	pb "github.com/go-skynet/LocalAI/pkg/grpc/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Capability is an operation a backend can implement
+type Capability string
+
+const (
+	CapabilityPredict            Capability = "predict"
+	CapabilityPredictStream      Capability = "stream"
+	CapabilityEmbeddings         Capability = "embeddings"
+	CapabilityGenerateImage      Capability = "image"
+	CapabilityTTS                Capability = "tts"
+	CapabilityAudioTranscription Capability = "transcription"
+	CapabilityTokenize           Capability = "tokenize"
+	// CapabilityGrammar is not an operation: the backend makes the predictions follow the grammar of the request
+	CapabilityGrammar Capability = "grammar"
+)
+
+// ErrNotSupported is returned when a backend does not implement the requested operation
+var ErrNotSupported = errors.New("operation not supported by the backend")
+
+// NotSupportedError returns an error wrapping ErrNotSupported for the given capability
+func NotSupportedError(backend string, c Capability) error {
+	if backend == "" {
+		return fmt.Errorf("%w: %s", ErrNotSupported, c)
+	}
+	return fmt.Errorf("%w: backend %s does not support %s", ErrNotSupported, backend, c)
+}
+
+// Has returns whether the capability is part of the ones reported by the backend
+func Has(caps *pb.CapabilitiesResult, c Capability) bool {
+	switch c {
+	case CapabilityPredict:
+		return caps.GetPredict()
+	case CapabilityPredictStream:
+		return caps.GetPredictStream()
+	case CapabilityEmbeddings:
+		return caps.GetEmbeddings()
+	case CapabilityGenerateImage:
+		return caps.GetGenerateImage()
+	case CapabilityTTS:
+		return caps.GetTTS()
+	case CapabilityAudioTranscription:
+		return caps.GetAudioTranscription()
+	case CapabilityTokenize:
+		return caps.GetTokenize()
+	case CapabilityGrammar:
+		return caps.GetGrammar()
+	}
+	return false
+}
+
+// Capabilities returns the operations implemented by the backend. The answer is cached for the lifetime of the client.
+// Backends that predate the Capabilities call (or don't implement it) return nil capabilities and no error.
+func (c *Client) Capabilities(ctx context.Context) (*pb.CapabilitiesResult, error) {
+	c.mu.Lock()
+	if c.capabilitiesKnown {
+		defer c.mu.Unlock()
+		return c.capabilities, nil
+	}
+	c.mu.Unlock()
+
+	conn, err := c.connection()
+	if err != nil {
+		return nil, err
+	}
+	client := pb.NewBackendClient(conn)
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	caps, err := client.Capabilities(ctx, &pb.HealthMessage{})
+	if status.Code(err) == codes.Unimplemented {
+		caps, err = nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.capabilities, c.capabilitiesKnown = caps, true
+	return caps, nil
+}
+
+// Supports returns whether the backend implements the operation. Backends that cannot
+// report their capabilities are assumed to support everything, leaving them to answer
+// with an error to the requests they cannot serve.
+func (c *Client) Supports(ctx context.Context, capability Capability) bool {
+	caps, err := c.Capabilities(ctx)
+	if err != nil || caps == nil {
+		return true
+	}
+	return Has(caps, capability)
+}
@@ -0,0 +1,97 @@
+package grpc_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/go-skynet/LocalAI/pkg/grpc"
+	"github.com/go-skynet/LocalAI/pkg/grpc/base"
+	pb "github.com/go-skynet/LocalAI/pkg/grpc/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type embeddingsOnly struct {
+	base.Base
+}
+
+func (e *embeddingsOnly) Capabilities() *pb.CapabilitiesResult {
+	return &pb.CapabilitiesResult{Embeddings: true}
+}
+
+func (e *embeddingsOnly) Embeddings(opts *pb.PredictOptions) ([]float32, error) {
+	return []float32{1}, nil
+}
+
+func startTestServer(t *testing.T, llm LLM) *Client {
+	dir, err := os.MkdirTemp("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	address := "unix://" + filepath.Join(dir, "grpc.sock")
+	go StartServer(address, llm)
+
+	client := NewClient(address)
+	t.Cleanup(func() { client.Close() })
+	for i := 0; i < 50; i++ {
+		if client.HealthCheck(context.Background()) {
+			return client
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	t.Fatal("server did not start")
+	return nil
+}
+
+func TestCapabilities(t *testing.T) {
+	client := startTestServer(t, &embeddingsOnly{})
+
+	if !client.Supports(context.Background(), CapabilityEmbeddings) {
+		t.Error("expected embeddings to be supported")
+	}
+	for _, c := range []Capability{CapabilityPredict, CapabilityPredictStream, CapabilityGenerateImage, CapabilityTTS, CapabilityAudioTranscription, CapabilityTokenize, CapabilityGrammar} {
+		if client.Supports(context.Background(), c) {
+			t.Errorf("expected %s not to be supported", c)
+		}
+	}
+
+	// unsupported calls are refused before reaching the backend
+	_, err := client.Predict(context.Background(), &pb.PredictOptions{})
+	if status.Code(err) != codes.Unimplemented {
+		t.Errorf("expected Unimplemented error, got %v", err)
+	}
+	err = client.PredictStream(context.Background(), &pb.PredictOptions{}, func(s []byte) bool { return true })
+	if status.Code(err) != codes.Unimplemented {
+		t.Errorf("expected Unimplemented error, got %v", err)
+	}
+
+	res, err := client.Embeddings(context.Background(), &pb.PredictOptions{})
+	if err != nil || len(res.Embeddings) != 1 {
+		t.Errorf("unexpected embeddings result: %v, %v", res, err)
+	}
+}
+
+func TestBaseCapabilities(t *testing.T) {
+	client := startTestServer(t, &base.Base{})
+
+	caps, err := client.Capabilities(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !caps.Predict || !caps.Embeddings || caps.Tokenize || caps.Grammar {
+		t.Errorf("unexpected default capabilities: %v", caps)
+	}
+}
+
+func TestNotSupportedError(t *testing.T) {
+	err := NotSupportedError("whisper", CapabilityPredict)
+	if !errors.Is(err, ErrNotSupported) {
+		t.Errorf("expected %v to wrap ErrNotSupported", err)
+	}
+}
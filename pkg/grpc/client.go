@@ -4,16 +4,31 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"sync"
 	"time"
 
 	pb "github.com/go-skynet/LocalAI/pkg/grpc/proto"
 	"github.com/go-skynet/LocalAI/pkg/grpc/whisper/api"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/backoff"
+	"google.golang.org/grpc/connectivity"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/keepalive"
 )
 
+// Client is a client to a backend gRPC service.
+// It keeps a single connection to the backend, which is created on first use
+// and re-used (and transparently re-connected by gRPC) until Close is called.
 type Client struct {
 	address string
+	options ClientOptions
+
+	// mu guards the connection and the cached capabilities
+	mu   sync.Mutex
+	conn *grpc.ClientConn
+
+	capabilities      *pb.CapabilitiesResult
+	capabilitiesKnown bool
 }
 
 func NewClient(address string) *Client {
@@ -22,13 +37,83 @@ func NewClient(address string) *Client {
 	}
 }
 
+// NewClientWithOptions returns a client that authenticates to the backend as described by the options
+func NewClientWithOptions(address string, options ClientOptions) *Client {
+	return &Client{
+		address: address,
+		options: options,
+	}
+}
+
+var insecureCredentials = insecure.NewCredentials()
+
+// dialOptions are the options used to connect to all the backends.
+var dialOptions = []grpc.DialOption{
+	// Keep pings rare: servers with default settings (like the python backends)
+	// drop connections that ping more often than every 5 minutes.
+	grpc.WithKeepaliveParams(keepalive.ClientParameters{
+		Time:    5 * time.Minute,
+		Timeout: 20 * time.Second,
+	}),
+	// Backends are local processes (or close to it) that might take a while to start up:
+	// retry often rather than backing off up to the default of 2 minutes.
+	grpc.WithConnectParams(grpc.ConnectParams{
+		Backoff: backoff.Config{
+			BaseDelay:  100 * time.Millisecond,
+			Multiplier: 1.6,
+			Jitter:     0.2,
+			MaxDelay:   2 * time.Second,
+		},
+		MinConnectTimeout: 5 * time.Second,
+	}),
+}
+
+// connection returns the connection to the backend, creating it if needed
+func (c *Client) connection() (*grpc.ClientConn, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.conn != nil && c.conn.GetState() != connectivity.Shutdown {
+		return c.conn, nil
+	}
+
+	opts, err := c.options.dialOptions()
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := grpc.Dial(c.address, append(opts, dialOptions...)...)
+	if err != nil {
+		return nil, err
+	}
+	c.conn = conn
+	return conn, nil
+}
+
+// Close closes the connection to the backend. The client can still be used afterwards, in which case a new connection is created.
+func (c *Client) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.conn == nil {
+		return nil
+	}
+	err := c.conn.Close()
+	c.conn = nil
+	return err
+}
+
+// Address returns the address of the backend the client connects to
+func (c *Client) Address() string {
+	return c.address
+}
+
 func (c *Client) HealthCheck(ctx context.Context) bool {
-	conn, err := grpc.Dial(c.address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	conn, err := c.connection()
 	if err != nil {
 		fmt.Println(err)
 		return false
 	}
-	defer conn.Close()
 	client := pb.NewBackendClient(conn)
 
 	// The healthcheck call shouldn't take long time
@@ -49,45 +134,45 @@ func (c *Client) HealthCheck(ctx context.Context) bool {
 }
 
 func (c *Client) Embeddings(ctx context.Context, in *pb.PredictOptions, opts ...grpc.CallOption) (*pb.EmbeddingResult, error) {
-	conn, err := grpc.Dial(c.address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	conn, err := c.connection()
 	if err != nil {
 		return nil, err
 	}
-	defer conn.Close()
 	client := pb.NewBackendClient(conn)
 
 	return client.Embedding(ctx, in, opts...)
 }
 
 func (c *Client) Predict(ctx context.Context, in *pb.PredictOptions, opts ...grpc.CallOption) (*pb.Reply, error) {
-	conn, err := grpc.Dial(c.address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	conn, err := c.connection()
 	if err != nil {
 		return nil, err
 	}
-	defer conn.Close()
 	client := pb.NewBackendClient(conn)
 
 	return client.Predict(ctx, in, opts...)
 }
 
 func (c *Client) LoadModel(ctx context.Context, in *pb.ModelOptions, opts ...grpc.CallOption) (*pb.Result, error) {
-	conn, err := grpc.Dial(c.address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	conn, err := c.connection()
 	if err != nil {
 		return nil, err
 	}
-	defer conn.Close()
 	client := pb.NewBackendClient(conn)
 	return client.LoadModel(ctx, in, opts...)
 }
 
-func (c *Client) PredictStream(ctx context.Context, in *pb.PredictOptions, f func(s []byte), opts ...grpc.CallOption) error {
-	conn, err := grpc.Dial(c.address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+// PredictStream calls f with the tokens as they are generated, until f returns false or the context is done.
+// Leaving the stream early cancels it, for the backend to stop generating.
+func (c *Client) PredictStream(ctx context.Context, in *pb.PredictOptions, f func(s []byte) bool, opts ...grpc.CallOption) error {
+	conn, err := c.connection()
 	if err != nil {
 		return err
 	}
-	defer conn.Close()
 	client := pb.NewBackendClient(conn)
 
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
 	stream, err := client.PredictStream(ctx, in, opts...)
 	if err != nil {
 		return err
@@ -103,38 +188,37 @@ func (c *Client) PredictStream(ctx context.Context, in *pb.PredictOptions, f fun
 
 			return err
 		}
-		f(feature.GetMessage())
+		if !f(feature.GetMessage()) {
+			break
+		}
 	}
 
 	return nil
 }
 
 func (c *Client) GenerateImage(ctx context.Context, in *pb.GenerateImageRequest, opts ...grpc.CallOption) (*pb.Result, error) {
-	conn, err := grpc.Dial(c.address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	conn, err := c.connection()
 	if err != nil {
 		return nil, err
 	}
-	defer conn.Close()
 	client := pb.NewBackendClient(conn)
 	return client.GenerateImage(ctx, in, opts...)
 }
 
 func (c *Client) TTS(ctx context.Context, in *pb.TTSRequest, opts ...grpc.CallOption) (*pb.Result, error) {
-	conn, err := grpc.Dial(c.address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	conn, err := c.connection()
 	if err != nil {
 		return nil, err
 	}
-	defer conn.Close()
 	client := pb.NewBackendClient(conn)
 	return client.TTS(ctx, in, opts...)
 }
 
 func (c *Client) AudioTranscription(ctx context.Context, in *pb.TranscriptRequest, opts ...grpc.CallOption) (*api.Result, error) {
-	conn, err := grpc.Dial(c.address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	conn, err := c.connection()
 	if err != nil {
 		return nil, err
 	}
-	defer conn.Close()
 	client := pb.NewBackendClient(conn)
 	res, err := client.AudioTranscription(ctx, in, opts...)
 	if err != nil {
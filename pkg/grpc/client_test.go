@@ -0,0 +1,75 @@
+package grpc_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/go-skynet/LocalAI/pkg/grpc"
+	"github.com/go-skynet/LocalAI/pkg/grpc/base"
+	pb "github.com/go-skynet/LocalAI/pkg/grpc/proto"
+	"github.com/phayes/freeport"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+var (
+	serverOnce    sync.Once
+	serverAddress string
+)
+
+// testServer starts (once) a backend server to benchmark the client against
+func testServer(b *testing.B) string {
+	serverOnce.Do(func() {
+		port, err := freeport.GetFreePort()
+		if err != nil {
+			b.Fatal(err)
+		}
+		serverAddress = fmt.Sprintf("127.0.0.1:%d", port)
+		go StartServer(serverAddress, &base.Base{})
+
+		client := NewClient(serverAddress)
+		defer client.Close()
+		for i := 0; i < 50; i++ {
+			if client.HealthCheck(context.Background()) {
+				return
+			}
+			time.Sleep(100 * time.Millisecond)
+		}
+		b.Fatal("test server did not start")
+	})
+	return serverAddress
+}
+
+// BenchmarkHealthCheck measures a request over the persistent connection kept by the client
+func BenchmarkHealthCheck(b *testing.B) {
+	client := NewClient(testServer(b))
+	defer client.Close()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if !client.HealthCheck(context.Background()) {
+			b.Fatal("health check failed")
+		}
+	}
+}
+
+// BenchmarkHealthCheckDialPerRequest measures the same request when a new connection is created for each call
+func BenchmarkHealthCheckDialPerRequest(b *testing.B) {
+	address := testServer(b)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		conn, err := grpc.Dial(address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			b.Fatal(err)
+		}
+		res, err := pb.NewBackendClient(conn).Health(context.Background(), &pb.HealthMessage{})
+		if err != nil || string(res.Message) != "OK" {
+			b.Fatal("health check failed", err)
+		}
+		conn.Close()
+	}
+}
@@ -0,0 +1,207 @@
+package grpc
+
+import (
+	"context"
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Environment variables read by StartServer to secure the backends
+const (
+	// ServerTLSCertEnv and ServerTLSKeyEnv are the certificate and key the backend serves TLS with
+	ServerTLSCertEnv = "LOCALAI_BACKEND_TLS_CERT"
+	ServerTLSKeyEnv  = "LOCALAI_BACKEND_TLS_KEY"
+	// ServerTLSClientCAEnv is the CA used to verify client certificates. When set, clients must present a valid certificate (mTLS)
+	ServerTLSClientCAEnv = "LOCALAI_BACKEND_TLS_CLIENT_CA"
+	// ServerTokenEnv is the bearer token clients must send
+	ServerTokenEnv = "LOCALAI_BACKEND_TOKEN"
+)
+
+// ClientOptions describe how a client authenticates to a backend
+type ClientOptions struct {
+	// TLS enables TLS. If CACert is empty, the system roots are used to verify the server
+	TLS        bool
+	CACert     string
+	ClientCert string
+	ClientKey  string
+	ServerName string
+
+	// Token is sent as bearer token in the metadata of each request
+	Token string
+}
+
+// ParseURI parses the URI of an external backend: an address optionally followed by
+// a query string setting the connection options, for example:
+//
+//	host:port?ca=/path/to/ca.pem&cert=/path/to/cert.pem&key=/path/to/key.pem&token_file=/path/to/token
+//
+// Supported parameters are tls (true/false), ca, cert, key, server_name, token and token_file.
+// Setting any of the TLS parameters enables TLS.
+func ParseURI(uri string) (string, ClientOptions, error) {
+	opts := ClientOptions{}
+	address, query, found := strings.Cut(uri, "?")
+	if !found {
+		return address, opts, nil
+	}
+
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		return "", opts, fmt.Errorf("invalid backend options %q: %w", query, err)
+	}
+
+	if v := values.Get("tls"); v != "" {
+		opts.TLS, err = strconv.ParseBool(v)
+		if err != nil {
+			return "", opts, fmt.Errorf("invalid tls option %q: %w", v, err)
+		}
+	}
+	opts.CACert = values.Get("ca")
+	opts.ClientCert = values.Get("cert")
+	opts.ClientKey = values.Get("key")
+	opts.ServerName = values.Get("server_name")
+	if opts.CACert != "" || opts.ClientCert != "" || opts.ServerName != "" {
+		opts.TLS = true
+	}
+	if (opts.ClientCert == "") != (opts.ClientKey == "") {
+		return "", opts, fmt.Errorf("both cert and key must be set to use a client certificate")
+	}
+
+	opts.Token = values.Get("token")
+	if f := values.Get("token_file"); f != "" {
+		dat, err := os.ReadFile(f)
+		if err != nil {
+			return "", opts, fmt.Errorf("cannot read token file: %w", err)
+		}
+		opts.Token = strings.TrimSpace(string(dat))
+	}
+
+	return address, opts, nil
+}
+
+// dialOptions returns the gRPC options to set up the transport and the per-request credentials
+func (o ClientOptions) dialOptions() ([]grpc.DialOption, error) {
+	opts := []grpc.DialOption{}
+
+	if o.TLS {
+		tlsConfig := &tls.Config{ServerName: o.ServerName}
+		if o.CACert != "" {
+			pool, err := certPool(o.CACert)
+			if err != nil {
+				return nil, err
+			}
+			tlsConfig.RootCAs = pool
+		}
+		if o.ClientCert != "" {
+			cert, err := tls.LoadX509KeyPair(o.ClientCert, o.ClientKey)
+			if err != nil {
+				return nil, fmt.Errorf("cannot load client certificate: %w", err)
+			}
+			tlsConfig.Certificates = []tls.Certificate{cert}
+		}
+		opts = append(opts, grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)))
+	} else {
+		opts = append(opts, grpc.WithTransportCredentials(insecureCredentials))
+	}
+
+	if o.Token != "" {
+		opts = append(opts, grpc.WithPerRPCCredentials(tokenCredentials{token: o.Token, secure: o.TLS}))
+	}
+
+	return opts, nil
+}
+
+// tokenCredentials sends a bearer token along each request
+type tokenCredentials struct {
+	token  string
+	secure bool
+}
+
+func (t tokenCredentials) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return map[string]string{"authorization": "Bearer " + t.token}, nil
+}
+
+func (t tokenCredentials) RequireTransportSecurity() bool {
+	return t.secure
+}
+
+func certPool(file string) (*x509.CertPool, error) {
+	dat, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read CA certificate: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(dat) {
+		return nil, fmt.Errorf("no valid certificate found in %s", file)
+	}
+	return pool, nil
+}
+
+// serverOptionsFromEnv returns the gRPC server options to enforce TLS and token authentication,
+// as configured by the LOCALAI_BACKEND_* environment variables
+func serverOptionsFromEnv() ([]grpc.ServerOption, error) {
+	opts := []grpc.ServerOption{}
+
+	certFile, keyFile, clientCA := os.Getenv(ServerTLSCertEnv), os.Getenv(ServerTLSKeyEnv), os.Getenv(ServerTLSClientCAEnv)
+	if certFile != "" || keyFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("cannot load server certificate: %w", err)
+		}
+		tlsConfig := &tls.Config{Certificates: []tls.Certificate{cert}}
+		if clientCA != "" {
+			pool, err := certPool(clientCA)
+			if err != nil {
+				return nil, err
+			}
+			tlsConfig.ClientCAs = pool
+			tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+		}
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	} else if clientCA != "" {
+		return nil, fmt.Errorf("%s requires %s and %s to be set", ServerTLSClientCAEnv, ServerTLSCertEnv, ServerTLSKeyEnv)
+	}
+
+	if token := os.Getenv(ServerTokenEnv); token != "" {
+		opts = append(opts,
+			grpc.UnaryInterceptor(func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+				if err := checkToken(ctx, token); err != nil {
+					return nil, err
+				}
+				return handler(ctx, req)
+			}),
+			grpc.StreamInterceptor(func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+				if err := checkToken(ss.Context(), token); err != nil {
+					return err
+				}
+				return handler(srv, ss)
+			}),
+		)
+	}
+
+	return opts, nil
+}
+
+func checkToken(ctx context.Context, token string) error {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return status.Error(codes.Unauthenticated, "missing credentials")
+	}
+	for _, v := range md.Get("authorization") {
+		if subtle.ConstantTimeCompare([]byte(v), []byte("Bearer "+token)) == 1 {
+			return nil
+		}
+	}
+	return status.Error(codes.Unauthenticated, "invalid token")
+}
@@ -0,0 +1,61 @@
+package grpc_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	. "github.com/go-skynet/LocalAI/pkg/grpc"
+	"github.com/go-skynet/LocalAI/pkg/grpc/base"
+	"github.com/phayes/freeport"
+)
+
+func TestParseURI(t *testing.T) {
+	address, opts, err := ParseURI("localhost:50051")
+	if err != nil || address != "localhost:50051" || opts != (ClientOptions{}) {
+		t.Fatalf("unexpected result for a plain address: %s %+v %v", address, opts, err)
+	}
+
+	address, opts, err = ParseURI("localhost:50051?ca=/etc/ca.pem&cert=/etc/cert.pem&key=/etc/key.pem&token=secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := ClientOptions{TLS: true, CACert: "/etc/ca.pem", ClientCert: "/etc/cert.pem", ClientKey: "/etc/key.pem", Token: "secret"}
+	if address != "localhost:50051" || opts != expected {
+		t.Fatalf("unexpected result: %s %+v", address, opts)
+	}
+
+	if _, _, err := ParseURI("localhost:50051?cert=/etc/cert.pem"); err == nil {
+		t.Fatal("expected an error when the client key is missing")
+	}
+}
+
+func TestServerToken(t *testing.T) {
+	t.Setenv(ServerTokenEnv, "secret")
+
+	port, err := freeport.GetFreePort()
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := fmt.Sprintf("127.0.0.1:%d", port)
+	go StartServer(address, &base.Base{})
+
+	client := NewClientWithOptions(address, ClientOptions{Token: "secret"})
+	defer client.Close()
+	ready := false
+	for i := 0; i < 50 && !ready; i++ {
+		ready = client.HealthCheck(context.Background())
+		time.Sleep(100 * time.Millisecond)
+	}
+	if !ready {
+		t.Fatal("server did not answer to an authenticated client")
+	}
+
+	for _, c := range []*Client{NewClient(address), NewClientWithOptions(address, ClientOptions{Token: "wrong"})} {
+		if c.HealthCheck(context.Background()) {
+			t.Fatal("server answered to an unauthenticated client")
+		}
+		c.Close()
+	}
+}
@@ -20,6 +20,12 @@ func (sd *StableDiffusion) Load(opts *pb.ModelOptions) error {
 	return err
 }
 
+func (sd *StableDiffusion) Capabilities() *pb.CapabilitiesResult {
+	return &pb.CapabilitiesResult{
+		GenerateImage: true,
+	}
+}
+
 func (sd *StableDiffusion) GenerateImage(opts *pb.GenerateImageRequest) error {
 	return sd.stablediffusion.GenerateImage(
 		int(opts.Height),
@@ -1,18 +1,28 @@
 package grpc
 
 import (
+	"context"
+
 	pb "github.com/go-skynet/LocalAI/pkg/grpc/proto"
 	"github.com/go-skynet/LocalAI/pkg/grpc/whisper/api"
 )
 
 type LLM interface {
 	Predict(*pb.PredictOptions) (string, error)
+	// PredictStream sends the tokens to the channel, and closes it once done. It may leave the channel open
+	// when it returns an error.
 	PredictStream(*pb.PredictOptions, chan string) error
+	// PredictContext and PredictStreamContext are the variants of Predict and PredictStream
+	// that stop generating when the context is canceled. Backends embedding base.Base
+	// that do not override them are called without a context.
+	PredictContext(context.Context, *pb.PredictOptions) (string, error)
+	PredictStreamContext(context.Context, *pb.PredictOptions, chan string) error
 	Load(*pb.ModelOptions) error
 	Embeddings(*pb.PredictOptions) ([]float32, error)
 	GenerateImage(*pb.GenerateImageRequest) error
 	AudioTranscription(*pb.TranscriptRequest) (api.Result, error)
 	TTS(*pb.TTSRequest) error
+	Capabilities() *pb.CapabilitiesResult
 }
 
 func newReply(s string) *pb.Reply {
@@ -20,6 +20,12 @@ func (llm *Embeddings) Load(opts *pb.ModelOptions) error {
 	return err
 }
 
+func (llm *Embeddings) Capabilities() *pb.CapabilitiesResult {
+	return &pb.CapabilitiesResult{
+		Embeddings: true,
+	}
+}
+
 func (llm *Embeddings) Embeddings(opts *pb.PredictOptions) ([]float32, error) {
 	if len(opts.EmbeddingTokens) > 0 {
 		tokens := []int{}
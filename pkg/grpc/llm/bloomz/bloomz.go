@@ -23,6 +23,13 @@ func (llm *LLM) Load(opts *pb.ModelOptions) error {
 	return err
 }
 
+func (llm *LLM) Capabilities() *pb.CapabilitiesResult {
+	return &pb.CapabilitiesResult{
+		Predict:       true,
+		PredictStream: true,
+	}
+}
+
 func buildPredictOptions(opts *pb.PredictOptions) []bloomz.PredictOption {
 	predictOptions := []bloomz.PredictOption{
 		bloomz.SetTemperature(float64(opts.Temperature)),
@@ -45,6 +45,13 @@ func (llm *LLM) Load(opts *pb.ModelOptions) error {
 	return err
 }
 
+func (llm *LLM) Capabilities() *pb.CapabilitiesResult {
+	return &pb.CapabilitiesResult{
+		Predict:       true,
+		PredictStream: true,
+	}
+}
+
 func buildPredictOptions(opts *pb.PredictOptions) []ggllm.PredictOption {
 	predictOptions := []ggllm.PredictOption{
 		ggllm.SetTemperature(float64(opts.Temperature)),
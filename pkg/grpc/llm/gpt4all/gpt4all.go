@@ -24,6 +24,13 @@ func (llm *LLM) Load(opts *pb.ModelOptions) error {
 	return err
 }
 
+func (llm *LLM) Capabilities() *pb.CapabilitiesResult {
+	return &pb.CapabilitiesResult{
+		Predict:       true,
+		PredictStream: true,
+	}
+}
+
 func buildPredictOptions(opts *pb.PredictOptions) []gpt4all.PredictOption {
 	predictOptions := []gpt4all.PredictOption{
 		gpt4all.SetTemperature(float64(opts.Temperature)),
@@ -23,6 +23,13 @@ func (llm *LLM) Load(opts *pb.ModelOptions) error {
 	return nil
 }
 
+func (llm *LLM) Capabilities() *pb.CapabilitiesResult {
+	return &pb.CapabilitiesResult{
+		Predict:       true,
+		PredictStream: true,
+	}
+}
+
 func (llm *LLM) Predict(opts *pb.PredictOptions) (string, error) {
 	o := []langchain.PredictOption{
 		langchain.SetModel(llm.model),
@@ -3,6 +3,7 @@ package llama
 // This is a wrapper to statisfy the GRPC service interface
 // It is meant to be used by the main executable that is the server for the specific backend type (falcon, gpt3, etc)
 import (
+	"context"
 	"fmt"
 
 	"github.com/go-skynet/LocalAI/pkg/grpc/base"
@@ -76,6 +77,15 @@ func (llm *LLM) Load(opts *pb.ModelOptions) error {
 	return err
 }
 
+func (llm *LLM) Capabilities() *pb.CapabilitiesResult {
+	return &pb.CapabilitiesResult{
+		Predict:       true,
+		PredictStream: true,
+		Embeddings:    true,
+		Grammar:       true,
+	}
+}
+
 func buildPredictOptions(opts *pb.PredictOptions) []llama.PredictOption {
 	ropeFreqBase := float32(10000)
 	ropeFreqScale := float32(1)
@@ -167,15 +177,39 @@ func buildPredictOptions(opts *pb.PredictOptions) []llama.PredictOption {
 }
 
 func (llm *LLM) Predict(opts *pb.PredictOptions) (string, error) {
-	return llm.llama.Predict(opts.Prompt, buildPredictOptions(opts)...)
+	return llm.PredictContext(context.Background(), opts)
+}
+
+// PredictContext stops generating as soon as the context is canceled
+func (llm *LLM) PredictContext(ctx context.Context, opts *pb.PredictOptions) (string, error) {
+	predictOptions := buildPredictOptions(opts)
+
+	predictOptions = append(predictOptions, llama.SetTokenCallback(func(token string) bool {
+		return ctx.Err() == nil
+	}))
+
+	res, err := llm.llama.Predict(opts.Prompt, predictOptions...)
+	if err != nil {
+		return res, err
+	}
+	return res, ctx.Err()
 }
 
 func (llm *LLM) PredictStream(opts *pb.PredictOptions, results chan string) error {
+	return llm.PredictStreamContext(context.Background(), opts, results)
+}
+
+// PredictStreamContext stops generating as soon as the context is canceled
+func (llm *LLM) PredictStreamContext(ctx context.Context, opts *pb.PredictOptions, results chan string) error {
 	predictOptions := buildPredictOptions(opts)
 
 	predictOptions = append(predictOptions, llama.SetTokenCallback(func(token string) bool {
-		results <- token
-		return true
+		select {
+		case results <- token:
+			return true
+		case <-ctx.Done():
+			return false
+		}
 	}))
 
 	go func() {
@@ -31,6 +31,13 @@ func (llm *LLM) Load(opts *pb.ModelOptions) error {
 	return nil
 }
 
+func (llm *LLM) Capabilities() *pb.CapabilitiesResult {
+	return &pb.CapabilitiesResult{
+		Predict:       true,
+		PredictStream: true,
+	}
+}
+
 func (llm *LLM) Predict(opts *pb.PredictOptions) (string, error) {
 
 	stopWord := "\n"
@@ -49,6 +56,7 @@ func (llm *LLM) Predict(opts *pb.PredictOptions) (string, error) {
 
 func (llm *LLM) PredictStream(opts *pb.PredictOptions, results chan string) error {
 	go func() {
+		defer close(results)
 
 		stopWord := "\n"
 		if len(opts.StopPrompts) > 0 {
@@ -64,7 +72,6 @@ func (llm *LLM) PredictStream(opts *pb.PredictOptions, results chan string) erro
 			results <- s
 			return true
 		})
-		close(results)
 	}()
 
 	return nil
@@ -3,7 +3,7 @@ package transformers
 // This is a wrapper to statisfy the GRPC service interface
 // It is meant to be used by the main executable that is the server for the specific backend type (falcon, gpt3, etc)
 import (
-	"fmt"
+	"context"
 
 	"github.com/go-skynet/LocalAI/pkg/grpc/base"
 	pb "github.com/go-skynet/LocalAI/pkg/grpc/proto"
@@ -14,7 +14,8 @@ import (
 type Dolly struct {
 	base.Base
 
-	dolly *transformers.Dolly
+	dolly      *transformers.Dolly
+	generation generation
 }
 
 func (llm *Dolly) Load(opts *pb.ModelOptions) error {
@@ -23,21 +24,30 @@ func (llm *Dolly) Load(opts *pb.ModelOptions) error {
 	return err
 }
 
+func (llm *Dolly) Capabilities() *pb.CapabilitiesResult {
+	return &pb.CapabilitiesResult{
+		Predict:       true,
+		PredictStream: true,
+	}
+}
+
 func (llm *Dolly) Predict(opts *pb.PredictOptions) (string, error) {
-	return llm.dolly.Predict(opts.Prompt, buildPredictOptions(opts)...)
+	return llm.PredictContext(context.Background(), opts)
+}
+
+func (llm *Dolly) PredictContext(ctx context.Context, opts *pb.PredictOptions) (string, error) {
+	return llm.generation.predict(ctx, func() (string, error) {
+		return llm.dolly.Predict(opts.Prompt, buildPredictOptions(opts)...)
+	})
 }
 
 // fallback to Predict
 func (llm *Dolly) PredictStream(opts *pb.PredictOptions, results chan string) error {
-	go func() {
-		res, err := llm.dolly.Predict(opts.Prompt, buildPredictOptions(opts)...)
-
-		if err != nil {
-			fmt.Println("err: ", err)
-		}
-		results <- res
-		close(results)
-	}()
+	return llm.PredictStreamContext(context.Background(), opts, results)
+}
 
-	return nil
+func (llm *Dolly) PredictStreamContext(ctx context.Context, opts *pb.PredictOptions, results chan string) error {
+	return llm.generation.predictStream(ctx, results, func() (string, error) {
+		return llm.dolly.Predict(opts.Prompt, buildPredictOptions(opts)...)
+	})
 }
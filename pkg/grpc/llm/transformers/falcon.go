@@ -3,7 +3,7 @@ package transformers
 // This is a wrapper to statisfy the GRPC service interface
 // It is meant to be used by the main executable that is the server for the specific backend type (falcon, gpt3, etc)
 import (
-	"fmt"
+	"context"
 
 	"github.com/go-skynet/LocalAI/pkg/grpc/base"
 	pb "github.com/go-skynet/LocalAI/pkg/grpc/proto"
@@ -14,7 +14,8 @@ import (
 type Falcon struct {
 	base.Base
 
-	falcon *transformers.Falcon
+	falcon     *transformers.Falcon
+	generation generation
 }
 
 func (llm *Falcon) Load(opts *pb.ModelOptions) error {
@@ -23,21 +24,30 @@ func (llm *Falcon) Load(opts *pb.ModelOptions) error {
 	return err
 }
 
+func (llm *Falcon) Capabilities() *pb.CapabilitiesResult {
+	return &pb.CapabilitiesResult{
+		Predict:       true,
+		PredictStream: true,
+	}
+}
+
 func (llm *Falcon) Predict(opts *pb.PredictOptions) (string, error) {
-	return llm.falcon.Predict(opts.Prompt, buildPredictOptions(opts)...)
+	return llm.PredictContext(context.Background(), opts)
+}
+
+func (llm *Falcon) PredictContext(ctx context.Context, opts *pb.PredictOptions) (string, error) {
+	return llm.generation.predict(ctx, func() (string, error) {
+		return llm.falcon.Predict(opts.Prompt, buildPredictOptions(opts)...)
+	})
 }
 
 // fallback to Predict
 func (llm *Falcon) PredictStream(opts *pb.PredictOptions, results chan string) error {
-	go func() {
-		res, err := llm.falcon.Predict(opts.Prompt, buildPredictOptions(opts)...)
-
-		if err != nil {
-			fmt.Println("err: ", err)
-		}
-		results <- res
-		close(results)
-	}()
+	return llm.PredictStreamContext(context.Background(), opts, results)
+}
 
-	return nil
+func (llm *Falcon) PredictStreamContext(ctx context.Context, opts *pb.PredictOptions, results chan string) error {
+	return llm.generation.predictStream(ctx, results, func() (string, error) {
+		return llm.falcon.Predict(opts.Prompt, buildPredictOptions(opts)...)
+	})
 }
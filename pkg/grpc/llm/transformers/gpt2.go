@@ -3,7 +3,7 @@ package transformers
 // This is a wrapper to statisfy the GRPC service interface
 // It is meant to be used by the main executable that is the server for the specific backend type (falcon, gpt3, etc)
 import (
-	"fmt"
+	"context"
 
 	"github.com/go-skynet/LocalAI/pkg/grpc/base"
 	pb "github.com/go-skynet/LocalAI/pkg/grpc/proto"
@@ -14,7 +14,8 @@ import (
 type GPT2 struct {
 	base.Base
 
-	gpt2 *transformers.GPT2
+	gpt2       *transformers.GPT2
+	generation generation
 }
 
 func (llm *GPT2) Load(opts *pb.ModelOptions) error {
@@ -23,20 +24,30 @@ func (llm *GPT2) Load(opts *pb.ModelOptions) error {
 	return err
 }
 
+func (llm *GPT2) Capabilities() *pb.CapabilitiesResult {
+	return &pb.CapabilitiesResult{
+		Predict:       true,
+		PredictStream: true,
+	}
+}
+
 func (llm *GPT2) Predict(opts *pb.PredictOptions) (string, error) {
-	return llm.gpt2.Predict(opts.Prompt, buildPredictOptions(opts)...)
+	return llm.PredictContext(context.Background(), opts)
+}
+
+func (llm *GPT2) PredictContext(ctx context.Context, opts *pb.PredictOptions) (string, error) {
+	return llm.generation.predict(ctx, func() (string, error) {
+		return llm.gpt2.Predict(opts.Prompt, buildPredictOptions(opts)...)
+	})
 }
 
 // fallback to Predict
 func (llm *GPT2) PredictStream(opts *pb.PredictOptions, results chan string) error {
-	go func() {
-		res, err := llm.gpt2.Predict(opts.Prompt, buildPredictOptions(opts)...)
-
-		if err != nil {
-			fmt.Println("err: ", err)
-		}
-		results <- res
-		close(results)
-	}()
-	return nil
+	return llm.PredictStreamContext(context.Background(), opts, results)
+}
+
+func (llm *GPT2) PredictStreamContext(ctx context.Context, opts *pb.PredictOptions, results chan string) error {
+	return llm.generation.predictStream(ctx, results, func() (string, error) {
+		return llm.gpt2.Predict(opts.Prompt, buildPredictOptions(opts)...)
+	})
 }
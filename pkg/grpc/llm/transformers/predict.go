@@ -1,6 +1,10 @@
 package transformers
 
 import (
+	"context"
+	"fmt"
+	"sync"
+
 	pb "github.com/go-skynet/LocalAI/pkg/grpc/proto"
 	transformers "github.com/go-skynet/go-ggml-transformers.cpp"
 )
@@ -24,3 +28,49 @@ func buildPredictOptions(opts *pb.PredictOptions) []transformers.PredictOption {
 
 	return predictOptions
 }
+
+// generation serializes the generations on a model, as the bindings can't run them concurrently.
+// Each model has its own, so that the models loaded in the same process don't wait for each other.
+// Waiting for it, unlike for a mutex, can be interrupted by canceling the request.
+//
+// Cancellation is only partly supported by the transformers backends: the bindings can't stop a generation
+// once started, so canceling a request only drops it while it waits for the model, and a running generation
+// completes before the model is available again.
+type generation struct {
+	once sync.Once
+	busy chan struct{}
+}
+
+// predict runs the generation unless the request is canceled before the model is available.
+// The requests whose client went away while waiting are dropped instead of keeping the model busy for nothing.
+func (g *generation) predict(ctx context.Context, generate func() (string, error)) (string, error) {
+	g.once.Do(func() { g.busy = make(chan struct{}, 1) })
+	select {
+	case g.busy <- struct{}{}:
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+	defer func() { <-g.busy }()
+
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	return generate()
+}
+
+// predictStream sends the whole result of predict at once, as the bindings don't stream tokens
+func (g *generation) predictStream(ctx context.Context, results chan string, generate func() (string, error)) error {
+	go func() {
+		defer close(results)
+		res, err := g.predict(ctx, generate)
+		if err != nil {
+			fmt.Println("err: ", err)
+			return
+		}
+		select {
+		case results <- res:
+		case <-ctx.Done():
+		}
+	}()
+	return nil
+}
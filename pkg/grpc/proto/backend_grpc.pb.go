@@ -30,6 +30,7 @@ type BackendClient interface {
 	GenerateImage(ctx context.Context, in *GenerateImageRequest, opts ...grpc.CallOption) (*Result, error)
 	AudioTranscription(ctx context.Context, in *TranscriptRequest, opts ...grpc.CallOption) (*TranscriptResult, error)
 	TTS(ctx context.Context, in *TTSRequest, opts ...grpc.CallOption) (*Result, error)
+	Capabilities(ctx context.Context, in *HealthMessage, opts ...grpc.CallOption) (*CapabilitiesResult, error)
 }
 
 type backendClient struct {
@@ -135,6 +136,15 @@ func (c *backendClient) TTS(ctx context.Context, in *TTSRequest, opts ...grpc.Ca
 	return out, nil
 }
 
+func (c *backendClient) Capabilities(ctx context.Context, in *HealthMessage, opts ...grpc.CallOption) (*CapabilitiesResult, error) {
+	out := new(CapabilitiesResult)
+	err := c.cc.Invoke(ctx, "/backend.Backend/Capabilities", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // BackendServer is the server API for Backend service.
 // All implementations must embed UnimplementedBackendServer
 // for forward compatibility
@@ -147,6 +157,7 @@ type BackendServer interface {
 	GenerateImage(context.Context, *GenerateImageRequest) (*Result, error)
 	AudioTranscription(context.Context, *TranscriptRequest) (*TranscriptResult, error)
 	TTS(context.Context, *TTSRequest) (*Result, error)
+	Capabilities(context.Context, *HealthMessage) (*CapabilitiesResult, error)
 	mustEmbedUnimplementedBackendServer()
 }
 
@@ -178,6 +189,9 @@ func (UnimplementedBackendServer) AudioTranscription(context.Context, *Transcrip
 func (UnimplementedBackendServer) TTS(context.Context, *TTSRequest) (*Result, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method TTS not implemented")
 }
+func (UnimplementedBackendServer) Capabilities(context.Context, *HealthMessage) (*CapabilitiesResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Capabilities not implemented")
+}
 func (UnimplementedBackendServer) mustEmbedUnimplementedBackendServer() {}
 
 // UnsafeBackendServer may be embedded to opt out of forward compatibility for this service.
@@ -338,6 +352,24 @@ func _Backend_TTS_Handler(srv interface{}, ctx context.Context, dec func(interfa
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Backend_Capabilities_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HealthMessage)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BackendServer).Capabilities(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/backend.Backend/Capabilities",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BackendServer).Capabilities(ctx, req.(*HealthMessage))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // Backend_ServiceDesc is the grpc.ServiceDesc for Backend service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -373,6 +405,10 @@ var Backend_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "TTS",
 			Handler:    _Backend_TTS_Handler,
 		},
+		{
+			MethodName: "Capabilities",
+			Handler:    _Backend_Capabilities_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
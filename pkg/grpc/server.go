@@ -2,12 +2,18 @@ package grpc
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net"
+	"os"
+	"strings"
 
+	"github.com/go-skynet/LocalAI/pkg/grpc/base"
 	pb "github.com/go-skynet/LocalAI/pkg/grpc/proto"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 // A GRPC Server that allows to run LLM inference.
@@ -29,7 +35,23 @@ func (s *server) Health(ctx context.Context, in *pb.HealthMessage) (*pb.Reply, e
 	return newReply("OK"), nil
 }
 
+func (s *server) Capabilities(ctx context.Context, in *pb.HealthMessage) (*pb.CapabilitiesResult, error) {
+	return s.llm.Capabilities(), nil
+}
+
+// supports returns an Unimplemented error if the backend does not implement the operation,
+// rather than letting the call fail deep inside the backend
+func (s *server) supports(c Capability) error {
+	if !Has(s.llm.Capabilities(), c) {
+		return status.Errorf(codes.Unimplemented, "%s is not supported by this backend", c)
+	}
+	return nil
+}
+
 func (s *server) Embedding(ctx context.Context, in *pb.PredictOptions) (*pb.EmbeddingResult, error) {
+	if err := s.supports(CapabilityEmbeddings); err != nil {
+		return nil, err
+	}
 	embeds, err := s.llm.Embeddings(in)
 	if err != nil {
 		return nil, err
@@ -47,11 +69,20 @@ func (s *server) LoadModel(ctx context.Context, in *pb.ModelOptions) (*pb.Result
 }
 
 func (s *server) Predict(ctx context.Context, in *pb.PredictOptions) (*pb.Reply, error) {
-	result, err := s.llm.Predict(in)
+	if err := s.supports(CapabilityPredict); err != nil {
+		return nil, err
+	}
+	result, err := s.llm.PredictContext(ctx, in)
+	if errors.Is(err, base.ErrNoContext) {
+		result, err = s.llm.Predict(in)
+	}
 	return newReply(result), err
 }
 
 func (s *server) GenerateImage(ctx context.Context, in *pb.GenerateImageRequest) (*pb.Result, error) {
+	if err := s.supports(CapabilityGenerateImage); err != nil {
+		return nil, err
+	}
 	err := s.llm.GenerateImage(in)
 	if err != nil {
 		return &pb.Result{Message: fmt.Sprintf("Error generating image: %s", err.Error()), Success: false}, err
@@ -60,6 +91,9 @@ func (s *server) GenerateImage(ctx context.Context, in *pb.GenerateImageRequest)
 }
 
 func (s *server) TTS(ctx context.Context, in *pb.TTSRequest) (*pb.Result, error) {
+	if err := s.supports(CapabilityTTS); err != nil {
+		return nil, err
+	}
 	err := s.llm.TTS(in)
 	if err != nil {
 		return &pb.Result{Message: fmt.Sprintf("Error generating audio: %s", err.Error()), Success: false}, err
@@ -68,6 +102,9 @@ func (s *server) TTS(ctx context.Context, in *pb.TTSRequest) (*pb.Result, error)
 }
 
 func (s *server) AudioTranscription(ctx context.Context, in *pb.TranscriptRequest) (*pb.TranscriptResult, error) {
+	if err := s.supports(CapabilityAudioTranscription); err != nil {
+		return nil, err
+	}
 	result, err := s.llm.AudioTranscription(in)
 	if err != nil {
 		return nil, err
@@ -93,29 +130,64 @@ func (s *server) AudioTranscription(ctx context.Context, in *pb.TranscriptReques
 }
 
 func (s *server) PredictStream(in *pb.PredictOptions, stream pb.Backend_PredictStreamServer) error {
+	if err := s.supports(CapabilityPredictStream); err != nil {
+		return err
+	}
 
 	resultChan := make(chan string)
+	// closed when the backend fails, as it may then return without closing the results
+	failed := make(chan struct{})
 
 	done := make(chan bool)
 	go func() {
-		for result := range resultChan {
-			stream.Send(newReply(result))
+		defer func() { done <- true }()
+		for {
+			select {
+			case result, ok := <-resultChan:
+				if !ok {
+					return
+				}
+				// keep draining the results if the client went away, until the backend stops
+				stream.Send(newReply(result))
+			case <-failed:
+				return
+			}
 		}
-		done <- true
 	}()
 
-	s.llm.PredictStream(in, resultChan)
+	err := s.llm.PredictStreamContext(stream.Context(), in, resultChan)
+	if errors.Is(err, base.ErrNoContext) {
+		err = s.llm.PredictStream(in, resultChan)
+	}
+	if err != nil {
+		close(failed)
+	}
 	<-done
+	if err != nil {
+		return err
+	}
 
-	return nil
+	return stream.Context().Err()
 }
 
+// StartServer starts a gRPC server serving the model at the given address.
+// The address is either a TCP address (host:port) or a Unix socket (unix:///path/to/socket).
+//
+// The server can require clients to authenticate by setting the following environment variables:
+//   - LOCALAI_BACKEND_TLS_CERT and LOCALAI_BACKEND_TLS_KEY to serve over TLS
+//   - LOCALAI_BACKEND_TLS_CLIENT_CA to also require client certificates signed by this CA (mTLS)
+//   - LOCALAI_BACKEND_TOKEN to require clients to send this bearer token
 func StartServer(address string, model LLM) error {
-	lis, err := net.Listen("tcp", address)
+	opts, err := serverOptionsFromEnv()
 	if err != nil {
 		return err
 	}
-	s := grpc.NewServer()
+
+	lis, err := listen(address)
+	if err != nil {
+		return err
+	}
+	s := grpc.NewServer(opts...)
 	pb.RegisterBackendServer(s, &server{llm: model})
 	log.Printf("gRPC Server listening at %v", lis.Addr())
 	if err := s.Serve(lis); err != nil {
@@ -124,3 +196,15 @@ func StartServer(address string, model LLM) error {
 
 	return nil
 }
+
+func listen(address string) (net.Listener, error) {
+	if strings.HasPrefix(address, "unix://") {
+		path := strings.TrimPrefix(address, "unix://")
+		// Remove any stale socket left behind by a previous run
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		return net.Listen("unix", path)
+	}
+	return net.Listen("tcp", address)
+}
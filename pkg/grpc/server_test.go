@@ -0,0 +1,178 @@
+package grpc_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/go-skynet/LocalAI/pkg/grpc"
+	"github.com/go-skynet/LocalAI/pkg/grpc/base"
+	pb "github.com/go-skynet/LocalAI/pkg/grpc/proto"
+)
+
+func TestStartServerUnixSocket(t *testing.T) {
+	dir, err := os.MkdirTemp("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	address := "unix://" + filepath.Join(dir, "grpc.sock")
+	go StartServer(address, &base.Base{})
+
+	client := NewClient(address)
+	defer client.Close()
+	for i := 0; i < 50; i++ {
+		if client.HealthCheck(context.Background()) {
+			return
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	t.Fatal("server did not answer on the unix socket")
+}
+
+// endless streams tokens until the request is canceled
+type endless struct {
+	base.Base
+	stopped chan struct{}
+}
+
+func (e *endless) PredictStreamContext(ctx context.Context, opts *pb.PredictOptions, results chan string) error {
+	go func() {
+		defer close(e.stopped)
+		defer close(results)
+		for {
+			select {
+			case results <- "token":
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+func TestPredictStreamCanceled(t *testing.T) {
+	llm := &endless{stopped: make(chan struct{})}
+	client := startTestServer(t, llm)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	tokens := 0
+	err := client.PredictStream(ctx, &pb.PredictOptions{}, func(s []byte) bool {
+		if tokens++; tokens == 3 {
+			cancel()
+		}
+		return true
+	})
+	if err == nil {
+		t.Error("expected the stream to fail once canceled")
+	}
+
+	select {
+	case <-llm.stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the backend did not stop generating")
+	}
+}
+
+func TestPredictStreamStopped(t *testing.T) {
+	llm := &endless{stopped: make(chan struct{})}
+	client := startTestServer(t, llm)
+
+	tokens := 0
+	err := client.PredictStream(context.Background(), &pb.PredictOptions{}, func(s []byte) bool {
+		tokens++
+		return tokens < 3
+	})
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if tokens != 3 {
+		t.Errorf("expected the stream to stop after 3 tokens, got %d", tokens)
+	}
+
+	select {
+	case <-llm.stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the backend did not stop generating")
+	}
+}
+
+// failing can't start generating
+type failing struct {
+	base.Base
+}
+
+func (f *failing) PredictStreamContext(ctx context.Context, opts *pb.PredictOptions, results chan string) error {
+	return errors.New("out of memory")
+}
+
+func TestPredictStreamFailing(t *testing.T) {
+	client := startTestServer(t, &failing{})
+
+	err := client.PredictStream(context.Background(), &pb.PredictOptions{}, func(s []byte) bool {
+		t.Errorf("unexpected token %q", s)
+		return true
+	})
+	if err == nil || !strings.Contains(err.Error(), "out of memory") {
+		t.Errorf("expected the error of the backend, got %v", err)
+	}
+}
+
+// interrupted sends a token, then closes the results and fails, as backends can do when generation breaks
+type interrupted struct {
+	base.Base
+}
+
+func (i *interrupted) PredictStreamContext(ctx context.Context, opts *pb.PredictOptions, results chan string) error {
+	results <- "partial"
+	close(results)
+	return errors.New("generation failed")
+}
+
+func TestPredictStreamFailingAfterClosing(t *testing.T) {
+	client := startTestServer(t, &interrupted{})
+
+	tokens := []string{}
+	err := client.PredictStream(context.Background(), &pb.PredictOptions{}, func(s []byte) bool {
+		tokens = append(tokens, string(s))
+		return true
+	})
+	if err == nil || !strings.Contains(err.Error(), "generation failed") {
+		t.Errorf("expected the error of the backend, got %v", err)
+	}
+	if len(tokens) != 1 || tokens[0] != "partial" {
+		t.Errorf("expected the token sent before failing, got %v", tokens)
+	}
+
+	// the server keeps serving
+	err = client.PredictStream(context.Background(), &pb.PredictOptions{}, func(s []byte) bool { return true })
+	if err == nil || !strings.Contains(err.Error(), "generation failed") {
+		t.Errorf("expected the error of the backend, got %v", err)
+	}
+}
+
+// contextless only implements the methods without a context
+type contextless struct {
+	base.Base
+}
+
+func (c *contextless) Predict(opts *pb.PredictOptions) (string, error) {
+	return "reply", nil
+}
+
+func TestPredictWithoutContext(t *testing.T) {
+	client := startTestServer(t, &contextless{})
+
+	reply, err := client.Predict(context.Background(), &pb.PredictOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(reply.GetMessage()) != "reply" {
+		t.Errorf("expected the reply of Predict, got %q", reply.GetMessage())
+	}
+}
@@ -22,6 +22,12 @@ func (sd *Whisper) Load(opts *pb.ModelOptions) error {
 	return err
 }
 
+func (sd *Whisper) Capabilities() *pb.CapabilitiesResult {
+	return &pb.CapabilitiesResult{
+		AudioTranscription: true,
+	}
+}
+
 func (sd *Whisper) AudioTranscription(opts *pb.TranscriptRequest) (api.Result, error) {
 	return whisperutil.Transcript(sd.whisper, opts.Dst, opts.Language, uint(opts.Threads))
 }
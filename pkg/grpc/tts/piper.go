@@ -27,6 +27,12 @@ func (sd *Piper) Load(opts *pb.ModelOptions) error {
 	return err
 }
 
+func (sd *Piper) Capabilities() *pb.CapabilitiesResult {
+	return &pb.CapabilitiesResult{
+		TTS: true,
+	}
+}
+
 func (sd *Piper) TTS(opts *pb.TTSRequest) error {
 	return sd.piper.TTS(opts.Text, opts.Model, opts.Dst)
 }
@@ -0,0 +1,693 @@
+package jinja
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+type filterFunc func(v interface{}, args []interface{}, kwargs map[string]interface{}) (interface{}, error)
+
+type testFunc func(v interface{}, args []interface{}) (bool, error)
+
+var builtins map[string]function
+
+var filters map[string]filterFunc
+
+var tests map[string]testFunc
+
+func init() {
+	builtins = map[string]function{
+		"raise_exception": func(args []interface{}, kwargs map[string]interface{}) (interface{}, error) {
+			return nil, &RaisedError{Message: toString(arg(args, kwargs, 0, "message", ""))}
+		},
+		"range": func(args []interface{}, kwargs map[string]interface{}) (interface{}, error) {
+			bounds := []int{}
+			for _, a := range args {
+				i, ok := toInt(a)
+				if !ok {
+					return nil, fmt.Errorf("range() arguments must be integers, not %s", typeName(a))
+				}
+				bounds = append(bounds, i)
+			}
+			start, stop, step := 0, 0, 1
+			switch len(bounds) {
+			case 1:
+				stop = bounds[0]
+			case 2:
+				start, stop = bounds[0], bounds[1]
+			case 3:
+				start, stop, step = bounds[0], bounds[1], bounds[2]
+			default:
+				return nil, fmt.Errorf("range() expects 1 to 3 arguments, got %d", len(bounds))
+			}
+			if step == 0 {
+				return nil, fmt.Errorf("range() step must not be zero")
+			}
+			// the length is computed on unsigned integers, for the distance between the bounds not to overflow
+			var n uint64
+			if step > 0 && start < stop {
+				n = (uint64(stop)-uint64(start)-1)/uint64(step) + 1
+			} else if step < 0 && start > stop {
+				n = (uint64(start)-uint64(stop)-1)/uint64(-step) + 1
+			}
+			if n > maxListLength {
+				return nil, fmt.Errorf("range() of %d items: %w", n, errListTooLong)
+			}
+			res := make([]interface{}, n)
+			for i := range res {
+				res[i] = start + i*step
+			}
+			return res, nil
+		},
+		"namespace": func(args []interface{}, kwargs map[string]interface{}) (interface{}, error) {
+			ns := &namespace{vars: map[string]interface{}{}}
+			for _, a := range args {
+				if m, ok := a.(map[string]interface{}); ok {
+					for k, v := range m {
+						ns.vars[k] = v
+					}
+				}
+			}
+			for k, v := range kwargs {
+				ns.vars[k] = v
+			}
+			return ns, nil
+		},
+	}
+
+	filters = map[string]filterFunc{
+		"trim": func(v interface{}, args []interface{}, kwargs map[string]interface{}) (interface{}, error) {
+			return strip(toString(v), arg(args, kwargs, 0, "chars", nil), true, true), nil
+		},
+		"length": length,
+		"count":  length,
+		"upper": func(v interface{}, _ []interface{}, _ map[string]interface{}) (interface{}, error) {
+			return strings.ToUpper(toString(v)), nil
+		},
+		"lower": func(v interface{}, _ []interface{}, _ map[string]interface{}) (interface{}, error) {
+			return strings.ToLower(toString(v)), nil
+		},
+		"title": func(v interface{}, _ []interface{}, _ map[string]interface{}) (interface{}, error) {
+			return title(toString(v)), nil
+		},
+		"capitalize": func(v interface{}, _ []interface{}, _ map[string]interface{}) (interface{}, error) {
+			return capitalize(toString(v)), nil
+		},
+		"tojson": func(v interface{}, args []interface{}, kwargs map[string]interface{}) (interface{}, error) {
+			indent, _ := toInt(arg(args, kwargs, 0, "indent", nil))
+			var sb strings.Builder
+			if err := writeJSON(&sb, v, indent, 0); err != nil {
+				return nil, err
+			}
+			return sb.String(), nil
+		},
+		"first": func(v interface{}, _ []interface{}, _ map[string]interface{}) (interface{}, error) {
+			items, err := toList(v)
+			if err != nil || len(items) == 0 {
+				return &undefined{name: "first"}, err
+			}
+			return items[0], nil
+		},
+		"last": func(v interface{}, _ []interface{}, _ map[string]interface{}) (interface{}, error) {
+			items, err := toList(v)
+			if err != nil || len(items) == 0 {
+				return &undefined{name: "last"}, err
+			}
+			return items[len(items)-1], nil
+		},
+		"join": func(v interface{}, args []interface{}, kwargs map[string]interface{}) (interface{}, error) {
+			items, err := toList(v)
+			if err != nil {
+				return nil, err
+			}
+			if attr, ok := arg(args, kwargs, 1, "attribute", nil).(string); ok {
+				values := make([]interface{}, len(items))
+				for i := range items {
+					values[i] = getAttr(items[i], attr)
+				}
+				items = values
+			}
+			return join(toString(arg(args, kwargs, 0, "d", "")), items)
+		},
+		"string": func(v interface{}, _ []interface{}, _ map[string]interface{}) (interface{}, error) {
+			return toString(v), nil
+		},
+		"default": defaultFilter,
+		"d":       defaultFilter,
+		"list": func(v interface{}, _ []interface{}, _ map[string]interface{}) (interface{}, error) {
+			items, err := toList(v)
+			return append([]interface{}{}, items...), err
+		},
+		"items": func(v interface{}, _ []interface{}, _ map[string]interface{}) (interface{}, error) {
+			m, ok := v.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("items filter expects a dict, got %s", typeName(v))
+			}
+			return items(m), nil
+		},
+		"replace": func(v interface{}, args []interface{}, kwargs map[string]interface{}) (interface{}, error) {
+			n, ok := toInt(arg(args, kwargs, 2, "count", nil))
+			if !ok {
+				n = -1
+			}
+			return replace(toString(v), toString(arg(args, kwargs, 0, "old", "")), toString(arg(args, kwargs, 1, "new", "")), n)
+		},
+		"int": func(v interface{}, args []interface{}, kwargs map[string]interface{}) (interface{}, error) {
+			switch n := v.(type) {
+			case float64:
+				return int(n), nil
+			case string:
+				if i, err := strconv.Atoi(strings.TrimSpace(n)); err == nil {
+					return i, nil
+				}
+				if f, err := strconv.ParseFloat(strings.TrimSpace(n), 64); err == nil {
+					return int(f), nil
+				}
+			}
+			if i, ok := toInt(v); ok {
+				return i, nil
+			}
+			return arg(args, kwargs, 0, "default", 0), nil
+		},
+		"float": func(v interface{}, args []interface{}, kwargs map[string]interface{}) (interface{}, error) {
+			if s, ok := v.(string); ok {
+				if f, err := strconv.ParseFloat(strings.TrimSpace(s), 64); err == nil {
+					return f, nil
+				}
+			}
+			if f, ok := toFloat(v); ok {
+				return f, nil
+			}
+			return arg(args, kwargs, 0, "default", 0.0), nil
+		},
+		"reverse": func(v interface{}, _ []interface{}, _ map[string]interface{}) (interface{}, error) {
+			if s, ok := v.(string); ok {
+				r := []rune(s)
+				for i, j := 0, len(r)-1; i < j; i, j = i+1, j-1 {
+					r[i], r[j] = r[j], r[i]
+				}
+				return string(r), nil
+			}
+			items, err := toList(v)
+			if err != nil {
+				return nil, err
+			}
+			res := make([]interface{}, len(items))
+			for i := range items {
+				res[len(items)-1-i] = items[i]
+			}
+			return res, nil
+		},
+		"safe": func(v interface{}, _ []interface{}, _ map[string]interface{}) (interface{}, error) {
+			return v, nil
+		},
+		"indent": func(v interface{}, args []interface{}, kwargs map[string]interface{}) (interface{}, error) {
+			width, ok := toInt(arg(args, kwargs, 0, "width", 4))
+			if !ok {
+				width = 4
+			}
+			s := toString(v)
+			lines := strings.Split(s, "\n")
+			if width > 0 && len(lines) > (maxStringSize-len(s))/width {
+				return nil, errStringTooLarge
+			}
+			pad, err := repeat(" ", width)
+			if err != nil {
+				return nil, err
+			}
+			for i := range lines {
+				if (i > 0 || truthy(arg(args, kwargs, 1, "first", false))) && lines[i] != "" {
+					lines[i] = pad + lines[i]
+				}
+			}
+			return strings.Join(lines, "\n"), nil
+		},
+		"selectattr": func(v interface{}, args []interface{}, kwargs map[string]interface{}) (interface{}, error) {
+			return selectAttr(v, args, true)
+		},
+		"rejectattr": func(v interface{}, args []interface{}, kwargs map[string]interface{}) (interface{}, error) {
+			return selectAttr(v, args, false)
+		},
+		"map": func(v interface{}, args []interface{}, kwargs map[string]interface{}) (interface{}, error) {
+			attr, ok := kwargs["attribute"].(string)
+			if !ok {
+				return nil, fmt.Errorf("map filter expects an attribute")
+			}
+			items, err := toList(v)
+			if err != nil {
+				return nil, err
+			}
+			res := make([]interface{}, len(items))
+			for i := range items {
+				res[i] = getAttr(items[i], attr)
+			}
+			return res, nil
+		},
+	}
+
+	tests = map[string]testFunc{
+		"defined": func(v interface{}, _ []interface{}) (bool, error) {
+			_, undef := v.(*undefined)
+			return !undef, nil
+		},
+		"undefined": func(v interface{}, _ []interface{}) (bool, error) {
+			_, undef := v.(*undefined)
+			return undef, nil
+		},
+		"none": func(v interface{}, _ []interface{}) (bool, error) {
+			return v == nil, nil
+		},
+		"string": func(v interface{}, _ []interface{}) (bool, error) {
+			_, ok := v.(string)
+			return ok, nil
+		},
+		"number": func(v interface{}, _ []interface{}) (bool, error) {
+			switch v.(type) {
+			case int, float64:
+				return true, nil
+			}
+			return false, nil
+		},
+		"integer": func(v interface{}, _ []interface{}) (bool, error) {
+			_, ok := v.(int)
+			return ok, nil
+		},
+		"float": func(v interface{}, _ []interface{}) (bool, error) {
+			_, ok := v.(float64)
+			return ok, nil
+		},
+		"boolean": func(v interface{}, _ []interface{}) (bool, error) {
+			_, ok := v.(bool)
+			return ok, nil
+		},
+		"true": func(v interface{}, _ []interface{}) (bool, error) {
+			return v == true, nil
+		},
+		"false": func(v interface{}, _ []interface{}) (bool, error) {
+			return v == false, nil
+		},
+		"mapping": func(v interface{}, _ []interface{}) (bool, error) {
+			_, ok := v.(map[string]interface{})
+			return ok, nil
+		},
+		"sequence": isIterable,
+		"iterable": isIterable,
+		"callable": func(v interface{}, _ []interface{}) (bool, error) {
+			_, ok := v.(function)
+			return ok, nil
+		},
+		"even": func(v interface{}, _ []interface{}) (bool, error) {
+			i, ok := toInt(v)
+			return ok && i%2 == 0, nil
+		},
+		"odd": func(v interface{}, _ []interface{}) (bool, error) {
+			i, ok := toInt(v)
+			return ok && i%2 != 0, nil
+		},
+		"divisibleby": func(v interface{}, args []interface{}) (bool, error) {
+			i, ok := toInt(v)
+			n, nok := toInt(arg(args, nil, 0, "num", nil))
+			if !ok || !nok || n == 0 {
+				return false, fmt.Errorf("divisibleby expects integers")
+			}
+			return i%n == 0, nil
+		},
+		"eq":      equalTest,
+		"equalto": equalTest,
+		"==":      equalTest,
+		"ne": func(v interface{}, args []interface{}) (bool, error) {
+			eq, err := equalTest(v, args)
+			return !eq, err
+		},
+		"in": func(v interface{}, args []interface{}) (bool, error) {
+			return contains(arg(args, nil, 0, "seq", nil), v)
+		},
+		"lower": func(v interface{}, _ []interface{}) (bool, error) {
+			s, ok := v.(string)
+			return ok && s == strings.ToLower(s), nil
+		},
+		"upper": func(v interface{}, _ []interface{}) (bool, error) {
+			s, ok := v.(string)
+			return ok && s == strings.ToUpper(s), nil
+		},
+	}
+}
+
+// arg returns the argument at the given position or with the given name
+func arg(args []interface{}, kwargs map[string]interface{}, i int, name string, def interface{}) interface{} {
+	if i < len(args) {
+		return args[i]
+	}
+	if v, ok := kwargs[name]; ok {
+		return v
+	}
+	return def
+}
+
+func length(v interface{}, _ []interface{}, _ map[string]interface{}) (interface{}, error) {
+	switch v := v.(type) {
+	case string:
+		return len([]rune(v)), nil
+	case []interface{}:
+		return len(v), nil
+	case map[string]interface{}:
+		return len(v), nil
+	case *undefined:
+		return 0, nil
+	}
+	return nil, fmt.Errorf("object of type %s has no length", typeName(v))
+}
+
+func defaultFilter(v interface{}, args []interface{}, kwargs map[string]interface{}) (interface{}, error) {
+	_, undef := v.(*undefined)
+	if undef || truthy(arg(args, kwargs, 1, "boolean", false)) && !truthy(v) {
+		return arg(args, kwargs, 0, "default_value", ""), nil
+	}
+	return v, nil
+}
+
+func selectAttr(v interface{}, args []interface{}, keep bool) (interface{}, error) {
+	items, err := toList(v)
+	if err != nil {
+		return nil, err
+	}
+	if len(args) == 0 {
+		return nil, fmt.Errorf("missing attribute name")
+	}
+	attr := toString(args[0])
+	res := []interface{}{}
+	for _, item := range items {
+		a := getAttr(item, attr)
+		ok := truthy(a)
+		if len(args) > 1 {
+			t, found := tests[toString(args[1])]
+			if !found {
+				return nil, fmt.Errorf("unknown test %s", toString(args[1]))
+			}
+			if ok, err = t(a, args[2:]); err != nil {
+				return nil, err
+			}
+		}
+		if ok == keep {
+			res = append(res, item)
+		}
+	}
+	return res, nil
+}
+
+func equalTest(v interface{}, args []interface{}) (bool, error) {
+	return equal(v, arg(args, nil, 0, "other", nil)), nil
+}
+
+func isIterable(v interface{}, _ []interface{}) (bool, error) {
+	switch v.(type) {
+	case string, []interface{}, map[string]interface{}:
+		return true, nil
+	}
+	return false, nil
+}
+
+func items(m map[string]interface{}) []interface{} {
+	res := make([]interface{}, 0, len(m))
+	for _, k := range sortedKeys(m) {
+		res = append(res, []interface{}{k, m[k]})
+	}
+	return res
+}
+
+func join(sep string, items []interface{}) (string, error) {
+	var sb strings.Builder
+	for i := range items {
+		if i > 0 {
+			sb.WriteString(sep)
+		}
+		sb.WriteString(toString(items[i]))
+		if sb.Len() > maxStringSize {
+			return "", errStringTooLarge
+		}
+	}
+	return sb.String(), nil
+}
+
+func strip(s string, chars interface{}, left, right bool) string {
+	cutset, ok := chars.(string)
+	if !ok {
+		if left {
+			s = strings.TrimLeftFunc(s, unicode.IsSpace)
+		}
+		if right {
+			s = strings.TrimRightFunc(s, unicode.IsSpace)
+		}
+		return s
+	}
+	if left {
+		s = strings.TrimLeft(s, cutset)
+	}
+	if right {
+		s = strings.TrimRight(s, cutset)
+	}
+	return s
+}
+
+func title(s string) string {
+	r := []rune(s)
+	start := true
+	for i, c := range r {
+		if unicode.IsLetter(c) {
+			if start {
+				r[i] = unicode.ToUpper(c)
+			} else {
+				r[i] = unicode.ToLower(c)
+			}
+			start = false
+		} else {
+			start = true
+		}
+	}
+	return string(r)
+}
+
+func capitalize(s string) string {
+	r := []rune(strings.ToLower(s))
+	if len(r) > 0 {
+		r[0] = unicode.ToUpper(r[0])
+	}
+	return string(r)
+}
+
+// method returns the method of a string or a dict with the given name
+func method(obj interface{}, name string) (function, bool) {
+	switch o := obj.(type) {
+	case string:
+		return stringMethod(o, name)
+	case map[string]interface{}:
+		return dictMethod(o, name)
+	}
+	return nil, false
+}
+
+func stringMethod(s string, name string) (function, bool) {
+	switch name {
+	case "strip", "lstrip", "rstrip":
+		return func(args []interface{}, kwargs map[string]interface{}) (interface{}, error) {
+			return strip(s, arg(args, kwargs, 0, "chars", nil), name != "rstrip", name != "lstrip"), nil
+		}, true
+	case "upper":
+		return func(_ []interface{}, _ map[string]interface{}) (interface{}, error) { return strings.ToUpper(s), nil }, true
+	case "lower":
+		return func(_ []interface{}, _ map[string]interface{}) (interface{}, error) { return strings.ToLower(s), nil }, true
+	case "title":
+		return func(_ []interface{}, _ map[string]interface{}) (interface{}, error) { return title(s), nil }, true
+	case "capitalize":
+		return func(_ []interface{}, _ map[string]interface{}) (interface{}, error) { return capitalize(s), nil }, true
+	case "startswith", "endswith":
+		return func(args []interface{}, kwargs map[string]interface{}) (interface{}, error) {
+			candidates := []interface{}{arg(args, kwargs, 0, "prefix", "")}
+			if list, ok := candidates[0].([]interface{}); ok {
+				candidates = list
+			}
+			for _, c := range candidates {
+				if name == "startswith" && strings.HasPrefix(s, toString(c)) || name == "endswith" && strings.HasSuffix(s, toString(c)) {
+					return true, nil
+				}
+			}
+			return false, nil
+		}, true
+	case "split":
+		return func(args []interface{}, kwargs map[string]interface{}) (interface{}, error) {
+			var parts []string
+			n, ok := toInt(arg(args, kwargs, 1, "maxsplit", nil))
+			if !ok || n < 0 {
+				n = -1
+			} else {
+				n++
+			}
+			if sep, ok := arg(args, kwargs, 0, "sep", nil).(string); ok {
+				parts = strings.SplitN(s, sep, n)
+			} else {
+				parts = strings.Fields(s)
+			}
+			if err := checkLength(len(parts)); err != nil {
+				return nil, err
+			}
+			res := make([]interface{}, len(parts))
+			for i := range parts {
+				res[i] = parts[i]
+			}
+			return res, nil
+		}, true
+	case "replace":
+		return func(args []interface{}, kwargs map[string]interface{}) (interface{}, error) {
+			n, ok := toInt(arg(args, kwargs, 2, "count", nil))
+			if !ok {
+				n = -1
+			}
+			return replace(s, toString(arg(args, kwargs, 0, "old", "")), toString(arg(args, kwargs, 1, "new", "")), n)
+		}, true
+	case "join":
+		return func(args []interface{}, kwargs map[string]interface{}) (interface{}, error) {
+			items, err := toList(arg(args, kwargs, 0, "iterable", nil))
+			if err != nil {
+				return nil, err
+			}
+			return join(s, items)
+		}, true
+	case "find":
+		return func(args []interface{}, kwargs map[string]interface{}) (interface{}, error) {
+			return strings.Index(s, toString(arg(args, kwargs, 0, "sub", ""))), nil
+		}, true
+	}
+	return nil, false
+}
+
+func dictMethod(m map[string]interface{}, name string) (function, bool) {
+	switch name {
+	case "items":
+		return func(_ []interface{}, _ map[string]interface{}) (interface{}, error) { return items(m), nil }, true
+	case "keys":
+		return func(_ []interface{}, _ map[string]interface{}) (interface{}, error) { return toList(m) }, true
+	case "values":
+		return func(_ []interface{}, _ map[string]interface{}) (interface{}, error) {
+			res := make([]interface{}, 0, len(m))
+			for _, k := range sortedKeys(m) {
+				res = append(res, m[k])
+			}
+			return res, nil
+		}, true
+	case "get":
+		return func(args []interface{}, kwargs map[string]interface{}) (interface{}, error) {
+			if v, ok := m[toString(arg(args, kwargs, 0, "key", ""))]; ok {
+				return v, nil
+			}
+			return arg(args, kwargs, 1, "default", nil), nil
+		}, true
+	}
+	return nil, false
+}
+
+// writeJSON serializes a value as Python's json.dumps does, which is what the tojson filter of transformers uses
+func writeJSON(sb *strings.Builder, v interface{}, indent, depth int) error {
+	if depth > maxDepth {
+		return errTooDeep
+	}
+	if sb.Len() > maxStringSize {
+		return errStringTooLarge
+	}
+	newline := func(depth int) error {
+		if indent <= 0 {
+			return nil
+		}
+		if indent > maxStringSize/(depth+1) {
+			return errStringTooLarge
+		}
+		pad, err := repeat(" ", indent*depth)
+		if err != nil {
+			return err
+		}
+		sb.WriteString("\n" + pad)
+		return nil
+	}
+	sep := ", "
+	if indent > 0 {
+		sep = ","
+	}
+
+	switch v := v.(type) {
+	case nil, *undefined:
+		sb.WriteString("null")
+	case bool:
+		if v {
+			sb.WriteString("true")
+		} else {
+			sb.WriteString("false")
+		}
+	case int:
+		sb.WriteString(strconv.Itoa(v))
+	case float64:
+		sb.WriteString(formatFloat(v))
+	case string:
+		// unlike json.Marshal, Python doesn't escape the HTML characters
+		var buf bytes.Buffer
+		enc := json.NewEncoder(&buf)
+		enc.SetEscapeHTML(false)
+		if err := enc.Encode(v); err != nil {
+			return err
+		}
+		sb.WriteString(strings.TrimSuffix(buf.String(), "\n"))
+	case []interface{}:
+		if len(v) == 0 {
+			sb.WriteString("[]")
+			return nil
+		}
+		sb.WriteString("[")
+		for i, item := range v {
+			if i > 0 {
+				sb.WriteString(sep)
+			}
+			if err := newline(depth + 1); err != nil {
+				return err
+			}
+			if err := writeJSON(sb, item, indent, depth+1); err != nil {
+				return err
+			}
+		}
+		if err := newline(depth); err != nil {
+			return err
+		}
+		sb.WriteString("]")
+	case map[string]interface{}:
+		if len(v) == 0 {
+			sb.WriteString("{}")
+			return nil
+		}
+		sb.WriteString("{")
+		for i, k := range sortedKeys(v) {
+			if i > 0 {
+				sb.WriteString(sep)
+			}
+			if err := newline(depth + 1); err != nil {
+				return err
+			}
+			if err := writeJSON(sb, k, indent, depth+1); err != nil {
+				return err
+			}
+			sb.WriteString(": ")
+			if err := writeJSON(sb, v[k], indent, depth+1); err != nil {
+				return err
+			}
+		}
+		if err := newline(depth); err != nil {
+			return err
+		}
+		sb.WriteString("}")
+	case *namespace:
+		return writeJSON(sb, v.vars, indent, depth)
+	default:
+		return fmt.Errorf("object of type %s is not JSON serializable", typeName(v))
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package jinja_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/go-skynet/LocalAI/pkg/jinja"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// The files of testdata hold the chat_template of the tokenizer_config.json of the models, unchanged.
+// The expected prompts are the ones rendered by transformers' apply_chat_template.
+var _ = Describe("Hugging Face chat templates", func() {
+	template := func(name string) *Template {
+		src, err := os.ReadFile(filepath.Join("testdata", name+".jinja"))
+		Expect(err).ToNot(HaveOccurred())
+		// Jinja drops the newline ending the template, that the files add to the JSON string
+		t, err := Parse(strings.TrimSuffix(string(src), "\n"))
+		Expect(err).ToNot(HaveOccurred())
+		return t
+	}
+
+	system := map[string]interface{}{"role": "system", "content": "You are a helpful assistant."}
+	conversation := []interface{}{
+		map[string]interface{}{"role": "user", "content": "Hello!"},
+		map[string]interface{}{"role": "assistant", "content": "Hi there."},
+		map[string]interface{}{"role": "user", "content": "How are you?"},
+	}
+	withSystem := append([]interface{}{system}, conversation...)
+
+	weather := map[string]interface{}{
+		"type": "function",
+		"function": map[string]interface{}{
+			"name":        "get_weather",
+			"description": "Get the weather of a city",
+			"parameters": map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{"city": map[string]interface{}{"type": "string"}},
+				"required":   []interface{}{"city"},
+			},
+		},
+	}
+	toolCall := []interface{}{
+		map[string]interface{}{"role": "user", "content": "What's the weather in Paris?"},
+		map[string]interface{}{"role": "assistant", "content": "", "tool_calls": []interface{}{
+			map[string]interface{}{"type": "function", "function": map[string]interface{}{
+				"name":      "get_weather",
+				"arguments": map[string]interface{}{"city": "Paris"},
+			}},
+		}},
+		map[string]interface{}{"role": "tool", "content": "sunny"},
+	}
+
+	// vars returns the variables of a prompt, followed by the extra ones given as key and value pairs
+	vars := func(messages []interface{}, addGenerationPrompt bool, extra ...interface{}) map[string]interface{} {
+		v := map[string]interface{}{
+			"messages":              messages,
+			"add_generation_prompt": addGenerationPrompt,
+		}
+		for i := 0; i+1 < len(extra); i += 2 {
+			v[extra[i].(string)] = extra[i+1]
+		}
+		return v
+	}
+
+	DescribeTable("renders the prompts of the models",
+		func(name string, vars map[string]interface{}, expected string) {
+			out, err := template(name).Execute(vars)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(Equal(expected))
+		},
+		Entry("Llama 3", "llama-3-instruct", vars(withSystem, true, "bos_token", "<|begin_of_text|>"),
+			"<|begin_of_text|><|start_header_id|>system<|end_header_id|>\n\nYou are a helpful assistant.<|eot_id|>"+
+				"<|start_header_id|>user<|end_header_id|>\n\nHello!<|eot_id|>"+
+				"<|start_header_id|>assistant<|end_header_id|>\n\nHi there.<|eot_id|>"+
+				"<|start_header_id|>user<|end_header_id|>\n\nHow are you?<|eot_id|>"+
+				"<|start_header_id|>assistant<|end_header_id|>\n\n"),
+		Entry("Llama 3.1", "llama-3.1-instruct", vars(withSystem, true, "bos_token", "<|begin_of_text|>"),
+			"<|begin_of_text|><|start_header_id|>system<|end_header_id|>\n\n"+
+				"Cutting Knowledge Date: December 2023\nToday Date: 26 Jul 2024\n\nYou are a helpful assistant.<|eot_id|>"+
+				"<|start_header_id|>user<|end_header_id|>\n\nHello!<|eot_id|>"+
+				"<|start_header_id|>assistant<|end_header_id|>\n\nHi there.<|eot_id|>"+
+				"<|start_header_id|>user<|end_header_id|>\n\nHow are you?<|eot_id|>"+
+				"<|start_header_id|>assistant<|end_header_id|>\n\n"),
+		Entry("Llama 3.1 with tools", "llama-3.1-instruct", vars(toolCall, true, "bos_token", "<|begin_of_text|>", "tools", []interface{}{weather}),
+			"<|begin_of_text|><|start_header_id|>system<|end_header_id|>\n\n"+
+				"Environment: ipython\nCutting Knowledge Date: December 2023\nToday Date: 26 Jul 2024\n\n<|eot_id|>"+
+				"<|start_header_id|>user<|end_header_id|>\n\n"+
+				"Given the following functions, please respond with a JSON for a function call with its proper arguments that best answers the given prompt.\n\n"+
+				`Respond in the format {"name": function name, "parameters": dictionary of argument name and its value}.Do not use variables.`+"\n\n"+
+				`{
+    "function": {
+        "description": "Get the weather of a city",
+        "name": "get_weather",
+        "parameters": {
+            "properties": {
+                "city": {
+                    "type": "string"
+                }
+            },
+            "required": [
+                "city"
+            ],
+            "type": "object"
+        }
+    },
+    "type": "function"
+}`+"\n\nWhat's the weather in Paris?<|eot_id|>"+
+				`<|start_header_id|>assistant<|end_header_id|>`+"\n\n"+`{"name": "get_weather", "parameters": {"city": "Paris"}}<|eot_id|>`+
+				"<|start_header_id|>ipython<|end_header_id|>\n\n\"sunny\"<|eot_id|>"+
+				"<|start_header_id|>assistant<|end_header_id|>\n\n"),
+		Entry("Gemma", "gemma-it", vars(conversation, true, "bos_token", "<bos>"),
+			"<bos><start_of_turn>user\nHello!<end_of_turn>\n<start_of_turn>model\nHi there.<end_of_turn>\n"+
+				"<start_of_turn>user\nHow are you?<end_of_turn>\n<start_of_turn>model\n"),
+		Entry("Phi-3", "phi-3-mini-instruct", vars(withSystem, false, "eos_token", "<|endoftext|>"),
+			"<|system|>\nYou are a helpful assistant.<|end|>\n<|user|>\nHello!<|end|>\n<|assistant|>\nHi there.<|end|>\n"+
+				"<|user|>\nHow are you?<|end|>\n<|endoftext|>"),
+		Entry("Qwen2, with its default system prompt", "qwen2-instruct", vars(conversation, true),
+			"<|im_start|>system\nYou are a helpful assistant.<|im_end|>\n<|im_start|>user\nHello!<|im_end|>\n"+
+				"<|im_start|>assistant\nHi there.<|im_end|>\n<|im_start|>user\nHow are you?<|im_end|>\n<|im_start|>assistant\n"),
+		Entry("Qwen2.5", "qwen2.5-instruct", vars(conversation, true),
+			"<|im_start|>system\nYou are Qwen, created by Alibaba Cloud. You are a helpful assistant.<|im_end|>\n"+
+				"<|im_start|>user\nHello!<|im_end|>\n<|im_start|>assistant\nHi there.<|im_end|>\n"+
+				"<|im_start|>user\nHow are you?<|im_end|>\n<|im_start|>assistant\n"),
+		Entry("Qwen2.5 with tools", "qwen2.5-instruct", vars(toolCall, true, "tools", []interface{}{weather}),
+			"<|im_start|>system\nYou are Qwen, created by Alibaba Cloud. You are a helpful assistant.\n\n# Tools\n\n"+
+				"You may call one or more functions to assist with the user query.\n\n"+
+				"You are provided with function signatures within <tools></tools> XML tags:\n<tools>\n"+
+				`{"function": {"description": "Get the weather of a city", "name": "get_weather", "parameters": {"properties": {"city": {"type": "string"}}, "required": ["city"], "type": "object"}}, "type": "function"}`+
+				"\n</tools>\n\nFor each function call, return a json object with function name and arguments within <tool_call></tool_call> XML tags:\n"+
+				"<tool_call>\n{\"name\": <function-name>, \"arguments\": <args-json-object>}\n</tool_call><|im_end|>\n"+
+				"<|im_start|>user\nWhat's the weather in Paris?<|im_end|>\n"+
+				"<|im_start|>assistant\n<tool_call>\n{\"name\": \"get_weather\", \"arguments\": {\"city\": \"Paris\"}}\n</tool_call><|im_end|>\n"+
+				"<|im_start|>user\n<tool_response>\nsunny\n</tool_response><|im_end|>\n<|im_start|>assistant\n"),
+		Entry("Mixtral, with a system prompt", "mixtral-instruct", vars(withSystem, true, "bos_token", "<s>", "eos_token", "</s>"),
+			"<s> [INST] You are a helpful assistant.\n\nHello! [/INST] Hi there.</s> [INST] How are you? [/INST]"),
+		Entry("Mixtral, without a system prompt", "mixtral-instruct", vars(conversation, true, "bos_token", "<s>", "eos_token", "</s>"),
+			"<s> [INST] Hello! [/INST] Hi there.</s> [INST] How are you? [/INST]"),
+	)
+
+	DescribeTable("returns the exceptions raised by the template",
+		func(name string, messages []interface{}, expected string) {
+			_, err := template(name).Execute(vars(messages, true))
+			Expect(err).To(Equal(&RaisedError{Message: expected}))
+		},
+		Entry("Gemma with a system prompt", "gemma-it", withSystem, "System role not supported"),
+		Entry("Mixtral starting with the assistant", "mixtral-instruct", conversation[1:],
+			"After the optional system message, conversation roles must alternate user/assistant/user/assistant/..."),
+	)
+})
@@ -0,0 +1,1029 @@
+package jinja
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// undefined is the value of the variables and attributes that don't exist
+type undefined struct {
+	name string
+}
+
+// namespace is the object returned by namespace(), whose attributes can be assigned from inner scopes
+type namespace struct {
+	vars map[string]interface{}
+}
+
+// function is a callable value: a builtin function or a bound method
+type function func(args []interface{}, kwargs map[string]interface{}) (interface{}, error)
+
+type scope struct {
+	vars   map[string]interface{}
+	parent *scope
+}
+
+func (s *scope) lookup(name string) (interface{}, bool) {
+	for ; s != nil; s = s.parent {
+		if v, ok := s.vars[name]; ok {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+// renderer holds the state of a rendering, to enforce the limits
+type renderer struct {
+	iterations int
+	// depth is the number of macro calls in progress
+	depth int
+}
+
+func (r *renderer) render(sb *strings.Builder, nodes []node, s *scope) error {
+	for _, n := range nodes {
+		switch n := n.(type) {
+		case textNode:
+			sb.WriteString(string(n))
+			if sb.Len() > maxStringSize {
+				return errStringTooLarge
+			}
+		case *outputNode:
+			v, err := eval(n.e, s)
+			if err != nil {
+				return err
+			}
+			sb.WriteString(toString(v))
+			if sb.Len() > maxStringSize {
+				return errStringTooLarge
+			}
+		case *ifNode:
+			done := false
+			for i, cond := range n.conds {
+				v, err := eval(cond, s)
+				if err != nil {
+					return err
+				}
+				if truthy(v) {
+					if err := r.render(sb, n.bodies[i], s); err != nil {
+						return err
+					}
+					done = true
+					break
+				}
+			}
+			if !done {
+				if err := r.render(sb, n.els, s); err != nil {
+					return err
+				}
+			}
+		case *forNode:
+			if err := r.renderFor(sb, n, s); err != nil {
+				return err
+			}
+		case *setNode:
+			var v interface{}
+			if n.body != nil {
+				var body strings.Builder
+				if err := r.render(&body, n.body, s); err != nil {
+					return err
+				}
+				v = body.String()
+			} else {
+				var err error
+				if v, err = eval(n.e, s); err != nil {
+					return err
+				}
+			}
+			if n.attr == "" {
+				s.vars[n.name] = v
+				continue
+			}
+			obj, _ := s.lookup(n.name)
+			ns, ok := obj.(*namespace)
+			if !ok {
+				return fmt.Errorf("cannot assign attribute %s of %s: not a namespace", n.attr, n.name)
+			}
+			ns.vars[n.attr] = v
+		case *macroNode:
+			s.vars[n.name] = r.macro(n, s)
+		}
+	}
+	return nil
+}
+
+// macro returns the function calling a macro, which renders the body in a scope of its own,
+// child of the scope the macro is defined in, and returns the output
+func (r *renderer) macro(n *macroNode, s *scope) function {
+	return func(args []interface{}, kwargs map[string]interface{}) (interface{}, error) {
+		if len(args) > len(n.params) {
+			return nil, fmt.Errorf("macro %s takes %d arguments, got %d", n.name, len(n.params), len(args))
+		}
+		inner := &scope{vars: map[string]interface{}{}, parent: s}
+		for i, p := range n.params {
+			if i < len(args) {
+				inner.vars[p] = args[i]
+			} else if v, ok := kwargs[p]; ok {
+				inner.vars[p] = v
+			} else if d, ok := n.defaults[p]; ok {
+				v, err := eval(d, s)
+				if err != nil {
+					return nil, err
+				}
+				inner.vars[p] = v
+			} else {
+				inner.vars[p] = &undefined{name: p}
+			}
+		}
+		for k := range kwargs {
+			if _, ok := inner.vars[k]; !ok {
+				return nil, fmt.Errorf("macro %s has no argument %s", n.name, k)
+			}
+		}
+
+		if r.depth++; r.depth > maxDepth {
+			r.depth--
+			return nil, errTooDeep
+		}
+		defer func() { r.depth-- }()
+		var sb strings.Builder
+		if err := r.render(&sb, n.body, inner); err != nil {
+			return nil, err
+		}
+		return sb.String(), nil
+	}
+}
+
+func (r *renderer) renderFor(sb *strings.Builder, n *forNode, s *scope) error {
+	v, err := eval(n.iter, s)
+	if err != nil {
+		return err
+	}
+	items, err := toList(v)
+	if err != nil {
+		return err
+	}
+
+	// the loop variables are bound in a scope of their own, so that the assignments in the body don't leak out
+	bind := func(item interface{}) (*scope, error) {
+		if r.iterations++; r.iterations > maxIterations {
+			return nil, errTooManyLoops
+		}
+		inner := &scope{vars: map[string]interface{}{}, parent: s}
+		if len(n.targets) == 1 {
+			inner.vars[n.targets[0]] = item
+			return inner, nil
+		}
+		values, ok := item.([]interface{})
+		if !ok || len(values) != len(n.targets) {
+			return nil, fmt.Errorf("cannot unpack %s in %d values", toString(item), len(n.targets))
+		}
+		for i, t := range n.targets {
+			inner.vars[t] = values[i]
+		}
+		return inner, nil
+	}
+
+	if n.filter != nil {
+		filtered := []interface{}{}
+		for _, item := range items {
+			inner, err := bind(item)
+			if err != nil {
+				return err
+			}
+			ok, err := eval(n.filter, inner)
+			if err != nil {
+				return err
+			}
+			if truthy(ok) {
+				filtered = append(filtered, item)
+			}
+		}
+		items = filtered
+	}
+
+	if len(items) == 0 {
+		return r.render(sb, n.els, s)
+	}
+	for i, item := range items {
+		inner, err := bind(item)
+		if err != nil {
+			return err
+		}
+		loop := map[string]interface{}{
+			"index":     i + 1,
+			"index0":    i,
+			"revindex":  len(items) - i,
+			"revindex0": len(items) - i - 1,
+			"first":     i == 0,
+			"last":      i == len(items)-1,
+			"length":    len(items),
+		}
+		if i > 0 {
+			loop["previtem"] = items[i-1]
+		}
+		if i < len(items)-1 {
+			loop["nextitem"] = items[i+1]
+		}
+		inner.vars["loop"] = loop
+		if err := r.render(sb, n.body, inner); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func eval(e expr, s *scope) (interface{}, error) {
+	switch e := e.(type) {
+	case *literal:
+		return e.v, nil
+	case *nameExpr:
+		if v, ok := s.lookup(e.name); ok {
+			return v, nil
+		}
+		if f, ok := builtins[e.name]; ok {
+			return f, nil
+		}
+		return &undefined{name: e.name}, nil
+	case *attrExpr:
+		obj, err := eval(e.obj, s)
+		if err != nil {
+			return nil, err
+		}
+		return getAttr(obj, e.name), nil
+	case *indexExpr:
+		obj, err := eval(e.obj, s)
+		if err != nil {
+			return nil, err
+		}
+		idx, err := eval(e.index, s)
+		if err != nil {
+			return nil, err
+		}
+		return getItem(obj, idx), nil
+	case *sliceExpr:
+		return evalSlice(e, s)
+	case *callExpr:
+		return evalCall(e, s)
+	case *filterExpr:
+		v, err := eval(e.e, s)
+		if err != nil {
+			return nil, err
+		}
+		args, kwargs, err := evalArgs(e.args, e.kwargs, s)
+		if err != nil {
+			return nil, err
+		}
+		f, ok := filters[e.name]
+		if !ok {
+			return nil, fmt.Errorf("unknown filter %s", e.name)
+		}
+		return f(v, args, kwargs)
+	case *testExpr:
+		v, err := eval(e.e, s)
+		if err != nil {
+			return nil, err
+		}
+		args, _, err := evalArgs(e.args, nil, s)
+		if err != nil {
+			return nil, err
+		}
+		t, ok := tests[e.name]
+		if !ok {
+			return nil, fmt.Errorf("unknown test %s", e.name)
+		}
+		res, err := t(v, args)
+		return res != e.negate, err
+	case *unaryExpr:
+		v, err := eval(e.e, s)
+		if err != nil {
+			return nil, err
+		}
+		if e.op == "not" {
+			return !truthy(v), nil
+		}
+		switch n := v.(type) {
+		case int:
+			return -n, nil
+		case float64:
+			return -n, nil
+		}
+		return nil, fmt.Errorf("bad operand type for unary -: %s", typeName(v))
+	case *binaryExpr:
+		return evalBinary(e, s)
+	case *condExpr:
+		cond, err := eval(e.cond, s)
+		if err != nil {
+			return nil, err
+		}
+		if truthy(cond) {
+			return eval(e.then, s)
+		}
+		if e.els == nil {
+			return &undefined{}, nil
+		}
+		return eval(e.els, s)
+	case *listExpr:
+		items := make([]interface{}, 0, len(e.items))
+		for _, item := range e.items {
+			v, err := eval(item, s)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, v)
+		}
+		return items, nil
+	case *dictExpr:
+		d := make(map[string]interface{}, len(e.keys))
+		for i := range e.keys {
+			k, err := eval(e.keys[i], s)
+			if err != nil {
+				return nil, err
+			}
+			v, err := eval(e.values[i], s)
+			if err != nil {
+				return nil, err
+			}
+			d[toString(k)] = v
+		}
+		return d, nil
+	}
+	return nil, fmt.Errorf("unknown expression %T", e)
+}
+
+func evalArgs(exprs []expr, kwexprs map[string]expr, s *scope) ([]interface{}, map[string]interface{}, error) {
+	args := make([]interface{}, 0, len(exprs))
+	for _, e := range exprs {
+		v, err := eval(e, s)
+		if err != nil {
+			return nil, nil, err
+		}
+		args = append(args, v)
+	}
+	kwargs := make(map[string]interface{}, len(kwexprs))
+	for k, e := range kwexprs {
+		v, err := eval(e, s)
+		if err != nil {
+			return nil, nil, err
+		}
+		kwargs[k] = v
+	}
+	return args, kwargs, nil
+}
+
+func evalCall(e *callExpr, s *scope) (interface{}, error) {
+	args, kwargs, err := evalArgs(e.args, e.kwargs, s)
+	if err != nil {
+		return nil, err
+	}
+	var fn interface{}
+	if attr, ok := e.fn.(*attrExpr); ok {
+		obj, err := eval(attr.obj, s)
+		if err != nil {
+			return nil, err
+		}
+		if m, ok := method(obj, attr.name); ok {
+			return m(args, kwargs)
+		}
+		fn = getAttr(obj, attr.name)
+	} else if fn, err = eval(e.fn, s); err != nil {
+		return nil, err
+	}
+	f, ok := fn.(function)
+	if !ok {
+		return nil, fmt.Errorf("%s is not callable", describe(fn))
+	}
+	return f(args, kwargs)
+}
+
+func evalBinary(e *binaryExpr, s *scope) (interface{}, error) {
+	l, err := eval(e.l, s)
+	if err != nil {
+		return nil, err
+	}
+	// and/or return one of their operands, as in Python
+	switch e.op {
+	case "and":
+		if !truthy(l) {
+			return l, nil
+		}
+		return eval(e.r, s)
+	case "or":
+		if truthy(l) {
+			return l, nil
+		}
+		return eval(e.r, s)
+	}
+	r, err := eval(e.r, s)
+	if err != nil {
+		return nil, err
+	}
+
+	switch e.op {
+	case "==":
+		return equal(l, r), nil
+	case "!=":
+		return !equal(l, r), nil
+	case "in", "not in":
+		in, err := contains(r, l)
+		return in != (e.op == "not in"), err
+	case "~":
+		return concat(toString(l), toString(r))
+	case "<", ">", "<=", ">=":
+		c, err := compare(l, r)
+		if err != nil {
+			return nil, fmt.Errorf("'%s' not supported between %s and %s", e.op, typeName(l), typeName(r))
+		}
+		switch e.op {
+		case "<":
+			return c < 0, nil
+		case ">":
+			return c > 0, nil
+		case "<=":
+			return c <= 0, nil
+		}
+		return c >= 0, nil
+	case "+":
+		switch l := l.(type) {
+		case string:
+			if r, ok := r.(string); ok {
+				return concat(l, r)
+			}
+		case []interface{}:
+			if r, ok := r.([]interface{}); ok {
+				if err := checkLength(len(l) + len(r)); err != nil {
+					return nil, err
+				}
+				return append(append([]interface{}{}, l...), r...), nil
+			}
+		}
+	case "*":
+		if str, ok := l.(string); ok {
+			n, _ := r.(int)
+			return repeat(str, n)
+		}
+	}
+	return arithmetic(e.op, l, r)
+}
+
+func arithmetic(op string, l, r interface{}) (interface{}, error) {
+	li, lInt := toInt(l)
+	ri, rInt := toInt(r)
+	lf, lNum := toFloat(l)
+	rf, rNum := toFloat(r)
+	if !lNum || !rNum {
+		return nil, fmt.Errorf("unsupported operand types for %s: %s and %s", op, typeName(l), typeName(r))
+	}
+	if (op == "/" || op == "//" || op == "%") && rf == 0 {
+		return nil, fmt.Errorf("division by zero")
+	}
+	ints := lInt && rInt
+	switch op {
+	case "+":
+		if ints {
+			return li + ri, nil
+		}
+		return lf + rf, nil
+	case "-":
+		if ints {
+			return li - ri, nil
+		}
+		return lf - rf, nil
+	case "*":
+		if ints {
+			return li * ri, nil
+		}
+		return lf * rf, nil
+	case "/":
+		return lf / rf, nil
+	case "//":
+		if ints {
+			return int(math.Floor(float64(li) / float64(ri))), nil
+		}
+		return math.Floor(lf / rf), nil
+	case "%":
+		if ints {
+			// the result has the sign of the divisor, as in Python
+			return ((li % ri) + ri) % ri, nil
+		}
+		return lf - rf*math.Floor(lf/rf), nil
+	case "**":
+		if ints && ri >= 0 {
+			return int(math.Pow(float64(li), float64(ri))), nil
+		}
+		return math.Pow(lf, rf), nil
+	}
+	return nil, fmt.Errorf("unknown operator %s", op)
+}
+
+func evalSlice(e *sliceExpr, s *scope) (interface{}, error) {
+	obj, err := eval(e.obj, s)
+	if err != nil {
+		return nil, err
+	}
+	bound := func(e expr) (*int, error) {
+		if e == nil {
+			return nil, nil
+		}
+		v, err := eval(e, s)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := v.(*undefined); ok || v == nil {
+			return nil, nil
+		}
+		i, ok := toInt(v)
+		if !ok {
+			return nil, fmt.Errorf("slice indices must be integers, not %s", typeName(v))
+		}
+		return &i, nil
+	}
+	start, err := bound(e.start)
+	if err != nil {
+		return nil, err
+	}
+	stop, err := bound(e.stop)
+	if err != nil {
+		return nil, err
+	}
+	step, err := bound(e.step)
+	if err != nil {
+		return nil, err
+	}
+
+	if str, ok := obj.(string); ok {
+		runes := []rune(str)
+		var sb strings.Builder
+		slice(len(runes), start, stop, step, func(i int) {
+			sb.WriteRune(runes[i])
+		})
+		return sb.String(), nil
+	}
+	items, err := toList(obj)
+	if err != nil {
+		return nil, err
+	}
+	res := []interface{}{}
+	slice(len(items), start, stop, step, func(i int) {
+		res = append(res, items[i])
+	})
+	return res, nil
+}
+
+// slice calls fn with the indices selected by the Python slicing semantics, in a sequence of length n
+func slice(n int, start, stop, step *int, fn func(i int)) {
+	st := 1
+	if step != nil && *step != 0 {
+		st = *step
+	}
+	norm := func(i *int, def int) int {
+		if i == nil {
+			return def
+		}
+		v := *i
+		if v < 0 {
+			v += n
+		}
+		lo, hi := 0, n
+		if st < 0 {
+			lo, hi = -1, n-1
+		}
+		if v < lo {
+			return lo
+		}
+		if v > hi {
+			return hi
+		}
+		return v
+	}
+	if st > 0 {
+		for i := norm(start, 0); i < norm(stop, n); i += st {
+			fn(i)
+		}
+	} else {
+		for i := norm(start, n-1); i > norm(stop, -1); i += st {
+			fn(i)
+		}
+	}
+}
+
+func getAttr(obj interface{}, name string) interface{} {
+	switch o := obj.(type) {
+	case map[string]interface{}:
+		if v, ok := o[name]; ok {
+			return v
+		}
+	case *namespace:
+		if v, ok := o.vars[name]; ok {
+			return v
+		}
+	case []interface{}:
+		if i, err := strconv.Atoi(name); err == nil {
+			return getItem(o, i)
+		}
+	}
+	if m, ok := method(obj, name); ok {
+		return m
+	}
+	return &undefined{name: name}
+}
+
+func getItem(obj interface{}, key interface{}) interface{} {
+	switch o := obj.(type) {
+	case map[string]interface{}:
+		if k, ok := key.(string); ok {
+			if v, ok := o[k]; ok {
+				return v
+			}
+		}
+	case *namespace:
+		if k, ok := key.(string); ok {
+			if v, ok := o.vars[k]; ok {
+				return v
+			}
+		}
+	case []interface{}:
+		if i, ok := toInt(key); ok {
+			if i < 0 {
+				i += len(o)
+			}
+			if i >= 0 && i < len(o) {
+				return o[i]
+			}
+		}
+	case string:
+		if i, ok := toInt(key); ok {
+			r := []rune(o)
+			if i < 0 {
+				i += len(r)
+			}
+			if i >= 0 && i < len(r) {
+				return string(r[i])
+			}
+		}
+	}
+	return &undefined{name: toString(key)}
+}
+
+// normalize converts the values passed to the template to the types the evaluator works with
+func normalize(v interface{}) interface{} {
+	switch v := v.(type) {
+	case nil, string, bool, int, float64, *namespace, function:
+		return v
+	case []interface{}:
+		res := make([]interface{}, len(v))
+		for i := range v {
+			res[i] = normalize(v[i])
+		}
+		return res
+	case map[string]interface{}:
+		res := make(map[string]interface{}, len(v))
+		for k := range v {
+			res[k] = normalize(v[k])
+		}
+		return res
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return int(rv.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int(rv.Uint())
+	case reflect.Float32, reflect.Float64:
+		return rv.Float()
+	case reflect.String:
+		return rv.String()
+	case reflect.Bool:
+		return rv.Bool()
+	case reflect.Pointer, reflect.Interface:
+		if rv.IsNil() {
+			return nil
+		}
+		return normalize(rv.Elem().Interface())
+	case reflect.Slice, reflect.Array:
+		if rv.Kind() == reflect.Slice && rv.IsNil() {
+			return nil
+		}
+		res := make([]interface{}, rv.Len())
+		for i := range res {
+			res[i] = normalize(rv.Index(i).Interface())
+		}
+		return res
+	case reflect.Map:
+		if rv.Type().Key().Kind() == reflect.String {
+			res := make(map[string]interface{}, rv.Len())
+			iter := rv.MapRange()
+			for iter.Next() {
+				res[iter.Key().String()] = normalize(iter.Value().Interface())
+			}
+			return res
+		}
+	}
+
+	// structs are seen as they are serialized in JSON, honoring the field tags
+	b, err := json.Marshal(v)
+	if err != nil {
+		return toString(v)
+	}
+	var res interface{}
+	if err := json.Unmarshal(b, &res); err != nil {
+		return toString(v)
+	}
+	return fromJSON(res)
+}
+
+// fromJSON converts the numbers decoded from JSON to integers when they have no fractional part
+func fromJSON(v interface{}) interface{} {
+	switch v := v.(type) {
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) < 1<<53 {
+			return int(v)
+		}
+	case []interface{}:
+		for i := range v {
+			v[i] = fromJSON(v[i])
+		}
+	case map[string]interface{}:
+		for k := range v {
+			v[k] = fromJSON(v[k])
+		}
+	}
+	return v
+}
+
+func truthy(v interface{}) bool {
+	switch v := v.(type) {
+	case nil, *undefined:
+		return false
+	case bool:
+		return v
+	case int:
+		return v != 0
+	case float64:
+		return v != 0
+	case string:
+		return v != ""
+	case []interface{}:
+		return len(v) > 0
+	case map[string]interface{}:
+		return len(v) > 0
+	}
+	return true
+}
+
+func toInt(v interface{}) (int, bool) {
+	switch v := v.(type) {
+	case int:
+		return v, true
+	case bool:
+		if v {
+			return 1, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	if f, ok := v.(float64); ok {
+		return f, true
+	}
+	i, ok := toInt(v)
+	return float64(i), ok
+}
+
+func equal(a, b interface{}) bool {
+	if af, ok := toFloat(a); ok {
+		if bf, ok := toFloat(b); ok {
+			return af == bf
+		}
+		return false
+	}
+	_, aUndef := a.(*undefined)
+	_, bUndef := b.(*undefined)
+	if aUndef || bUndef {
+		return aUndef && bUndef
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func compare(a, b interface{}) (int, error) {
+	if af, ok := toFloat(a); ok {
+		if bf, ok := toFloat(b); ok {
+			switch {
+			case af < bf:
+				return -1, nil
+			case af > bf:
+				return 1, nil
+			}
+			return 0, nil
+		}
+	}
+	as, aok := a.(string)
+	bs, bok := b.(string)
+	if aok && bok {
+		return strings.Compare(as, bs), nil
+	}
+	return 0, fmt.Errorf("cannot compare %s and %s", typeName(a), typeName(b))
+}
+
+func contains(container, item interface{}) (bool, error) {
+	switch c := container.(type) {
+	case string:
+		s, ok := item.(string)
+		if !ok {
+			return false, fmt.Errorf("'in <string>' requires string as left operand, not %s", typeName(item))
+		}
+		return strings.Contains(c, s), nil
+	case []interface{}:
+		for _, v := range c {
+			if equal(v, item) {
+				return true, nil
+			}
+		}
+		return false, nil
+	case map[string]interface{}:
+		k, ok := item.(string)
+		if !ok {
+			return false, nil
+		}
+		_, found := c[k]
+		return found, nil
+	case *namespace:
+		k, _ := item.(string)
+		_, found := c.vars[k]
+		return found, nil
+	case *undefined:
+		return false, nil
+	}
+	return false, fmt.Errorf("argument of type %s is not iterable", typeName(container))
+}
+
+// toList returns the items iterated by a for loop: the elements of a list, the keys of a dictionary, the characters of a string
+func toList(v interface{}) ([]interface{}, error) {
+	switch v := v.(type) {
+	case []interface{}:
+		return v, nil
+	case map[string]interface{}:
+		keys := make([]interface{}, 0, len(v))
+		for _, k := range sortedKeys(v) {
+			keys = append(keys, k)
+		}
+		return keys, nil
+	case string:
+		if err := checkLength(utf8.RuneCountInString(v)); err != nil {
+			return nil, err
+		}
+		chars := []interface{}{}
+		for _, r := range v {
+			chars = append(chars, string(r))
+		}
+		return chars, nil
+	case *undefined:
+		return []interface{}{}, nil
+	}
+	return nil, fmt.Errorf("%s is not iterable", typeName(v))
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// toString converts a value to a string as Python's str() does
+func toString(v interface{}) string {
+	switch v := v.(type) {
+	case *undefined:
+		return ""
+	case string:
+		return v
+	}
+	return repr(v)
+}
+
+// repr formats a value as Python's repr() does
+func repr(v interface{}) string {
+	var sb strings.Builder
+	writeRepr(&sb, v, 0)
+	return sb.String()
+}
+
+// writeRepr elides the values nested too deeply, as Python does for the recursive ones,
+// and stops past the size limit: the string is then too large to be used anyway
+func writeRepr(sb *strings.Builder, v interface{}, depth int) {
+	switch v := v.(type) {
+	case nil:
+		sb.WriteString("None")
+	case *undefined:
+	case bool:
+		if v {
+			sb.WriteString("True")
+		} else {
+			sb.WriteString("False")
+		}
+	case int:
+		sb.WriteString(strconv.Itoa(v))
+	case float64:
+		sb.WriteString(formatFloat(v))
+	case string:
+		sb.WriteString("'" + strings.ReplaceAll(strings.ReplaceAll(v, `\`, `\\`), "'", `\'`) + "'")
+	case []interface{}:
+		if depth >= maxDepth {
+			sb.WriteString("[...]")
+			return
+		}
+		sb.WriteString("[")
+		for i := range v {
+			if sb.Len() > maxStringSize {
+				return
+			}
+			if i > 0 {
+				sb.WriteString(", ")
+			}
+			writeRepr(sb, v[i], depth+1)
+		}
+		sb.WriteString("]")
+	case map[string]interface{}:
+		if depth >= maxDepth {
+			sb.WriteString("{...}")
+			return
+		}
+		sb.WriteString("{")
+		for i, k := range sortedKeys(v) {
+			if sb.Len() > maxStringSize {
+				return
+			}
+			if i > 0 {
+				sb.WriteString(", ")
+			}
+			writeRepr(sb, k, depth+1)
+			sb.WriteString(": ")
+			writeRepr(sb, v[k], depth+1)
+		}
+		sb.WriteString("}")
+	case *namespace:
+		sb.WriteString("<Namespace ")
+		writeRepr(sb, v.vars, depth)
+		sb.WriteString(">")
+	case function:
+		sb.WriteString("<function>")
+	default:
+		sb.WriteString(fmt.Sprint(v))
+	}
+}
+
+func formatFloat(f float64) string {
+	if math.IsInf(f, 0) {
+		if f > 0 {
+			return "inf"
+		}
+		return "-inf"
+	}
+	if f == math.Trunc(f) && math.Abs(f) < 1e16 {
+		return strconv.FormatFloat(f, 'f', 1, 64)
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+func typeName(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "NoneType"
+	case *undefined:
+		return "Undefined"
+	case bool:
+		return "bool"
+	case int:
+		return "int"
+	case float64:
+		return "float"
+	case string:
+		return "str"
+	case []interface{}:
+		return "list"
+	case map[string]interface{}:
+		return "dict"
+	case *namespace:
+		return "Namespace"
+	case function:
+		return "function"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+func describe(v interface{}) string {
+	if u, ok := v.(*undefined); ok {
+		return fmt.Sprintf("undefined '%s'", u.name)
+	}
+	return typeName(v)
+}
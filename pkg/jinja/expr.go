@@ -0,0 +1,649 @@
+package jinja
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type expr interface{}
+
+type literal struct {
+	v interface{}
+}
+
+type nameExpr struct {
+	name string
+}
+
+type attrExpr struct {
+	obj  expr
+	name string
+}
+
+type indexExpr struct {
+	obj, index expr
+}
+
+type sliceExpr struct {
+	obj, start, stop, step expr
+}
+
+type callExpr struct {
+	fn     expr
+	args   []expr
+	kwargs map[string]expr
+}
+
+type filterExpr struct {
+	e      expr
+	name   string
+	args   []expr
+	kwargs map[string]expr
+}
+
+type testExpr struct {
+	e      expr
+	name   string
+	negate bool
+	args   []expr
+}
+
+type unaryExpr struct {
+	op string
+	e  expr
+}
+
+type binaryExpr struct {
+	op   string
+	l, r expr
+}
+
+type condExpr struct {
+	cond, then, els expr
+}
+
+type listExpr struct {
+	items []expr
+}
+
+type dictExpr struct {
+	keys, values []expr
+}
+
+type tokKind int
+
+const (
+	endTok tokKind = iota
+	nameTok
+	stringTok
+	intTok
+	floatTok
+	opTok
+)
+
+type etok struct {
+	kind tokKind
+	val  string
+}
+
+// operators, longest first
+var operators = []string{"**", "//", "==", "!=", "<=", ">=", "+", "-", "*", "/", "%", "~", "<", ">", "=", "(", ")", "[", "]", "{", "}", ".", ",", ":", "|"}
+
+func lex(s string, line int) ([]etok, error) {
+	toks := []etok{}
+	i := 0
+	for i < len(s) {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n':
+			i++
+		case c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z':
+			j := i + 1
+			for j < len(s) && (s[j] == '_' || s[j] >= 'a' && s[j] <= 'z' || s[j] >= 'A' && s[j] <= 'Z' || s[j] >= '0' && s[j] <= '9') {
+				j++
+			}
+			toks = append(toks, etok{kind: nameTok, val: s[i:j]})
+			i = j
+		case c >= '0' && c <= '9':
+			j, kind := i, intTok
+			for j < len(s) && (s[j] >= '0' && s[j] <= '9' || s[j] == '_' || s[j] == '.' && kind == intTok && j+1 < len(s) && s[j+1] >= '0' && s[j+1] <= '9') {
+				if s[j] == '.' {
+					kind = floatTok
+				}
+				j++
+			}
+			toks = append(toks, etok{kind: kind, val: strings.ReplaceAll(s[i:j], "_", "")})
+			i = j
+		case c == '\'' || c == '"':
+			str, n, err := unquote(s[i:])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			toks = append(toks, etok{kind: stringTok, val: str})
+			i += n
+		default:
+			found := false
+			for _, op := range operators {
+				if strings.HasPrefix(s[i:], op) {
+					toks = append(toks, etok{kind: opTok, val: op})
+					i += len(op)
+					found = true
+					break
+				}
+			}
+			if !found {
+				return nil, fmt.Errorf("line %d: unexpected character %q", line, c)
+			}
+		}
+	}
+	return toks, nil
+}
+
+// unquote decodes the string literal at the start of s, returning it with the length of the literal
+func unquote(s string) (string, int, error) {
+	quote := s[0]
+	var sb strings.Builder
+	for i := 1; i < len(s); i++ {
+		c := s[i]
+		if c == quote {
+			return sb.String(), i + 1, nil
+		}
+		if c != '\\' || i+1 == len(s) {
+			sb.WriteByte(c)
+			continue
+		}
+		i++
+		switch s[i] {
+		case 'n':
+			sb.WriteByte('\n')
+		case 't':
+			sb.WriteByte('\t')
+		case 'r':
+			sb.WriteByte('\r')
+		case '\\', '\'', '"':
+			sb.WriteByte(s[i])
+		case 'u':
+			if i+4 < len(s) {
+				if r, err := strconv.ParseUint(s[i+1:i+5], 16, 32); err == nil {
+					sb.WriteRune(rune(r))
+					i += 4
+					continue
+				}
+			}
+			sb.WriteString(`\u`)
+		default:
+			sb.WriteByte('\\')
+			sb.WriteByte(s[i])
+		}
+	}
+	return "", 0, fmt.Errorf("unterminated string %s", s)
+}
+
+type exprParser struct {
+	toks []etok
+	pos  int
+	line int
+}
+
+func parseExpr(s string, line int) (expr, error) {
+	toks, err := lex(s, line)
+	if err != nil {
+		return nil, err
+	}
+	p := &exprParser{toks: toks, line: line}
+	e, err := p.parseTernary()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expectEnd(); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+func (p *exprParser) peek() etok {
+	if p.pos < len(p.toks) {
+		return p.toks[p.pos]
+	}
+	return etok{kind: endTok}
+}
+
+func (p *exprParser) next() etok {
+	t := p.peek()
+	if p.pos < len(p.toks) {
+		p.pos++
+	}
+	return t
+}
+
+// back undoes next, t being the token it returned
+func (p *exprParser) back(t etok) {
+	if t.kind != endTok {
+		p.pos--
+	}
+}
+
+func (p *exprParser) isOp(op string) bool {
+	t := p.peek()
+	return t.kind == opTok && t.val == op
+}
+
+func (p *exprParser) isName(name string) bool {
+	t := p.peek()
+	return t.kind == nameTok && t.val == name
+}
+
+func (p *exprParser) acceptOp(op string) bool {
+	if p.isOp(op) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *exprParser) acceptName(name string) bool {
+	if p.isName(name) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *exprParser) expectOp(op string) error {
+	if !p.acceptOp(op) {
+		return p.unexpected(fmt.Sprintf("expected %q", op))
+	}
+	return nil
+}
+
+func (p *exprParser) expectEnd() error {
+	if p.peek().kind != endTok {
+		return p.unexpected("expected the end of the expression")
+	}
+	return nil
+}
+
+func (p *exprParser) unexpected(expected string) error {
+	t := p.peek()
+	if t.kind == endTok {
+		return fmt.Errorf("line %d: unexpected end of expression, %s", p.line, expected)
+	}
+	return fmt.Errorf("line %d: unexpected %q, %s", p.line, t.val, expected)
+}
+
+func (p *exprParser) parseTernary() (expr, error) {
+	e, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if !p.acceptName("if") {
+		return e, nil
+	}
+	cond, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	var els expr
+	if p.acceptName("else") {
+		if els, err = p.parseTernary(); err != nil {
+			return nil, err
+		}
+	}
+	return &condExpr{cond: cond, then: e, els: els}, nil
+}
+
+func (p *exprParser) parseOr() (expr, error) {
+	l, err := p.parseAnd()
+	for err == nil && p.acceptName("or") {
+		var r expr
+		r, err = p.parseAnd()
+		l = &binaryExpr{op: "or", l: l, r: r}
+	}
+	return l, err
+}
+
+func (p *exprParser) parseAnd() (expr, error) {
+	l, err := p.parseNot()
+	for err == nil && p.acceptName("and") {
+		var r expr
+		r, err = p.parseNot()
+		l = &binaryExpr{op: "and", l: l, r: r}
+	}
+	return l, err
+}
+
+func (p *exprParser) parseNot() (expr, error) {
+	if p.acceptName("not") {
+		e, err := p.parseNot()
+		return &unaryExpr{op: "not", e: e}, err
+	}
+	return p.parseCompare()
+}
+
+func (p *exprParser) parseCompare() (expr, error) {
+	l, err := p.parseAdd()
+	for err == nil {
+		t := p.peek()
+		var op string
+		switch {
+		case t.kind == opTok && (t.val == "==" || t.val == "!=" || t.val == "<" || t.val == ">" || t.val == "<=" || t.val == ">="):
+			op = t.val
+			p.pos++
+		case p.acceptName("in"):
+			op = "in"
+		case p.isName("not") && p.pos+1 < len(p.toks) && p.toks[p.pos+1].kind == nameTok && p.toks[p.pos+1].val == "in":
+			op = "not in"
+			p.pos += 2
+		default:
+			return l, nil
+		}
+		var r expr
+		r, err = p.parseAdd()
+		l = &binaryExpr{op: op, l: l, r: r}
+	}
+	return l, err
+}
+
+func (p *exprParser) parseAdd() (expr, error) {
+	l, err := p.parseConcat()
+	for err == nil && (p.isOp("+") || p.isOp("-")) {
+		op := p.next().val
+		var r expr
+		r, err = p.parseConcat()
+		l = &binaryExpr{op: op, l: l, r: r}
+	}
+	return l, err
+}
+
+func (p *exprParser) parseConcat() (expr, error) {
+	l, err := p.parseMul()
+	for err == nil && p.acceptOp("~") {
+		var r expr
+		r, err = p.parseMul()
+		l = &binaryExpr{op: "~", l: l, r: r}
+	}
+	return l, err
+}
+
+func (p *exprParser) parseMul() (expr, error) {
+	l, err := p.parsePow()
+	for err == nil && (p.isOp("*") || p.isOp("/") || p.isOp("//") || p.isOp("%")) {
+		op := p.next().val
+		var r expr
+		r, err = p.parsePow()
+		l = &binaryExpr{op: op, l: l, r: r}
+	}
+	return l, err
+}
+
+func (p *exprParser) parsePow() (expr, error) {
+	l, err := p.parseUnary()
+	for err == nil && p.acceptOp("**") {
+		var r expr
+		r, err = p.parseUnary()
+		l = &binaryExpr{op: "**", l: l, r: r}
+	}
+	return l, err
+}
+
+func (p *exprParser) parseUnary() (expr, error) {
+	if p.acceptOp("-") {
+		e, err := p.parseUnary()
+		return &unaryExpr{op: "-", e: e}, err
+	}
+	if p.acceptOp("+") {
+		return p.parseUnary()
+	}
+	e, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	if e, err = p.parsePostfix(e); err != nil {
+		return nil, err
+	}
+	return p.parseFilters(e)
+}
+
+func (p *exprParser) parsePrimary() (expr, error) {
+	t := p.next()
+	switch t.kind {
+	case nameTok:
+		switch t.val {
+		case "true", "True":
+			return &literal{v: true}, nil
+		case "false", "False":
+			return &literal{v: false}, nil
+		case "none", "None":
+			return &literal{v: nil}, nil
+		}
+		return &nameExpr{name: t.val}, nil
+	case stringTok:
+		s := t.val
+		// adjacent literals are concatenated
+		for p.peek().kind == stringTok {
+			s += p.next().val
+		}
+		return &literal{v: s}, nil
+	case intTok:
+		i, err := strconv.Atoi(t.val)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid number %s", p.line, t.val)
+		}
+		return &literal{v: i}, nil
+	case floatTok:
+		f, err := strconv.ParseFloat(t.val, 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid number %s", p.line, t.val)
+		}
+		return &literal{v: f}, nil
+	case opTok:
+		switch t.val {
+		case "(":
+			if p.acceptOp(")") {
+				return &listExpr{}, nil
+			}
+			e, err := p.parseTernary()
+			if err != nil {
+				return nil, err
+			}
+			if p.isOp(",") {
+				// a tuple, evaluated as a list
+				items := []expr{e}
+				for p.acceptOp(",") && !p.isOp(")") {
+					item, err := p.parseTernary()
+					if err != nil {
+						return nil, err
+					}
+					items = append(items, item)
+				}
+				e = &listExpr{items: items}
+			}
+			return e, p.expectOp(")")
+		case "[":
+			items, err := p.parseList("]")
+			return &listExpr{items: items}, err
+		case "{":
+			d := &dictExpr{}
+			for !p.acceptOp("}") {
+				k, err := p.parseTernary()
+				if err != nil {
+					return nil, err
+				}
+				if err := p.expectOp(":"); err != nil {
+					return nil, err
+				}
+				v, err := p.parseTernary()
+				if err != nil {
+					return nil, err
+				}
+				d.keys, d.values = append(d.keys, k), append(d.values, v)
+				if !p.acceptOp(",") {
+					if err := p.expectOp("}"); err != nil {
+						return nil, err
+					}
+					break
+				}
+			}
+			return d, nil
+		}
+	}
+	p.back(t)
+	return nil, p.unexpected("expected a value")
+}
+
+// parseList parses the comma separated expressions up to the closing delimiter
+func (p *exprParser) parseList(closing string) ([]expr, error) {
+	items := []expr{}
+	for !p.acceptOp(closing) {
+		item, err := p.parseTernary()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+		if !p.acceptOp(",") {
+			return items, p.expectOp(closing)
+		}
+	}
+	return items, nil
+}
+
+func (p *exprParser) parsePostfix(e expr) (expr, error) {
+	for {
+		switch {
+		case p.acceptOp("."):
+			t := p.next()
+			if t.kind != nameTok && t.kind != intTok {
+				p.back(t)
+				return nil, p.unexpected("expected an attribute name")
+			}
+			e = &attrExpr{obj: e, name: t.val}
+		case p.acceptOp("["):
+			var err error
+			if e, err = p.parseSubscript(e); err != nil {
+				return nil, err
+			}
+		case p.acceptOp("("):
+			args, kwargs, err := p.parseArgs()
+			if err != nil {
+				return nil, err
+			}
+			e = &callExpr{fn: e, args: args, kwargs: kwargs}
+		default:
+			return e, nil
+		}
+	}
+}
+
+func (p *exprParser) parseSubscript(obj expr) (expr, error) {
+	var parts [3]expr
+	n := 0
+	for {
+		if !p.isOp(":") && !p.isOp("]") {
+			e, err := p.parseTernary()
+			if err != nil {
+				return nil, err
+			}
+			parts[n] = e
+		}
+		if p.acceptOp("]") {
+			break
+		}
+		if n == 2 || !p.acceptOp(":") {
+			return nil, p.unexpected(`expected "]"`)
+		}
+		n++
+	}
+	if n == 0 {
+		if parts[0] == nil {
+			return nil, fmt.Errorf("line %d: empty subscript", p.line)
+		}
+		return &indexExpr{obj: obj, index: parts[0]}, nil
+	}
+	return &sliceExpr{obj: obj, start: parts[0], stop: parts[1], step: parts[2]}, nil
+}
+
+// parseArgs parses the arguments of a call, after the opening parenthesis
+func (p *exprParser) parseArgs() ([]expr, map[string]expr, error) {
+	args, kwargs := []expr{}, map[string]expr{}
+	for !p.acceptOp(")") {
+		if p.peek().kind == nameTok && p.pos+1 < len(p.toks) && p.toks[p.pos+1].kind == opTok && p.toks[p.pos+1].val == "=" {
+			name := p.next().val
+			p.next()
+			v, err := p.parseTernary()
+			if err != nil {
+				return nil, nil, err
+			}
+			kwargs[name] = v
+		} else {
+			v, err := p.parseTernary()
+			if err != nil {
+				return nil, nil, err
+			}
+			args = append(args, v)
+		}
+		if !p.acceptOp(",") {
+			if err := p.expectOp(")"); err != nil {
+				return nil, nil, err
+			}
+			break
+		}
+	}
+	return args, kwargs, nil
+}
+
+func (p *exprParser) parseFilters(e expr) (expr, error) {
+	for {
+		switch {
+		case p.acceptOp("|"):
+			t := p.next()
+			if t.kind != nameTok {
+				p.back(t)
+				return nil, p.unexpected("expected a filter name")
+			}
+			f := &filterExpr{e: e, name: t.val}
+			if p.acceptOp("(") {
+				var err error
+				if f.args, f.kwargs, err = p.parseArgs(); err != nil {
+					return nil, err
+				}
+			}
+			e = f
+		case p.acceptName("is"):
+			test := &testExpr{e: e, negate: p.acceptName("not")}
+			t := p.next()
+			if t.kind != nameTok {
+				p.back(t)
+				return nil, p.unexpected("expected a test name")
+			}
+			test.name = t.val
+			switch next := p.peek(); {
+			case p.acceptOp("("):
+				args, _, err := p.parseArgs()
+				if err != nil {
+					return nil, err
+				}
+				test.args = args
+			case next.kind == stringTok || next.kind == intTok || next.kind == floatTok ||
+				next.kind == nameTok && !isKeyword(next.val):
+				// a single argument can be given without parentheses: x is divisibleby 3
+				arg, err := p.parsePrimary()
+				if err != nil {
+					return nil, err
+				}
+				test.args = []expr{arg}
+			}
+			e = test
+		default:
+			return e, nil
+		}
+	}
+}
+
+func isKeyword(s string) bool {
+	switch s {
+	case "and", "or", "not", "in", "is", "if", "else":
+		return true
+	}
+	return false
+}
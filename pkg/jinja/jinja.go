@@ -0,0 +1,77 @@
+// Package jinja renders the Jinja2 chat templates that Hugging Face models ship in their
+// tokenizer_config.json (and GGUF files in tokenizer.chat_template), without a Python runtime.
+//
+// Only the subset of the language used by chat templates is implemented:
+//   - {{ expressions }}, {% if %}/{% elif %}/{% else %}, {% for %} (with loop.* and an else block), {% set %}, {% macro %} and {# comments #}
+//   - literals, variables, attributes, subscripts and slices, arithmetic, comparisons, logic, the ternary operator and ~ concatenation
+//   - the most common filters (trim, length, tojson, join, default...), tests (defined, none, string...) and string methods (strip, startswith...)
+//   - the raise_exception, range and namespace functions
+//
+// Everything else fails to parse or to render with an error, notably: {% call %} blocks and the caller, varargs and kwargs
+// of the macros, {% filter %}, {% with %}, {% raw %}, {% break %} and {% continue %}, recursive loops, the template inheritance
+// and inclusion tags ({% extends %}, {% block %}, {% include %}, {% import %}) and the filters, tests and methods not listed
+// in builtins.go.
+//
+// It is not built on a Go template engine because none renders these templates as transformers does: pongo2 implements
+// the Django syntax (forloop instead of loop, no namespace, macros or Python methods), and the Jinja ports such as gonja are
+// unmaintained and don't follow the Python semantics the chat templates rely on more than the Jinja syntax (the string and
+// dict methods, the slicing, the output of json.dumps in tojson). Rendering the templates of downloaded models also needs
+// the limits below, which neither provides. The tests render the templates of popular models, kept unchanged in testdata.
+//
+// As in transformers, blocks are rendered with trim_blocks and lstrip_blocks enabled, and without autoescaping.
+//
+// The templates are not trusted: the size of the strings and lists they build and the number of loop iterations
+// are bounded, and a rendering going over a bound fails with an error.
+package jinja
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Template is a parsed chat template
+type Template struct {
+	root []node
+}
+
+// RaisedError is returned when the template calls raise_exception,
+// usually because the messages don't follow the format the model expects
+type RaisedError struct {
+	Message string
+}
+
+func (e *RaisedError) Error() string {
+	return e.Message
+}
+
+// Parse parses the source of a template
+func Parse(src string) (*Template, error) {
+	tokens, err := scan(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	root, end, err := p.parseBody()
+	if err != nil {
+		return nil, err
+	}
+	if end != nil {
+		return nil, fmt.Errorf("line %d: unexpected {%% %s %%}", end.line, end.name)
+	}
+	return &Template{root: root}, nil
+}
+
+// Execute renders the template with the given variables.
+// Values are strings, numbers, booleans, nil, []interface{} and map[string]interface{}, as decoded from JSON.
+func (t *Template) Execute(vars map[string]interface{}) (string, error) {
+	var sb strings.Builder
+	s := &scope{vars: map[string]interface{}{}}
+	for k, v := range vars {
+		s.vars[k] = normalize(v)
+	}
+	r := &renderer{}
+	if err := r.render(&sb, t.root, s); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
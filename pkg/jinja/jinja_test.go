@@ -0,0 +1,242 @@
+package jinja_test
+
+import (
+	. "github.com/go-skynet/LocalAI/pkg/jinja"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// The templates below are copied from the tokenizer_config.json of the models, except where noted
+const (
+	chatML = `{% for message in messages %}{{'<|im_start|>' + message['role'] + '\n' + message['content'] + '<|im_end|>' + '\n'}}{% endfor %}{% if add_generation_prompt %}{{ '<|im_start|>assistant\n' }}{% endif %}`
+
+	llama2 = `{% if messages[0]['role'] == 'system' %}{% set loop_messages = messages[1:] %}{% set system_message = messages[0]['content'] %}{% else %}{% set loop_messages = messages %}{% set system_message = false %}{% endif %}{% for message in loop_messages %}{% if (message['role'] == 'user') != (loop.index0 % 2 == 0) %}{{ raise_exception('Conversation roles must alternate user/assistant/user/assistant/...') }}{% endif %}{% if loop.index0 == 0 and system_message != false %}{% set content = '<<SYS>>\n' + system_message + '\n<</SYS>>\n\n' + message['content'] %}{% else %}{% set content = message['content'] %}{% endif %}{% if message['role'] == 'user' %}{{ bos_token + '[INST] ' + content.strip() + ' [/INST]' }}{% elif message['role'] == 'assistant' %}{{ ' '  + content.strip() + ' ' + eos_token }}{% endif %}{% endfor %}`
+
+	zephyr = `{% for message in messages %}
+{% if message['role'] == 'user' %}
+{{ '<|user|>\n' + message['content'] + eos_token }}
+{% elif message['role'] == 'system' %}
+{{ '<|system|>\n' + message['content'] + eos_token }}
+{% elif message['role'] == 'assistant' %}
+{{ '<|assistant|>\n'  + message['content'] + eos_token }}
+{% endif %}
+{% if loop.last and add_generation_prompt %}
+{{ '<|assistant|>' }}
+{% endif %}
+{% endfor %}`
+
+	mistral = `{{ bos_token }}{% for message in messages %}{% if (message['role'] == 'user') != (loop.index0 % 2 == 0) %}{{ raise_exception('Conversation roles must alternate user/assistant/user/assistant/...') }}{% endif %}{% if message['role'] == 'user' %}{{ '[INST] ' + message['content'] + ' [/INST]' }}{% elif message['role'] == 'assistant' %}{{ message['content'] + eos_token}}{% else %}{{ raise_exception('Only user and assistant roles are supported!') }}{% endif %}{% endfor %}`
+
+	// the DeepSeek R1 template, without the branches formatting the tool calls
+	deepSeekR1 = `{% if not add_generation_prompt is defined %}{% set add_generation_prompt = false %}{% endif %}{% set ns = namespace(is_first=false, is_tool=false, is_output_first=true, system_prompt='') %}{%- for message in messages %}{%- if message['role'] == 'system' %}{% set ns.system_prompt = message['content'] %}{%- endif %}{%- endfor %}{{bos_token}}{{ns.system_prompt}}{%- for message in messages %}{%- if message['role'] == 'user' %}{%- set ns.is_tool = false -%}{{'<｜User｜>' + message['content']}}{%- endif %}{%- if message['role'] == 'assistant' and message['content'] is not none %}{% set content = message['content'] %}{% if '</think>' in content %}{% set content = content.split('</think>')[-1] %}{% endif %}{{'<｜Assistant｜>' + content + '<｜end▁of▁sentence｜>'}}{%- endif %}{%- endfor -%}{% if add_generation_prompt and not ns.is_tool %}{{'<｜Assistant｜>'}}{% endif %}`
+
+	// the macro of the Hermes 2 Pro tool use template converting the JSON schemas of the parameters to Python types,
+	// with the loop listing the types of the parameters
+	hermesTypes = `{%- macro json_to_python_type(json_spec) %}
+{%- set basic_type_map = {
+    "string": "str",
+    "number": "float",
+    "integer": "int",
+    "boolean": "bool"
+} %}
+
+{%- if basic_type_map[json_spec.type] is defined %}
+    {{- basic_type_map[json_spec.type] }}
+{%- elif json_spec.type == "array" %}
+    {{- "list[" +  json_to_python_type(json_spec['items']) + "]"}}
+{%- elif json_spec.type == "object" %}
+    {%- if json_spec.additionalProperties is defined %}
+        {{- "dict[str, " + json_to_python_type(json_spec.additionalProperties) + ']'}}
+    {%- else %}
+        {{- "dict" }}
+    {%- endif %}
+{%- elif json_spec.type is iterable %}
+    {{- "Union[" }}
+    {%- for t in json_spec.type %}
+      {{- json_to_python_type({"type": t}) }}
+      {%- if not loop.last %}
+        {{- "," }}
+    {%- endif %}
+    {%- endfor %}
+    {{- "]" }}
+{%- else %}
+    {{- "Any" }}
+{%- endif %}
+{%- endmacro %}
+{%- for param_name, param_fields in parameters.properties|items %}
+    {{- param_name + ": " + json_to_python_type(param_fields) }}
+    {%- if not loop.last %}
+        {{- ", " }}
+    {%- endif %}
+{%- endfor %}`
+)
+
+func render(src string, vars map[string]interface{}) (string, error) {
+	t, err := Parse(src)
+	Expect(err).ToNot(HaveOccurred())
+	return t.Execute(vars)
+}
+
+func mustRender(src string, vars map[string]interface{}) string {
+	out, err := render(src, vars)
+	Expect(err).ToNot(HaveOccurred())
+	return out
+}
+
+var _ = Describe("Jinja chat templates", func() {
+	system := map[string]interface{}{"role": "system", "content": "You are a helpful assistant."}
+	conversation := []map[string]interface{}{
+		{"role": "user", "content": "Hello!"},
+		{"role": "assistant", "content": "Hi there."},
+		{"role": "user", "content": "How are you?"},
+	}
+	withSystem := append([]map[string]interface{}{system}, conversation...)
+
+	vars := func(messages []map[string]interface{}, addGenerationPrompt bool) map[string]interface{} {
+		return map[string]interface{}{
+			"messages":              messages,
+			"add_generation_prompt": addGenerationPrompt,
+			"bos_token":             "<s>",
+			"eos_token":             "</s>",
+		}
+	}
+
+	DescribeTable("renders the templates of the models",
+		func(src string, vars map[string]interface{}, expected string) {
+			Expect(mustRender(src, vars)).To(Equal(expected))
+		},
+		Entry("ChatML", chatML, vars(withSystem, true), "<|im_start|>system\nYou are a helpful assistant.<|im_end|>\n"+
+			"<|im_start|>user\nHello!<|im_end|>\n<|im_start|>assistant\nHi there.<|im_end|>\n"+
+			"<|im_start|>user\nHow are you?<|im_end|>\n<|im_start|>assistant\n"),
+		Entry("ChatML without the generation prompt", chatML, vars(conversation[:1], false), "<|im_start|>user\nHello!<|im_end|>\n"),
+		Entry("Llama 2 with a system prompt", llama2, vars(withSystem, true),
+			"<s>[INST] <<SYS>>\nYou are a helpful assistant.\n<</SYS>>\n\nHello! [/INST] Hi there. </s><s>[INST] How are you? [/INST]"),
+		Entry("Llama 2 without a system prompt", llama2, vars(conversation, true), "<s>[INST] Hello! [/INST] Hi there. </s><s>[INST] How are you? [/INST]"),
+		Entry("Zephyr, with trim_blocks and lstrip_blocks", zephyr, vars(withSystem, true), "<|system|>\nYou are a helpful assistant.</s>\n<|user|>\nHello!</s>\n"+
+			"<|assistant|>\nHi there.</s>\n<|user|>\nHow are you?</s>\n<|assistant|>\n"),
+		Entry("Zephyr, without the generation prompt after the last message", zephyr, vars(conversation[:2], false), "<|user|>\nHello!</s>\n<|assistant|>\nHi there.</s>\n"),
+		Entry("Mistral", mistral, vars(conversation, true), "<s>[INST] Hello! [/INST]Hi there.</s>[INST] How are you? [/INST]"),
+		Entry("DeepSeek R1, with a namespace", deepSeekR1, map[string]interface{}{
+			"messages": append(withSystem[:2:2],
+				map[string]interface{}{"role": "assistant", "content": "<think>The user greets me.</think>Hi there."},
+				conversation[2]),
+			"bos_token": "<｜begin▁of▁sentence｜>",
+		}, "<｜begin▁of▁sentence｜>You are a helpful assistant.<｜User｜>Hello!<｜Assistant｜>Hi there.<｜end▁of▁sentence｜><｜User｜>How are you?"),
+		Entry("Hermes 2 Pro tool types, with a recursive macro", hermesTypes, map[string]interface{}{
+			"parameters": map[string]interface{}{
+				"properties": map[string]interface{}{
+					"city":    map[string]interface{}{"type": "string"},
+					"days":    map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "integer"}},
+					"options": map[string]interface{}{"type": "object", "additionalProperties": map[string]interface{}{"type": []interface{}{"number", "boolean"}}},
+					"extra":   map[string]interface{}{},
+				},
+			},
+		}, "city: str, days: list[int], extra: Any, options: dict[str, Union[float,bool]]"),
+	)
+
+	DescribeTable("returns the exceptions raised by the template",
+		func(src string, messages []map[string]interface{}, expected string) {
+			_, err := render(src, vars(messages, true))
+			Expect(err).To(BeAssignableToTypeOf(&RaisedError{}))
+			Expect(err.Error()).To(Equal(expected))
+		},
+		Entry("Mistral with a system prompt", mistral, withSystem, "Conversation roles must alternate user/assistant/user/assistant/..."),
+		Entry("Mistral with a tool message", mistral, append(conversation[:1:1], map[string]interface{}{"role": "tool", "content": "{}"}), "Only user and assistant roles are supported!"),
+		Entry("Llama 2 starting with the assistant", llama2, conversation[1:], "Conversation roles must alternate user/assistant/user/assistant/..."),
+	)
+
+	It("reports syntax errors with their line", func() {
+		_, err := Parse("hello\n{% if x %}\n{{ x")
+		Expect(err).To(MatchError(ContainSubstring("line 3")))
+		_, err = Parse("{% for m in messages %}\n{% endif %}")
+		Expect(err).To(MatchError(ContainSubstring("line 2")))
+		_, err = Parse("{% if x %}")
+		Expect(err).To(MatchError(ContainSubstring("not closed")))
+		_, err = Parse("{% macro m(x) %}")
+		Expect(err).To(MatchError(ContainSubstring("not closed")))
+		_, err = Parse("\n{% include 'other.jinja' %}")
+		Expect(err).To(MatchError("line 2: unsupported tag {% include %}"))
+	})
+})
+
+var _ = Describe("Jinja expressions", func() {
+	DescribeTable("renders",
+		func(src string, vars map[string]interface{}, expected string) {
+			Expect(mustRender(src, vars)).To(Equal(expected))
+		},
+		Entry("arithmetic", "{{ 1 + 2 * 3 }} {{ 7 // 2 }} {{ -7 % 3 }} {{ 2 ** 3 }} {{ 1 / 2 }}", nil, "7 3 2 8 0.5"),
+		Entry("python literals", "{{ true }} {{ none }} {{ [1, 'a'] }} {{ {'k': 1.0} }}", nil, "True None [1, 'a'] {'k': 1.0}"),
+		Entry("logic", "{{ x and y }} {{ x or y }} {{ not x }}", map[string]interface{}{"x": 0, "y": "y"}, "0 y True"),
+		Entry("ternary", "{{ 'a' if x else 'b' }}{{ 'c' if not x }}", map[string]interface{}{"x": true}, "a"),
+		Entry("membership", "{{ 'b' in 'abc' }} {{ 2 not in [1, 2] }} {{ 'k' in d }}", map[string]interface{}{"d": map[string]string{"k": "v"}}, "True False True"),
+		Entry("concatenation", "{{ 'n=' ~ 1 ~ x }}", map[string]interface{}{"x": nil}, "n=1None"),
+		Entry("attributes and subscripts", "{{ m.role }}{{ m['content'] }}{{ l[-1] }}{{ l[1:] }}{{ 'abc'[::-1] }}", map[string]interface{}{
+			"m": map[string]interface{}{"role": "user", "content": "!"},
+			"l": []int{1, 2, 3},
+		}, "user!3[2, 3]cba"),
+		Entry("undefined values", "[{{ missing }}{{ m.missing }}{{ missing is defined }}]", map[string]interface{}{"m": map[string]interface{}{}}, "[False]"),
+		Entry("filters", "{{ ' a '|trim|upper }} {{ l|length }} {{ l|join(', ') }} {{ l|first }} {{ missing|default('d') }} {{ 'x'|d('y') }}",
+			map[string]interface{}{"l": []string{"a", "b"}}, "A 2 a, b a d x"),
+		Entry("tojson", "{{ v|tojson }}", map[string]interface{}{"v": map[string]interface{}{"b": []interface{}{1, "<x>", true, nil}, "a": 1.5}},
+			`{"a": 1.5, "b": [1, "<x>", true, null]}`),
+		Entry("tojson with indentation", "{{ v|tojson(indent=2) }}", map[string]interface{}{"v": map[string]interface{}{"a": []interface{}{1}}},
+			"{\n  \"a\": [\n    1\n  ]\n}"),
+		Entry("tests", "{{ x is string }} {{ x is not none }} {{ 4 is divisibleby 2 }} {{ 3 is odd }}", map[string]interface{}{"x": "s"}, "True True True True"),
+		Entry("string methods", "{{ ' Hi '.strip() }} {{ s.startswith('ab') }} {{ s.split(',') }} {{ s.replace('b', 'B') }} {{ s.title() }}",
+			map[string]interface{}{"s": "ab,cd"}, "Hi True ['ab', 'cd'] aB,cd Ab,Cd"),
+		Entry("dict methods", "{% for k, v in d.items() %}{{ k }}={{ v }};{% endfor %}{{ d.get('z', 0) }}", map[string]interface{}{"d": map[string]int{"b": 2, "a": 1}}, "a=1;b=2;0"),
+		Entry("loop variables", "{% for i in range(3) %}{{ loop.index }}{{ '!' if loop.last else ',' }}{% endfor %}", nil, "1,2,3!"),
+		Entry("loop filter and else", "{% for i in l if i > 1 %}{{ i }}{{ loop.length }}{% else %}none{% endfor %}{% for i in [] %}{% else %}empty{% endfor %}",
+			map[string]interface{}{"l": []int{1, 2, 3}}, "2232empty"),
+		Entry("scoping and namespaces", "{% set ns = namespace(found=false) %}{% set x = 1 %}{% for i in [1, 2] %}{% set x = i %}{% if i == 2 %}{% set ns.found = true %}{% endif %}{% endfor %}{{ x }} {{ ns.found }}", nil, "1 True"),
+		Entry("block assignments", "{% set v %}a{{ 1 }}{% endset %}{{ v }}", nil, "a1"),
+		Entry("macros", "{% macro tag(name, v='-', end='') %}<{{ name }}>{{ v }}{{ end }}{% endmacro %}{{ tag('a') }}{{ tag('b', 1, end='!') }}{{ tag(v=2, name='c') }}", nil, "<a>-<b>1!<c>2"),
+		Entry("macro scoping", "{% set x = 1 %}{% macro m() %}{% set x = 2 %}{{ x }}{{ y }}{% endmacro %}{{ m(y=1) if false }}{{ m() }}{{ x }}", nil, "21"),
+		Entry("selectattr", "{{ l|selectattr('role', 'equalto', 'user')|map(attribute='content')|list }}", map[string]interface{}{"l": []map[string]string{
+			{"role": "user", "content": "a"}, {"role": "system", "content": "b"},
+		}}, "['a']"),
+	)
+
+	DescribeTable("whitespace control",
+		func(src, expected string) {
+			Expect(mustRender(src, map[string]interface{}{"x": true})).To(Equal(expected))
+		},
+		Entry("minus modifiers", "a  {{- 'b' -}}  c", "abc"),
+		Entry("trim_blocks", "{% if x %}\nyes\n{% endif %}\n", "yes\n"),
+		Entry("lstrip_blocks", "  {% if x %}\n  yes\n  {% endif %}\n", "  yes\n"),
+		Entry("plus modifier", "  {%+ if x %}yes{% endif %}", "  yes"),
+		Entry("comments", "a {# comment #}b", "a b"),
+	)
+})
+
+var _ = Describe("Jinja limits", func() {
+	DescribeTable("fails instead of exhausting the memory",
+		func(src, expected string) {
+			_, err := render(src, nil)
+			Expect(err).To(MatchError(ContainSubstring(expected)))
+		},
+		Entry("string repetition", "{{ 'a' * 100000000000 }}", "string larger than"),
+		Entry("repeated concatenation", "{% set ns = namespace(s='a' * 1000000) %}{% for i in range(100) %}{% set ns.s = ns.s ~ ns.s %}{% endfor %}", "string larger than"),
+		Entry("long ranges", "{% for i in range(3000000000) %}{% endfor %}", "list longer than"),
+		Entry("ranges over the whole integers", "{{ range(-9223372036854775807, 9223372036854775807)|length }}", "list longer than"),
+		Entry("list concatenation", "{% set ns = namespace(l=range(1000000)) %}{{ (ns.l + ns.l)|length }}", "list longer than"),
+		Entry("output", "{% for i in range(1000) %}{{ 'a' * 1000000 }}{% endfor %}", "string larger than"),
+		Entry("replacements", "{{ ('a' * 1000000).replace('a', 'bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb') }}", "string larger than"),
+		Entry("joins", "{% set s = 'a' * 1000000 %}{{ [s, s, s, s, s, s, s, s, s, s, s, s, s, s, s, s, s, s, s, s, s, s, s, s, s, s, s, s, s, s, s, s, s, s]|join }}", "string larger than"),
+		Entry("indentation", "{{ 'a\nb'|indent(100000000000) }}", "string larger than"),
+		Entry("JSON indentation", "{{ [1]|tojson(indent=100000000000) }}", "string larger than"),
+		Entry("recursive values in JSON", "{% set ns = namespace() %}{% set ns.self = ns %}{{ ns|tojson }}", "too deeply"),
+		Entry("recursive macros", "{% macro m() %}{{ m() }}{% endmacro %}{{ m() }}", "too deeply"),
+		Entry("nested loops", "{% for i in range(1000000) %}{% for j in range(1000000) %}{% endfor %}{% endfor %}", "loops more than"),
+	)
+
+	It("elides the recursive values", func() {
+		out := mustRender("{% set ns = namespace() %}{% set ns.self = ns %}{{ ns }}", nil)
+		Expect(out).To(HavePrefix("<Namespace {'self': <Namespace {'self': "))
+		Expect(out).To(ContainSubstring("{'self': <Namespace {...}>}>"))
+	})
+
+	It("still repeats and ranges within the limits", func() {
+		Expect(mustRender("{{ 'ab' * 3 }}{{ 'x' * -1 }} {{ range(10, 0, -3)|list }} {{ range(0)|list }}", nil)).To(Equal("ababab [10, 7, 4, 1] []"))
+	})
+})
@@ -0,0 +1,71 @@
+package jinja
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// The templates come with the models, so they are not trusted: these limits keep a template from exhausting
+// the memory of the server or looping for too long. They are far above what any chat template needs.
+const (
+	// maxStringSize is the size of the largest string a template can build, including its output
+	maxStringSize = 32 * 1024 * 1024
+	// maxListLength is the length of the longest list a template can build
+	maxListLength = 1024 * 1024
+	// maxIterations is the number of loop iterations in a rendering
+	maxIterations = 4 * 1024 * 1024
+	// maxDepth is the nesting depth of the macro calls, and of the values converted to strings or JSON
+	maxDepth = 256
+)
+
+var (
+	errStringTooLarge = fmt.Errorf("the template builds a string larger than %d bytes", maxStringSize)
+	errListTooLong    = fmt.Errorf("the template builds a list longer than %d items", maxListLength)
+	errTooManyLoops   = fmt.Errorf("the template loops more than %d times", maxIterations)
+	errTooDeep        = errors.New("the template nests the values or the macro calls too deeply")
+)
+
+func checkSize(n int) error {
+	if n < 0 || n > maxStringSize {
+		return errStringTooLarge
+	}
+	return nil
+}
+
+func checkLength(n int) error {
+	if n < 0 || n > maxListLength {
+		return errListTooLong
+	}
+	return nil
+}
+
+// repeat is strings.Repeat, which returns an empty string for the counts below one as Python does
+func repeat(s string, n int) (string, error) {
+	if n <= 0 || s == "" {
+		return "", nil
+	}
+	if n > maxStringSize/len(s) {
+		return "", errStringTooLarge
+	}
+	return strings.Repeat(s, n), nil
+}
+
+func concat(a, b string) (string, error) {
+	if err := checkSize(len(a) + len(b)); err != nil {
+		return "", err
+	}
+	return a + b, nil
+}
+
+// replace is strings.Replace, checking the size of the result before building it
+func replace(s, old, new string, n int) (string, error) {
+	count := strings.Count(s, old)
+	if n >= 0 && n < count {
+		count = n
+	}
+	if grow := len(new) - len(old); grow > 0 && count > 0 && count > (maxStringSize-len(s))/grow {
+		return "", errStringTooLarge
+	}
+	return strings.Replace(s, old, new, n), nil
+}
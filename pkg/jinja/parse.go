@@ -0,0 +1,299 @@
+package jinja
+
+import (
+	"fmt"
+	"strings"
+)
+
+type node interface{}
+
+type textNode string
+
+type outputNode struct {
+	e expr
+}
+
+type ifNode struct {
+	conds  []expr
+	bodies [][]node
+	els    []node
+}
+
+type forNode struct {
+	targets []string
+	iter    expr
+	filter  expr
+	body    []node
+	els     []node
+}
+
+type setNode struct {
+	name string
+	// attr is set when assigning an attribute of a namespace: {% set ns.attr = value %}
+	attr string
+	e    expr
+	// body is the content of a block assignment: {% set name %}...{% endset %}
+	body []node
+}
+
+type macroNode struct {
+	name   string
+	params []string
+	// defaults are the default values of the parameters, evaluated at each call
+	defaults map[string]expr
+	body     []node
+}
+
+// tag is a block tag that closes or continues an enclosing block (else, endif...)
+type tag struct {
+	name string
+	args string
+	line int
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+// parseBody parses the nodes up to the end of the input, or up to the first tag that is not a statement on its own,
+// which is returned for the enclosing block to handle
+func (p *parser) parseBody() ([]node, *tag, error) {
+	nodes := []node{}
+	for p.pos < len(p.tokens) {
+		t := p.tokens[p.pos]
+		p.pos++
+		switch t.kind {
+		case textToken:
+			nodes = append(nodes, textNode(t.text))
+		case outputToken:
+			e, err := parseExpr(t.text, t.line)
+			if err != nil {
+				return nil, nil, err
+			}
+			nodes = append(nodes, &outputNode{e: e})
+		case blockToken:
+			name, args := splitTag(t.text)
+			var n node
+			var err error
+			switch name {
+			case "if":
+				n, err = p.parseIf(args, t.line)
+			case "for":
+				n, err = p.parseFor(args, t.line)
+			case "set":
+				n, err = p.parseSet(args, t.line)
+			case "macro":
+				n, err = p.parseMacro(args, t.line)
+			case "elif", "else", "endif", "endfor", "endset", "endmacro":
+				return nodes, &tag{name: name, args: args, line: t.line}, nil
+			default:
+				err = fmt.Errorf("line %d: unsupported tag {%% %s %%}", t.line, name)
+			}
+			if err != nil {
+				return nil, nil, err
+			}
+			nodes = append(nodes, n)
+		}
+	}
+	return nodes, nil, nil
+}
+
+// expectEnd checks that the block opened by the given tag at the given line ends with the end tag
+func expectEnd(end *tag, name string, line int) error {
+	if end == nil {
+		return fmt.Errorf("line %d: {%% %s %%} is not closed", line, name)
+	}
+	if end.name != "end"+name {
+		return fmt.Errorf("line %d: unexpected {%% %s %%}", end.line, end.name)
+	}
+	return nil
+}
+
+func splitTag(s string) (string, string) {
+	i := strings.IndexAny(s, " \t\r\n")
+	if i < 0 {
+		return s, ""
+	}
+	return s[:i], strings.TrimSpace(s[i:])
+}
+
+func (p *parser) parseIf(args string, line int) (node, error) {
+	n := &ifNode{}
+	cond, err := parseExpr(args, line)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		body, end, err := p.parseBody()
+		if err != nil {
+			return nil, err
+		}
+		if end == nil {
+			return nil, fmt.Errorf("line %d: {%% if %%} is not closed", line)
+		}
+		n.conds = append(n.conds, cond)
+		n.bodies = append(n.bodies, body)
+
+		switch end.name {
+		case "elif":
+			if cond, err = parseExpr(end.args, end.line); err != nil {
+				return nil, err
+			}
+		case "else":
+			els, end, err := p.parseBody()
+			if err != nil {
+				return nil, err
+			}
+			if err := expectEnd(end, "if", line); err != nil {
+				return nil, err
+			}
+			n.els = els
+			return n, nil
+		case "endif":
+			return n, nil
+		default:
+			return nil, fmt.Errorf("line %d: unexpected {%% %s %%}", end.line, end.name)
+		}
+	}
+}
+
+func (p *parser) parseFor(args string, line int) (node, error) {
+	toks, err := lex(args, line)
+	if err != nil {
+		return nil, err
+	}
+	ep := &exprParser{toks: toks, line: line}
+	n := &forNode{}
+	for {
+		t := ep.next()
+		if t.kind != nameTok {
+			return nil, fmt.Errorf("line %d: invalid loop variable in {%% for %s %%}", line, args)
+		}
+		n.targets = append(n.targets, t.val)
+		if !ep.acceptOp(",") {
+			break
+		}
+	}
+	if !ep.acceptName("in") {
+		return nil, fmt.Errorf("line %d: expected 'in' in {%% for %s %%}", line, args)
+	}
+	// the condition of the ternary operator would swallow the loop filter
+	if n.iter, err = ep.parseOr(); err != nil {
+		return nil, err
+	}
+	if ep.acceptName("if") {
+		if n.filter, err = ep.parseTernary(); err != nil {
+			return nil, err
+		}
+	}
+	ep.acceptName("recursive")
+	if err := ep.expectEnd(); err != nil {
+		return nil, err
+	}
+
+	body, end, err := p.parseBody()
+	if err != nil {
+		return nil, err
+	}
+	n.body = body
+	if end != nil && end.name == "else" {
+		if n.els, end, err = p.parseBody(); err != nil {
+			return nil, err
+		}
+	}
+	if err := expectEnd(end, "for", line); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+func (p *parser) parseSet(args string, line int) (node, error) {
+	target, value, assign := strings.Cut(args, "=")
+	target = strings.TrimSpace(target)
+	n := &setNode{name: target}
+	if name, attr, ok := strings.Cut(target, "."); ok {
+		n.name, n.attr = strings.TrimSpace(name), strings.TrimSpace(attr)
+	}
+	if !isIdentifier(n.name) || (n.attr != "" && !isIdentifier(n.attr)) {
+		return nil, fmt.Errorf("line %d: invalid assignment {%% set %s %%}", line, args)
+	}
+
+	if assign {
+		e, err := parseExpr(value, line)
+		if err != nil {
+			return nil, err
+		}
+		n.e = e
+		return n, nil
+	}
+
+	body, end, err := p.parseBody()
+	if err != nil {
+		return nil, err
+	}
+	if err := expectEnd(end, "set", line); err != nil {
+		return nil, err
+	}
+	n.body = body
+	return n, nil
+}
+
+func (p *parser) parseMacro(args string, line int) (node, error) {
+	toks, err := lex(args, line)
+	if err != nil {
+		return nil, err
+	}
+	ep := &exprParser{toks: toks, line: line}
+	t := ep.next()
+	if t.kind != nameTok {
+		return nil, fmt.Errorf("line %d: invalid macro name in {%% macro %s %%}", line, args)
+	}
+	n := &macroNode{name: t.val, defaults: map[string]expr{}}
+	if err := ep.expectOp("("); err != nil {
+		return nil, err
+	}
+	for !ep.acceptOp(")") {
+		t := ep.next()
+		if t.kind != nameTok {
+			return nil, fmt.Errorf("line %d: invalid parameter in {%% macro %s %%}", line, args)
+		}
+		n.params = append(n.params, t.val)
+		if ep.acceptOp("=") {
+			if n.defaults[t.val], err = ep.parseTernary(); err != nil {
+				return nil, err
+			}
+		}
+		if !ep.acceptOp(",") {
+			if err := ep.expectOp(")"); err != nil {
+				return nil, err
+			}
+			break
+		}
+	}
+	if err := ep.expectEnd(); err != nil {
+		return nil, err
+	}
+
+	body, end, err := p.parseBody()
+	if err != nil {
+		return nil, err
+	}
+	if err := expectEnd(end, "macro", line); err != nil {
+		return nil, err
+	}
+	n.body = body
+	return n, nil
+}
+
+func isIdentifier(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, c := range s {
+		if !(c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || i > 0 && c >= '0' && c <= '9') {
+			return false
+		}
+	}
+	return true
+}
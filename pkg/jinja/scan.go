@@ -0,0 +1,141 @@
+package jinja
+
+import (
+	"fmt"
+	"strings"
+)
+
+type tokenKind int
+
+const (
+	textToken tokenKind = iota
+	// outputToken is an {{ expression }}
+	outputToken
+	// blockToken is a {% statement %}
+	blockToken
+)
+
+type token struct {
+	kind tokenKind
+	// text is the raw text, or the inside of the tag
+	text string
+	line int
+}
+
+// scan splits the template in text and tags, applying the whitespace control:
+// the - modifiers, trim_blocks (the newline after a block is removed) and
+// lstrip_blocks (the spaces before a block on its line are removed).
+func scan(src string) ([]token, error) {
+	tokens := []token{}
+	pos := 0
+	for pos < len(src) {
+		start := nextTag(src, pos)
+		if start < 0 {
+			tokens = append(tokens, token{kind: textToken, text: src[pos:], line: lineAt(src, pos)})
+			break
+		}
+
+		open := src[start : start+2]
+		closing := map[string]string{"{{": "}}", "{%": "%}", "{#": "#}"}[open]
+		end := findClose(src, start+2, closing)
+		if end < 0 {
+			return nil, fmt.Errorf("line %d: %s is not closed", lineAt(src, start), open)
+		}
+
+		inner := src[start+2 : end]
+		stripBefore, keepBefore := strings.HasPrefix(inner, "-"), strings.HasPrefix(inner, "+")
+		if stripBefore || keepBefore {
+			inner = inner[1:]
+		}
+		stripAfter, keepAfter := strings.HasSuffix(inner, "-"), strings.HasSuffix(inner, "+")
+		if stripAfter || keepAfter {
+			inner = inner[:len(inner)-1]
+		}
+		isBlock := open != "{{"
+
+		text := src[pos:start]
+		if stripBefore {
+			text = strings.TrimRight(text, " \t\r\n")
+		} else if isBlock && !keepBefore {
+			text = lstripBlock(text, pos == 0 || src[pos-1] == '\n')
+		}
+		if text != "" {
+			tokens = append(tokens, token{kind: textToken, text: text, line: lineAt(src, pos)})
+		}
+
+		switch open {
+		case "{{":
+			tokens = append(tokens, token{kind: outputToken, text: strings.TrimSpace(inner), line: lineAt(src, start)})
+		case "{%":
+			tokens = append(tokens, token{kind: blockToken, text: strings.TrimSpace(inner), line: lineAt(src, start)})
+		}
+
+		pos = end + 2
+		if stripAfter {
+			for pos < len(src) && strings.ContainsRune(" \t\r\n", rune(src[pos])) {
+				pos++
+			}
+		} else if isBlock && !keepAfter {
+			if strings.HasPrefix(src[pos:], "\r\n") {
+				pos += 2
+			} else if strings.HasPrefix(src[pos:], "\n") {
+				pos++
+			}
+		}
+	}
+	return tokens, nil
+}
+
+func nextTag(src string, pos int) int {
+	for i := pos; i < len(src)-1; i++ {
+		if src[i] == '{' && strings.ContainsRune("{%#", rune(src[i+1])) {
+			return i
+		}
+	}
+	return -1
+}
+
+// findClose returns the position of the closing delimiter, ignoring the ones in string literals
+func findClose(src string, pos int, closing string) int {
+	if closing == "#}" {
+		if i := strings.Index(src[pos:], closing); i >= 0 {
+			return pos + i
+		}
+		return -1
+	}
+	var quote byte
+	for i := pos; i < len(src)-1; i++ {
+		c := src[i]
+		switch {
+		case quote != 0 && c == '\\':
+			i++
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case src[i:i+2] == closing:
+			return i
+		}
+	}
+	return -1
+}
+
+// lstripBlock removes the spaces and tabs preceding a block, if they are all there is on its line
+func lstripBlock(text string, atLineStart bool) string {
+	line := text
+	if i := strings.LastIndexByte(text, '\n'); i >= 0 {
+		line = text[i+1:]
+	} else if !atLineStart {
+		return text
+	}
+	if strings.Trim(line, " \t") != "" {
+		return text
+	}
+	return text[:len(text)-len(line)]
+}
+
+func lineAt(src string, pos int) int {
+	return strings.Count(src[:pos], "\n") + 1
+}
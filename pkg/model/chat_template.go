@@ -0,0 +1,107 @@
+package model
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-skynet/LocalAI/pkg/jinja"
+)
+
+// JinjaExtension is the extension of the chat template files in the model path
+const JinjaExtension = ".jinja"
+
+// GGUFChatTemplate selects the chat template stored in the metadata of the GGUF model file
+const GGUFChatTemplate = "gguf"
+
+// EvaluateJinjaChatTemplate renders a Hugging Face chat template, as set in the chat_template field of the model config:
+//   - "gguf" uses the template embedded in the GGUF model file
+//   - the name of a file in the model path (the .jinja extension can be omitted)
+//   - the source of the template itself
+//
+// vars usually holds messages and add_generation_prompt. bos_token and eos_token default to the ones of the GGUF model file.
+// Errors raised by the template with raise_exception are returned as *jinja.RaisedError.
+func (ml *ModelLoader) EvaluateJinjaChatTemplate(chatTemplate, modelFile string, vars map[string]interface{}) (string, error) {
+	tmpl, err := ml.loadJinjaTemplate(chatTemplate, modelFile)
+	if err != nil {
+		return "", err
+	}
+
+	all := map[string]interface{}{"bos_token": "", "eos_token": ""}
+	if md, _ := ml.ModelMetadata(modelFile); md != nil {
+		all["bos_token"], all["eos_token"] = md.BosToken, md.EosToken
+	}
+	for k, v := range vars {
+		all[k] = v
+	}
+	return tmpl.Execute(all)
+}
+
+// ChatTemplateName tells where the chat template is read from: the name of the file in the model path,
+// "gguf" for the metadata of the model, or "inline" if the chat_template holds the template itself
+func ChatTemplateName(chatTemplate string) string {
+	if chatTemplate == GGUFChatTemplate {
+		return GGUFChatTemplate
+	}
+	if file := jinjaFile(chatTemplate); file != "" {
+		return file
+	}
+	return "inline"
+}
+
+// jinjaFile returns the name of the file holding the chat template, empty if the template is given inline
+func jinjaFile(chatTemplate string) string {
+	if strings.Contains(chatTemplate, "{{") || strings.Contains(chatTemplate, "{%") {
+		return ""
+	}
+	file := filepath.Base(chatTemplate)
+	if filepath.Ext(file) != JinjaExtension {
+		file += JinjaExtension
+	}
+	return file
+}
+
+func (ml *ModelLoader) loadJinjaTemplate(chatTemplate, modelFile string) (*jinja.Template, error) {
+	// templates are cached by file name, or by source
+	var file string
+	src := chatTemplate
+	switch {
+	case chatTemplate == GGUFChatTemplate:
+		md, err := ml.ModelMetadata(modelFile)
+		if err != nil {
+			return nil, err
+		}
+		if md == nil || md.ChatTemplate == "" {
+			return nil, fmt.Errorf("model %s has no chat template in its metadata", modelFile)
+		}
+		src = md.ChatTemplate
+	default:
+		file = jinjaFile(chatTemplate)
+	}
+
+	key := src
+	if file != "" {
+		key = file
+	}
+
+	ml.mu.Lock()
+	defer ml.mu.Unlock()
+	if tmpl, ok := ml.jinjaTemplates[key]; ok {
+		return tmpl, nil
+	}
+
+	if file != "" {
+		dat, err := os.ReadFile(filepath.Join(ml.ModelPath, file))
+		if err != nil {
+			return nil, fmt.Errorf("failed reading the chat template: %w", err)
+		}
+		src = string(dat)
+	}
+	tmpl, err := jinja.Parse(src)
+	if err != nil {
+		return nil, fmt.Errorf("failed parsing the chat template: %w", err)
+	}
+	ml.jinjaTemplates[key] = tmpl
+	return tmpl, nil
+}
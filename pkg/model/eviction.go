@@ -0,0 +1,176 @@
+package model
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	process "github.com/mudler/go-processmanager"
+	"github.com/rs/zerolog/log"
+)
+
+// maxEvictionHistory is the number of evictions kept in memory to be displayed by the API
+const maxEvictionHistory = 100
+
+// Eviction records a model that was unloaded to make room for another one
+type Eviction struct {
+	Model  string    `json:"model"`
+	Reason string    `json:"reason"`
+	RSS    uint64    `json:"rss"`
+	For    string    `json:"for"`
+	Time   time.Time `json:"time"`
+}
+
+// SetMaxLoadedModels sets the maximum number of models that can be loaded at the same time.
+// When a new model would exceed this number, the least recently used one is stopped. 0 means no limit.
+func (ml *ModelLoader) SetMaxLoadedModels(n int) {
+	ml.mu.Lock()
+	defer ml.mu.Unlock()
+	ml.maxLoadedModels = n
+}
+
+// SetMemoryBudget sets the maximum amount of memory (in bytes) that backend processes can use, measured by their RSS.
+// When loading a new model would exceed the budget, least recently used models are stopped. 0 means no limit.
+func (ml *ModelLoader) SetMemoryBudget(bytes uint64) {
+	ml.mu.Lock()
+	defer ml.mu.Unlock()
+	ml.memoryBudget = bytes
+}
+
+// Evictions returns the most recent model evictions
+func (ml *ModelLoader) Evictions() []Eviction {
+	ml.mu.Lock()
+	defer ml.mu.Unlock()
+	res := make([]Eviction, len(ml.evictions))
+	copy(res, ml.evictions)
+	return res
+}
+
+// deleteProcess stops the backend process associated to a model (if any) and forgets about the model.
+// It must be called with ml.mu held.
+func (ml *ModelLoader) deleteProcess(s string) {
+	if m, ok := ml.models[s]; ok && m != nil {
+		m.Close()
+	}
+	if p, ok := ml.grpcProcesses[s]; ok && p != nil {
+		if err := p.Stop(); err != nil {
+			log.Debug().Msgf("Failed stopping GRPC process for %s: %s", s, err.Error())
+		}
+		// the state directory holds the process logs and its socket
+		os.RemoveAll(p.StateDir())
+	}
+	delete(ml.grpcProcesses, s)
+	delete(ml.models, s)
+	delete(ml.lastUsed, s)
+	delete(ml.loadedAt, s)
+	delete(ml.backends, s)
+	delete(ml.busy, s)
+	delete(ml.busySince, s)
+	delete(ml.instances, s)
+	delete(ml.loaders, s)
+}
+
+// usedMemory returns the RSS of all the backend processes currently running.
+// It must be called with ml.mu held.
+func (ml *ModelLoader) usedMemory() uint64 {
+	var total uint64
+	for _, p := range ml.grpcProcesses {
+		total += processRSS(p)
+	}
+	return total
+}
+
+// leastRecentlyUsed returns the loaded model that was used the longest time ago, excluding the one given
+// and the ones that are currently serving a request.
+// It must be called with ml.mu held.
+func (ml *ModelLoader) leastRecentlyUsed(exclude string) (string, bool) {
+	var (
+		candidate string
+		oldest    time.Time
+		found     bool
+	)
+	for m := range ml.models {
+		if m == exclude || ml.busy[m] > 0 {
+			continue
+		}
+		t := ml.lastUsed[m]
+		if !found || t.Before(oldest) {
+			candidate, oldest, found = m, t, true
+		}
+	}
+	return candidate, found
+}
+
+// evictIfNeeded stops least recently used models until there is room to load modelName,
+// according to the configured maximum number of models and memory budget.
+// The model file size is used as an estimate of the memory the new model will need.
+// It must be called with ml.mu held.
+func (ml *ModelLoader) evictIfNeeded(modelName, modelFile string) {
+	if ml.maxLoadedModels <= 0 && ml.memoryBudget == 0 {
+		return
+	}
+
+	var estimate uint64
+	if ml.memoryBudget > 0 {
+		if info, err := os.Stat(modelFile); err == nil && !info.IsDir() {
+			estimate = uint64(info.Size())
+		}
+	}
+
+	for {
+		var reason string
+		used := ml.usedMemory()
+		switch {
+		case ml.maxLoadedModels > 0 && len(ml.models) >= ml.maxLoadedModels:
+			reason = fmt.Sprintf("max loaded models reached (%d)", ml.maxLoadedModels)
+		case ml.memoryBudget > 0 && used+estimate > ml.memoryBudget:
+			reason = fmt.Sprintf("memory budget exceeded (used: %d, needed: %d, budget: %d)", used, estimate, ml.memoryBudget)
+		default:
+			return
+		}
+
+		victim, ok := ml.leastRecentlyUsed(modelName)
+		if !ok {
+			log.Warn().Msgf("Cannot make room for model %s: %s, but there is no model left to evict", modelName, reason)
+			return
+		}
+
+		rss := processRSS(ml.grpcProcesses[victim])
+		log.Info().Msgf("Evicting model %s to load %s: %s", victim, modelName, reason)
+		ml.deleteProcess(victim)
+
+		ml.evictions = append(ml.evictions, Eviction{
+			Model:  victim,
+			Reason: reason,
+			RSS:    rss,
+			For:    modelName,
+			Time:   time.Now(),
+		})
+		if len(ml.evictions) > maxEvictionHistory {
+			ml.evictions = ml.evictions[len(ml.evictions)-maxEvictionHistory:]
+		}
+	}
+}
+
+// processRSS returns the resident set size of a process in bytes.
+// It relies on /proc, and returns 0 if the information is not available.
+func processRSS(p *process.Process) uint64 {
+	if p == nil || p.PID == "" {
+		return 0
+	}
+	dat, err := os.ReadFile(fmt.Sprintf("/proc/%s/statm", strings.TrimSpace(p.PID)))
+	if err != nil {
+		return 0
+	}
+	fields := strings.Fields(string(dat))
+	if len(fields) < 2 {
+		return 0
+	}
+	pages, err := strconv.ParseUint(fields[1], 10, 64)
+	if err != nil {
+		return 0
+	}
+	return pages * uint64(os.Getpagesize())
+}
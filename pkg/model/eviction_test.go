@@ -0,0 +1,138 @@
+package model
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	grpc "github.com/go-skynet/LocalAI/pkg/grpc"
+	process "github.com/mudler/go-processmanager"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Model eviction", func() {
+	var ml *ModelLoader
+
+	loader := func(modelName, modelFile string) (*grpc.Client, error) {
+		return grpc.NewClient("127.0.0.1:0"), nil
+	}
+
+	// processLoader starts a real process for each model, for its memory to be measured
+	processLoader := func(modelName, modelFile string) (*grpc.Client, error) {
+		p := process.New(
+			process.WithName("/bin/sleep"),
+			process.WithArgs("60"),
+			process.WithStateDir(filepath.Join(GinkgoT().TempDir(), modelName)),
+		)
+		if err := p.Run(); err != nil {
+			return nil, err
+		}
+		DeferCleanup(func() { p.Stop() })
+		// the loader runs with the lock held, as the gRPC loaders registering their process
+		ml.grpcProcesses[modelName] = p
+		return grpc.NewClient("127.0.0.1:0"), nil
+	}
+
+	load := func(loader func(string, string) (*grpc.Client, error), models ...string) {
+		for _, m := range models {
+			_, err := ml.LoadModel(m, loader)
+			Expect(err).ToNot(HaveOccurred())
+			// for the models to be ordered by their last use
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	loaded := func() []string {
+		ml.mu.Lock()
+		defer ml.mu.Unlock()
+		res := []string{}
+		for m := range ml.models {
+			res = append(res, m)
+		}
+		return res
+	}
+
+	BeforeEach(func() {
+		ml = NewModelLoader(GinkgoT().TempDir())
+	})
+
+	It("evicts nothing without limits", func() {
+		load(loader, "a", "b", "c")
+		Expect(loaded()).To(ConsistOf("a", "b", "c"))
+		Expect(ml.Evictions()).To(BeEmpty())
+	})
+
+	It("evicts the least recently used model over the maximum number of models", func() {
+		ml.SetMaxLoadedModels(2)
+		load(loader, "a", "b")
+		// using a again makes b the least recently used
+		load(loader, "a", "c")
+
+		Expect(loaded()).To(ConsistOf("a", "c"))
+		evictions := ml.Evictions()
+		Expect(evictions).To(HaveLen(1))
+		Expect(evictions[0].Model).To(Equal("b"))
+		Expect(evictions[0].For).To(Equal("c"))
+		Expect(evictions[0].Reason).To(Equal("max loaded models reached (2)"))
+		Expect(evictions[0].Time).ToNot(BeZero())
+	})
+
+	It("does not evict the models serving a request", func() {
+		ml.SetMaxLoadedModels(2)
+		load(loader, "a", "b")
+		idle := ml.MarkBusy("b")
+		ml.MarkBusy("a")
+		load(loader, "c")
+
+		// there is nothing to evict, the model is loaded anyway
+		Expect(loaded()).To(ConsistOf("a", "b", "c"))
+		Expect(ml.Evictions()).To(BeEmpty())
+
+		// b was used after c, when its request completed
+		idle()
+		load(loader, "d")
+		Expect(loaded()).To(ConsistOf("a", "d"))
+		evictions := ml.Evictions()
+		Expect(evictions).To(HaveLen(2))
+		Expect(evictions[0].Model).To(Equal("c"))
+		Expect(evictions[1].Model).To(Equal("b"))
+	})
+
+	It("evicts models until the new one fits in the memory budget", func() {
+		load(processLoader, "a", "b")
+		ml.mu.Lock()
+		pa := ml.grpcProcesses["a"]
+		rssA, rssB := processRSS(pa), processRSS(ml.grpcProcesses["b"])
+		ml.mu.Unlock()
+		Expect(rssA).ToNot(BeZero())
+
+		// the size of the model file estimates the memory it needs: evicting a makes room for it
+		const size = 1024 * 1024
+		Expect(os.WriteFile(filepath.Join(ml.ModelPath, "c"), make([]byte, size), 0644)).To(Succeed())
+		ml.SetMemoryBudget(rssA + rssB + size - 1)
+		load(loader, "c")
+
+		Expect(loaded()).To(ConsistOf("b", "c"))
+		Eventually(pa.IsAlive).Should(BeFalse())
+		evictions := ml.Evictions()
+		Expect(evictions).To(HaveLen(1))
+		Expect(evictions[0].Model).To(Equal("a"))
+		Expect(evictions[0].RSS).To(Equal(rssA))
+		Expect(evictions[0].Reason).To(HavePrefix("memory budget exceeded"))
+	})
+
+	It("keeps the most recent evictions", func() {
+		ml.SetMaxLoadedModels(1)
+		for i := 0; i <= maxEvictionHistory+5; i++ {
+			_, err := ml.LoadModel(fmt.Sprintf("model-%d", i), loader)
+			Expect(err).ToNot(HaveOccurred())
+		}
+
+		evictions := ml.Evictions()
+		Expect(evictions).To(HaveLen(maxEvictionHistory))
+		Expect(evictions[0].Model).To(Equal("model-5"))
+		Expect(evictions[maxEvictionHistory-1].Model).To(Equal(fmt.Sprintf("model-%d", maxEvictionHistory+4)))
+	})
+})
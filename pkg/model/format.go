@@ -0,0 +1,159 @@
+package model
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ModelFormat is the format of a model file, as detected from its header
+type ModelFormat string
+
+const (
+	FormatUnknown     ModelFormat = ""
+	FormatGGML        ModelFormat = "ggml"
+	FormatGGMF        ModelFormat = "ggmf"
+	FormatGGJT        ModelFormat = "ggjt"
+	FormatGGCC        ModelFormat = "ggcc"
+	FormatGGUF        ModelFormat = "gguf"
+	FormatWhisperGGML ModelFormat = "whisper-ggml"
+	FormatRWKV        ModelFormat = "rwkv"
+	FormatONNX        ModelFormat = "onnx"
+	FormatPiper       ModelFormat = "piper"
+	FormatSafetensors ModelFormat = "safetensors"
+)
+
+// File magics, as read in little endian from the first 4 bytes of the file
+const (
+	magicGGML uint32 = 0x67676d6c // "lmgg"
+	magicGGMF uint32 = 0x67676d66 // "fmgg"
+	magicGGJT uint32 = 0x67676a74 // "tjgg"
+	magicGGCC uint32 = 0x67676363 // "ccgg", used by ggllm.cpp for falcon models
+	magicGGUF uint32 = 0x46554747 // "GGUF"
+)
+
+// whisperAudioCtx is the size of the audio context of all the whisper models.
+// It follows the vocabulary size in the header of whisper ggml files, where the
+// llama-like models have the embedding size instead.
+const whisperAudioCtx = 1500
+
+// rwkvMinVersion is the first file version of rwkv.cpp, which uses the GGMF magic
+const rwkvMinVersion = 100
+
+// FileFormat is the detected format of a model file
+type FileFormat struct {
+	Format  ModelFormat `json:"format"`
+	Version uint32      `json:"version,omitempty"`
+}
+
+// formatBackends lists, in order of preference, the backends able to load each format
+var formatBackends = map[ModelFormat][]string{
+	FormatGGUF:        {LlamaBackend},
+	FormatGGJT:        {LlamaBackend, Gpt4All},
+	FormatGGMF:        {LlamaBackend, Gpt4All},
+	FormatGGCC:        {FalconBackend},
+	FormatWhisperGGML: {WhisperBackend},
+	FormatRWKV:        {RwkvBackend},
+	FormatPiper:       {PiperBackend},
+	FormatGGML: {
+		Gpt4All,
+		FalconGGMLBackend,
+		GPTNeoXBackend,
+		BertEmbeddingsBackend,
+		GPTJBackend,
+		Gpt2Backend,
+		DollyBackend,
+		MPTBackend,
+		ReplitBackend,
+		StarcoderBackend,
+		BloomzBackend,
+	},
+	// No built-in backend loads these, they are served by external backends only
+	FormatONNX:        {},
+	FormatSafetensors: {},
+}
+
+// BackendsForFormat returns the built-in backends that can load a model of the given format,
+// or nil if the format is unknown
+func BackendsForFormat(f ModelFormat) []string {
+	return formatBackends[f]
+}
+
+// DetectFormat sniffs the header of a model file to find its format.
+// It returns FormatUnknown if the format is not recognized, or if the path is not a regular file
+// (for instance, models made of several files in a directory).
+func DetectFormat(path string) (FileFormat, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return FileFormat{}, err
+	}
+	if !info.Mode().IsRegular() {
+		return FileFormat{}, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return FileFormat{}, err
+	}
+	defer f.Close()
+
+	header := make([]byte, 16)
+	n, err := io.ReadFull(f, header)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return FileFormat{}, err
+	}
+	header = header[:n]
+
+	if strings.EqualFold(filepath.Ext(path), ".onnx") && len(header) > 0 && header[0] == 0x08 {
+		// ONNX files are protobuf messages starting with the ir_version varint (field 1)
+		if _, err := os.Stat(path + ".json"); err == nil {
+			return FileFormat{Format: FormatPiper}, nil
+		}
+		return FileFormat{Format: FormatONNX}, nil
+	}
+
+	if len(header) < 8 {
+		return FileFormat{}, nil
+	}
+
+	if isSafetensors(header, info.Size()) {
+		return FileFormat{Format: FormatSafetensors}, nil
+	}
+
+	magic := binary.LittleEndian.Uint32(header[0:4])
+	version := binary.LittleEndian.Uint32(header[4:8])
+	switch magic {
+	case magicGGUF:
+		return FileFormat{Format: FormatGGUF, Version: version}, nil
+	case magicGGJT:
+		return FileFormat{Format: FormatGGJT, Version: version}, nil
+	case magicGGCC:
+		return FileFormat{Format: FormatGGCC, Version: version}, nil
+	case magicGGMF:
+		if version >= rwkvMinVersion {
+			return FileFormat{Format: FormatRWKV, Version: version}, nil
+		}
+		return FileFormat{Format: FormatGGMF, Version: version}, nil
+	case magicGGML:
+		// unversioned: the hyperparameters follow the magic
+		if len(header) >= 12 && binary.LittleEndian.Uint32(header[8:12]) == whisperAudioCtx {
+			return FileFormat{Format: FormatWhisperGGML}, nil
+		}
+		return FileFormat{Format: FormatGGML}, nil
+	}
+
+	return FileFormat{}, nil
+}
+
+// isSafetensors checks for the safetensors header: the size of the JSON metadata
+// as a little endian uint64, followed by the metadata itself
+func isSafetensors(header []byte, size int64) bool {
+	if len(header) < 9 {
+		return false
+	}
+	n := binary.LittleEndian.Uint64(header[0:8])
+	return n > 0 && n < uint64(size) && header[8] == '{'
+}
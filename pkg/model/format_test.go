@@ -0,0 +1,77 @@
+package model_test
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+
+	. "github.com/go-skynet/LocalAI/pkg/model"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func header(values ...uint32) []byte {
+	b := make([]byte, 4*len(values))
+	for i, v := range values {
+		binary.LittleEndian.PutUint32(b[4*i:], v)
+	}
+	return append(b, make([]byte, 64)...)
+}
+
+var _ = Describe("Model format detection", func() {
+	var dir string
+
+	BeforeEach(func() {
+		var err error
+		dir, err = os.MkdirTemp("", "")
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(os.RemoveAll, dir)
+	})
+
+	write := func(name string, content []byte) string {
+		p := filepath.Join(dir, name)
+		Expect(os.WriteFile(p, content, 0600)).To(Succeed())
+		return p
+	}
+
+	DescribeTable("recognizes the file headers",
+		func(name string, content []byte, expected FileFormat) {
+			format, err := DetectFormat(write(name, content))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(format).To(Equal(expected))
+		},
+		Entry("gguf", "model.gguf", append([]byte("GGUF"), header(2)...), FileFormat{Format: FormatGGUF, Version: 2}),
+		Entry("ggjt", "model.bin", header(0x67676a74, 3), FileFormat{Format: FormatGGJT, Version: 3}),
+		Entry("ggmf", "model.bin", header(0x67676d66, 1), FileFormat{Format: FormatGGMF, Version: 1}),
+		Entry("rwkv", "model.bin", header(0x67676d66, 101), FileFormat{Format: FormatRWKV, Version: 101}),
+		Entry("ggcc", "model.bin", header(0x67676363, 1), FileFormat{Format: FormatGGCC, Version: 1}),
+		Entry("ggml", "model.bin", header(0x67676d6c, 50400, 2048), FileFormat{Format: FormatGGML}),
+		Entry("whisper ggml", "ggml-base.bin", header(0x67676d6c, 51865, 1500), FileFormat{Format: FormatWhisperGGML}),
+		Entry("onnx", "model.onnx", []byte{0x08, 0x07, 0x12, 0x00}, FileFormat{Format: FormatONNX}),
+		Entry("safetensors", "model.safetensors", append([]byte{2, 0, 0, 0, 0, 0, 0, 0}, []byte("{}")...), FileFormat{Format: FormatSafetensors}),
+		Entry("unknown", "model.bin", []byte("hello world, this is not a model"), FileFormat{}),
+		Entry("short files", "model.bin", []byte("GG"), FileFormat{}),
+	)
+
+	It("recognizes piper voices by their configuration", func() {
+		p := write("voice.onnx", []byte{0x08, 0x07, 0x12, 0x00})
+		write("voice.onnx.json", []byte("{}"))
+
+		format, err := DetectFormat(p)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(format.Format).To(Equal(FormatPiper))
+		Expect(BackendsForFormat(format.Format)).To(Equal([]string{PiperBackend}))
+	})
+
+	It("does not recognize directories", func() {
+		format, err := DetectFormat(dir)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(format.Format).To(Equal(FormatUnknown))
+		Expect(BackendsForFormat(format.Format)).To(BeNil())
+	})
+
+	It("fails on missing files", func() {
+		_, err := DetectFormat(filepath.Join(dir, "missing"))
+		Expect(err).To(HaveOccurred())
+	})
+})
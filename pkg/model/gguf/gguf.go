@@ -0,0 +1,399 @@
+// Package gguf reads the metadata stored in the header of GGUF model files,
+// without loading the tensors.
+package gguf
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+)
+
+// Magic is the first 4 bytes of a GGUF file ("GGUF" in little endian)
+const Magic uint32 = 0x46554747
+
+// ErrNotGGUF is returned when the file does not start with the GGUF magic
+var ErrNotGGUF = errors.New("not a GGUF file")
+
+// maxStringLength and maxArrayLength guard against allocating huge buffers on corrupted files:
+// the largest vocabularies have a few hundred thousand tokens.
+// maxArrayDepth bounds the nesting of the arrays, which are skipped recursively: the models don't nest them.
+const (
+	maxStringLength = 16 * 1024 * 1024
+	maxArrayLength  = 16 * 1024 * 1024
+	maxArrayDepth   = 16
+)
+
+// Metadata value types
+const (
+	typeUint8 uint32 = iota
+	typeInt8
+	typeUint16
+	typeInt16
+	typeUint32
+	typeInt32
+	typeFloat32
+	typeBool
+	typeString
+	typeArray
+	typeUint64
+	typeInt64
+	typeFloat64
+)
+
+// Metadata is the subset of the GGUF header LocalAI is interested in
+type Metadata struct {
+	Version     uint32 `json:"version"`
+	TensorCount uint64 `json:"tensor_count"`
+
+	Architecture string `json:"architecture,omitempty"`
+	Name         string `json:"name,omitempty"`
+	FileType     uint32 `json:"file_type"`
+	Quantization string `json:"quantization,omitempty"`
+
+	ContextLength   uint64  `json:"context_length,omitempty"`
+	EmbeddingLength uint64  `json:"embedding_length,omitempty"`
+	BlockCount      uint64  `json:"block_count,omitempty"`
+	HeadCount       uint64  `json:"head_count,omitempty"`
+	HeadCountKV     uint64  `json:"head_count_kv,omitempty"`
+	RopeFreqBase    float32 `json:"rope_freq_base,omitempty"`
+	RopeScaleLinear float32 `json:"rope_scale_linear,omitempty"`
+
+	Tokenizer    string `json:"tokenizer,omitempty"`
+	ChatTemplate string `json:"chat_template,omitempty"`
+	// BosToken and EosToken are the text of the beginning and end of sequence tokens, as used by the chat templates
+	BosToken string `json:"bos_token,omitempty"`
+	EosToken string `json:"eos_token,omitempty"`
+
+	// KV holds all the scalar values of the header. Arrays (like the vocabulary) are skipped.
+	KV map[string]interface{} `json:"-"`
+}
+
+// GQA returns the number of query heads sharing a key/value head (grouped-query attention), or 0 if unknown
+func (m *Metadata) GQA() int {
+	if m.HeadCount == 0 || m.HeadCountKV == 0 {
+		return 0
+	}
+	return int(m.HeadCount / m.HeadCountKV)
+}
+
+// fileTypes are the names of the values of general.file_type
+var fileTypes = map[uint32]string{
+	0:  "F32",
+	1:  "F16",
+	2:  "Q4_0",
+	3:  "Q4_1",
+	4:  "Q4_1_SOME_F16",
+	7:  "Q8_0",
+	8:  "Q5_0",
+	9:  "Q5_1",
+	10: "Q2_K",
+	11: "Q3_K_S",
+	12: "Q3_K_M",
+	13: "Q3_K_L",
+	14: "Q4_K_S",
+	15: "Q4_K_M",
+	16: "Q5_K_S",
+	17: "Q5_K_M",
+	18: "Q6_K",
+}
+
+// ReadFile reads the metadata of the GGUF file at path
+func ReadFile(path string) (*Metadata, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return Read(f)
+}
+
+// Read reads the metadata from the header of a GGUF file. It stops before the tensor infos.
+func Read(r io.Reader) (*Metadata, error) {
+	d := &decoder{r: bufio.NewReaderSize(r, 64*1024)}
+
+	if magic := d.uint32(); d.err != nil || magic != Magic {
+		if d.err != nil && !errors.Is(d.err, io.EOF) && !errors.Is(d.err, io.ErrUnexpectedEOF) {
+			return nil, d.err
+		}
+		return nil, ErrNotGGUF
+	}
+
+	m := &Metadata{KV: map[string]interface{}{}}
+	m.Version = d.uint32()
+	switch m.Version {
+	case 1:
+		// version 1 used 32 bit lengths and counts
+		d.v1 = true
+	case 2, 3:
+	default:
+		if d.err == nil {
+			return nil, fmt.Errorf("unsupported GGUF version %d", m.Version)
+		}
+	}
+
+	m.TensorCount = d.count()
+	kvCount := d.count()
+	// the vocabulary is only kept to look up the special tokens
+	var tokens []string
+	for i := uint64(0); i < kvCount && d.err == nil; i++ {
+		key := d.string()
+		t := d.uint32()
+		if t == typeArray && key == "tokenizer.ggml.tokens" {
+			tokens = d.stringArray()
+			continue
+		}
+		if t == typeArray {
+			d.skipArray(1)
+			continue
+		}
+		if v := d.value(t); d.err == nil {
+			m.KV[key] = v
+		}
+	}
+	if d.err != nil {
+		return nil, fmt.Errorf("invalid GGUF header: %w", d.err)
+	}
+
+	m.fill()
+	m.BosToken = token(tokens, m.KV["tokenizer.ggml.bos_token_id"])
+	m.EosToken = token(tokens, m.KV["tokenizer.ggml.eos_token_id"])
+	return m, nil
+}
+
+func token(tokens []string, id interface{}) string {
+	if i, ok := toUint64(id); ok && i < uint64(len(tokens)) {
+		return tokens[i]
+	}
+	return ""
+}
+
+// fill sets the well-known fields from the key/values
+func (m *Metadata) fill() {
+	m.Architecture, _ = m.KV["general.architecture"].(string)
+	m.Name, _ = m.KV["general.name"].(string)
+	if v, ok := toUint64(m.KV["general.file_type"]); ok {
+		m.FileType = uint32(v)
+		m.Quantization = fileTypes[m.FileType]
+	}
+
+	arch := m.Architecture
+	m.ContextLength, _ = toUint64(m.KV[arch+".context_length"])
+	m.EmbeddingLength, _ = toUint64(m.KV[arch+".embedding_length"])
+	m.BlockCount, _ = toUint64(m.KV[arch+".block_count"])
+	m.HeadCount, _ = toUint64(m.KV[arch+".attention.head_count"])
+	m.HeadCountKV, _ = toUint64(m.KV[arch+".attention.head_count_kv"])
+	if v, ok := m.KV[arch+".rope.freq_base"].(float32); ok {
+		m.RopeFreqBase = v
+	}
+	if v, ok := m.KV[arch+".rope.scale_linear"].(float32); ok {
+		m.RopeScaleLinear = v
+	}
+
+	m.Tokenizer, _ = m.KV["tokenizer.ggml.model"].(string)
+	m.ChatTemplate, _ = m.KV["tokenizer.chat_template"].(string)
+}
+
+func toUint64(v interface{}) (uint64, bool) {
+	switch n := v.(type) {
+	case uint8:
+		return uint64(n), true
+	case int8:
+		return uint64(n), n >= 0
+	case uint16:
+		return uint64(n), true
+	case int16:
+		return uint64(n), n >= 0
+	case uint32:
+		return uint64(n), true
+	case int32:
+		return uint64(n), n >= 0
+	case uint64:
+		return n, true
+	case int64:
+		return uint64(n), n >= 0
+	}
+	return 0, false
+}
+
+// decoder reads little endian values, keeping the first error encountered
+type decoder struct {
+	r   *bufio.Reader
+	v1  bool
+	err error
+	buf [8]byte
+}
+
+func (d *decoder) read(n int) []byte {
+	if d.err != nil {
+		return d.buf[:n]
+	}
+	_, d.err = io.ReadFull(d.r, d.buf[:n])
+	return d.buf[:n]
+}
+
+func (d *decoder) uint32() uint32 {
+	return binary.LittleEndian.Uint32(d.read(4))
+}
+
+func (d *decoder) uint64() uint64 {
+	return binary.LittleEndian.Uint64(d.read(8))
+}
+
+// count reads a length or a count, which are 64 bit since version 2
+func (d *decoder) count() uint64 {
+	if d.v1 {
+		return uint64(d.uint32())
+	}
+	return d.uint64()
+}
+
+func (d *decoder) string() string {
+	n := d.count()
+	if d.err != nil {
+		return ""
+	}
+	if n > maxStringLength {
+		d.err = fmt.Errorf("string too long (%d bytes)", n)
+		return ""
+	}
+	b := make([]byte, n)
+	_, d.err = io.ReadFull(d.r, b)
+	return string(b)
+}
+
+func (d *decoder) skip(n uint64) {
+	if d.err != nil {
+		return
+	}
+	if n > math.MaxInt64 {
+		d.err = fmt.Errorf("invalid length %d", n)
+		return
+	}
+	_, d.err = io.CopyN(io.Discard, d.r, int64(n))
+}
+
+// skipValues skips n values of a fixed size, checking that their total size doesn't overflow
+func (d *decoder) skipValues(size, n uint64) {
+	if d.err != nil {
+		return
+	}
+	if n > math.MaxInt64/size {
+		d.err = fmt.Errorf("array too large (%d items of %d bytes)", n, size)
+		return
+	}
+	d.skip(size * n)
+}
+
+// size returns the size of the values of fixed-size types, or 0
+func size(t uint32) uint64 {
+	switch t {
+	case typeUint8, typeInt8, typeBool:
+		return 1
+	case typeUint16, typeInt16:
+		return 2
+	case typeUint32, typeInt32, typeFloat32:
+		return 4
+	case typeUint64, typeInt64, typeFloat64:
+		return 8
+	}
+	return 0
+}
+
+// skipArray skips an array nested at the given depth
+func (d *decoder) skipArray(depth int) {
+	if depth > maxArrayDepth {
+		d.err = fmt.Errorf("arrays nested too deeply (more than %d levels)", maxArrayDepth)
+		return
+	}
+	t := d.uint32()
+	n := d.count()
+	if d.err != nil {
+		return
+	}
+	if s := size(t); s > 0 {
+		d.skipValues(s, n)
+		return
+	}
+	for i := uint64(0); i < n && d.err == nil; i++ {
+		switch t {
+		case typeString:
+			d.skip(d.count())
+		case typeArray:
+			d.skipArray(depth + 1)
+		default:
+			d.err = fmt.Errorf("unknown value type %d", t)
+		}
+	}
+}
+
+// stringArray reads an array, which is skipped if it doesn't hold strings
+func (d *decoder) stringArray() []string {
+	t := d.uint32()
+	n := d.count()
+	if d.err != nil {
+		return nil
+	}
+	if t != typeString {
+		if s := size(t); s > 0 {
+			d.skipValues(s, n)
+		} else {
+			d.err = fmt.Errorf("unexpected array of type %d", t)
+		}
+		return nil
+	}
+	if n > maxArrayLength {
+		d.err = fmt.Errorf("array too long (%d items)", n)
+		return nil
+	}
+	// the slice grows as the strings are read, for a truncated file not to allocate the whole count
+	res := make([]string, 0, minCount(n, 64*1024))
+	for i := uint64(0); i < n && d.err == nil; i++ {
+		res = append(res, d.string())
+	}
+	return res
+}
+
+func minCount(a, b uint64) uint64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func (d *decoder) value(t uint32) interface{} {
+	switch t {
+	case typeUint8:
+		return d.read(1)[0]
+	case typeInt8:
+		return int8(d.read(1)[0])
+	case typeUint16:
+		return binary.LittleEndian.Uint16(d.read(2))
+	case typeInt16:
+		return int16(binary.LittleEndian.Uint16(d.read(2)))
+	case typeUint32:
+		return d.uint32()
+	case typeInt32:
+		return int32(d.uint32())
+	case typeFloat32:
+		return math.Float32frombits(d.uint32())
+	case typeBool:
+		return d.read(1)[0] != 0
+	case typeString:
+		return d.string()
+	case typeUint64:
+		return d.uint64()
+	case typeInt64:
+		return int64(d.uint64())
+	case typeFloat64:
+		return math.Float64frombits(d.uint64())
+	}
+	if d.err == nil {
+		d.err = fmt.Errorf("unknown value type %d", t)
+	}
+	return nil
+}
@@ -0,0 +1,208 @@
+package gguf_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+
+	. "github.com/go-skynet/LocalAI/pkg/model/gguf"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// writer builds GGUF headers for the tests
+type writer struct {
+	bytes.Buffer
+	v1 bool
+}
+
+func (w *writer) u32(v uint32) { binary.Write(w, binary.LittleEndian, v) }
+func (w *writer) u64(v uint64) { binary.Write(w, binary.LittleEndian, v) }
+
+func (w *writer) count(n int) {
+	if w.v1 {
+		w.u32(uint32(n))
+	} else {
+		w.u64(uint64(n))
+	}
+}
+
+func (w *writer) str(s string) {
+	w.count(len(s))
+	w.WriteString(s)
+}
+
+func (w *writer) kvString(k, v string) {
+	w.str(k)
+	w.u32(8)
+	w.str(v)
+}
+
+func (w *writer) kvUint32(k string, v uint32) {
+	w.str(k)
+	w.u32(4)
+	w.u32(v)
+}
+
+func (w *writer) kvFloat32(k string, v float32) {
+	w.str(k)
+	w.u32(6)
+	w.u32(math.Float32bits(v))
+}
+
+func (w *writer) kvStringArray(k string, v []string) {
+	w.str(k)
+	w.u32(9)
+	w.u32(8)
+	w.count(len(v))
+	for _, s := range v {
+		w.str(s)
+	}
+}
+
+func (w *writer) kvFloat32Array(k string, v []float32) {
+	w.str(k)
+	w.u32(9)
+	w.u32(6)
+	w.count(len(v))
+	for _, f := range v {
+		w.u32(math.Float32bits(f))
+	}
+}
+
+func llamaHeader(version uint32) []byte {
+	w := &writer{v1: version == 1}
+	w.u32(Magic)
+	w.u32(version)
+	w.count(291)
+	w.count(13)
+	w.kvString("general.architecture", "llama")
+	w.kvString("general.name", "LLaMA v2")
+	w.kvUint32("general.file_type", 15)
+	w.kvUint32("llama.context_length", 4096)
+	w.kvUint32("llama.embedding_length", 8192)
+	w.kvUint32("llama.block_count", 80)
+	w.kvUint32("llama.attention.head_count", 64)
+	w.kvUint32("llama.attention.head_count_kv", 8)
+	w.kvFloat32("llama.rope.freq_base", 10000)
+	w.kvStringArray("tokenizer.ggml.tokens", []string{"<unk>", "<s>", "</s>"})
+	w.kvFloat32Array("tokenizer.ggml.scores", []float32{0, 0, 0})
+	w.kvUint32("tokenizer.ggml.bos_token_id", 1)
+	w.kvUint32("tokenizer.ggml.eos_token_id", 2)
+	// tensor infos follow, which are not read
+	w.WriteString("tensors")
+	return w.Bytes()
+}
+
+var _ = Describe("GGUF header", func() {
+	DescribeTable("reads the metadata",
+		func(version uint32) {
+			m, err := Read(bytes.NewReader(llamaHeader(version)))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(m.Version).To(Equal(version))
+			Expect(m.TensorCount).To(Equal(uint64(291)))
+			Expect(m.Architecture).To(Equal("llama"))
+			Expect(m.Name).To(Equal("LLaMA v2"))
+			Expect(m.Quantization).To(Equal("Q4_K_M"))
+			Expect(m.ContextLength).To(Equal(uint64(4096)))
+			Expect(m.EmbeddingLength).To(Equal(uint64(8192)))
+			Expect(m.BlockCount).To(Equal(uint64(80)))
+			Expect(m.RopeFreqBase).To(Equal(float32(10000)))
+			Expect(m.GQA()).To(Equal(8))
+			Expect(m.KV).ToNot(HaveKey("tokenizer.ggml.tokens"))
+			Expect(m.BosToken).To(Equal("<s>"))
+			Expect(m.EosToken).To(Equal("</s>"))
+		},
+		Entry("version 1", uint32(1)),
+		Entry("version 2", uint32(2)),
+	)
+
+	It("reads the tokenizer and the chat template", func() {
+		w := &writer{}
+		w.u32(Magic)
+		w.u32(2)
+		w.count(0)
+		w.count(2)
+		w.kvString("tokenizer.ggml.model", "llama")
+		w.kvString("tokenizer.chat_template", "{{ messages }}")
+
+		m, err := Read(bytes.NewReader(w.Bytes()))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(m.Tokenizer).To(Equal("llama"))
+		Expect(m.ChatTemplate).To(Equal("{{ messages }}"))
+		Expect(m.GQA()).To(Equal(0))
+	})
+
+	It("rejects other files", func() {
+		_, err := Read(bytes.NewReader([]byte("tjgg\x03\x00\x00\x00")))
+		Expect(err).To(Equal(ErrNotGGUF))
+		_, err = Read(bytes.NewReader([]byte{}))
+		Expect(err).To(Equal(ErrNotGGUF))
+	})
+
+	It("fails on truncated headers", func() {
+		header := llamaHeader(2)
+		_, err := Read(bytes.NewReader(header[:100]))
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("fails on corrupted counts without allocating them", func() {
+		header := func(key string, t uint32, n uint64) []byte {
+			w := &writer{}
+			w.u32(Magic)
+			w.u32(2)
+			w.count(0)
+			w.count(1)
+			w.str(key)
+			w.u32(9)
+			w.u32(t)
+			w.u64(n)
+			return w.Bytes()
+		}
+
+		_, err := Read(bytes.NewReader(header("tokenizer.ggml.tokens", 8, math.MaxUint64)))
+		Expect(err).To(MatchError(ContainSubstring("array too long")))
+		_, err = Read(bytes.NewReader(header("tokenizer.ggml.tokens", 8, 1000)))
+		Expect(err).To(MatchError(ContainSubstring("invalid GGUF header")))
+		_, err = Read(bytes.NewReader(header("tokenizer.ggml.tokens", 6, math.MaxUint64)))
+		Expect(err).To(MatchError(ContainSubstring("array too large")))
+		_, err = Read(bytes.NewReader(header("tokenizer.ggml.scores", 10, math.MaxUint64/4)))
+		Expect(err).To(MatchError(ContainSubstring("array too large")))
+		_, err = Read(bytes.NewReader(header("tokenizer.ggml.merges", 8, 1)))
+		Expect(err).To(MatchError(ContainSubstring("invalid GGUF header")))
+	})
+
+	It("fails on deeply nested arrays", func() {
+		header := func(depth int) []byte {
+			w := &writer{}
+			w.u32(Magic)
+			w.u32(2)
+			w.count(0)
+			w.count(1)
+			w.str("general.nested")
+			w.u32(9)
+			for i := 1; i < depth; i++ {
+				w.u32(9)
+				w.count(1)
+			}
+			w.u32(4)
+			w.count(0)
+			return w.Bytes()
+		}
+
+		_, err := Read(bytes.NewReader(header(16)))
+		Expect(err).ToNot(HaveOccurred())
+		_, err = Read(bytes.NewReader(header(17)))
+		Expect(err).To(MatchError(ContainSubstring("arrays nested too deeply")))
+		_, err = Read(bytes.NewReader(header(1000000)))
+		Expect(err).To(MatchError(ContainSubstring("arrays nested too deeply")))
+	})
+
+	It("fails on unsupported versions", func() {
+		w := &writer{}
+		w.u32(Magic)
+		w.u32(42)
+		_, err := Read(bytes.NewReader(w.Bytes()))
+		Expect(err).To(MatchError(ContainSubstring("unsupported GGUF version")))
+	})
+})
@@ -2,19 +2,20 @@ package model
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
-	"os/signal"
 	"path/filepath"
 	"strings"
-	"syscall"
 	"time"
 
 	grpc "github.com/go-skynet/LocalAI/pkg/grpc"
+	pb "github.com/go-skynet/LocalAI/pkg/grpc/proto"
 	"github.com/hashicorp/go-multierror"
 	"github.com/hpcloud/tail"
 	"github.com/phayes/freeport"
 	"github.com/rs/zerolog/log"
+	"google.golang.org/protobuf/proto"
 
 	process "github.com/mudler/go-processmanager"
 )
@@ -64,24 +65,41 @@ var AutoLoadBackends []string = []string{
 	PiperBackend,
 }
 
+// StopGRPC closes the connections to the backends and stops their processes
 func (ml *ModelLoader) StopGRPC() {
+	ml.mu.Lock()
+	defer ml.mu.Unlock()
+	for _, m := range ml.models {
+		m.Close()
+	}
 	for _, p := range ml.grpcProcesses {
 		p.Stop()
 	}
 }
 
-func (ml *ModelLoader) startProcess(grpcProcess, id string, serverAddress string) error {
+// startProcess starts the backend process and returns the address it listens on
+func (ml *ModelLoader) startProcess(grpcProcess, id string) (string, error) {
 	// Make sure the process is executable
 	if err := os.Chmod(grpcProcess, 0755); err != nil {
-		return err
+		return "", err
 	}
 
 	log.Debug().Msgf("Loading GRPC Process: %s", grpcProcess)
 
+	stateDir, err := os.MkdirTemp("", "go-processmanager")
+	if err != nil {
+		return "", err
+	}
+
+	serverAddress, err := ml.backendAddress(stateDir)
+	if err != nil {
+		return "", err
+	}
+
 	log.Debug().Msgf("GRPC Service for %s will be running at: '%s'", id, serverAddress)
 
 	grpcControlProcess := process.New(
-		process.WithTemporaryStateDir(),
+		process.WithStateDir(stateDir),
 		process.WithName(grpcProcess),
 		process.WithArgs("--addr", serverAddress),
 		process.WithEnvironment(os.Environ()...),
@@ -90,18 +108,10 @@ func (ml *ModelLoader) startProcess(grpcProcess, id string, serverAddress string
 	ml.grpcProcesses[id] = grpcControlProcess
 
 	if err := grpcControlProcess.Run(); err != nil {
-		return err
+		return "", err
 	}
 
 	log.Debug().Msgf("GRPC Service state dir: %s", grpcControlProcess.StateDir())
-	// clean up process
-	go func() {
-		c := make(chan os.Signal, 1)
-		signal.Notify(c, os.Interrupt, syscall.SIGTERM)
-		<-c
-		grpcControlProcess.Stop()
-	}()
-
 	go func() {
 		t, err := tail.TailFile(grpcControlProcess.StderrPath(), tail.Config{Follow: true})
 		if err != nil {
@@ -121,36 +131,41 @@ func (ml *ModelLoader) startProcess(grpcProcess, id string, serverAddress string
 		}
 	}()
 
-	return nil
+	return serverAddress, nil
+}
+
+// backendAddress returns the address a locally spawned backend should listen on.
+// By default, this is a Unix socket in the process state directory, which only the
+// current user can access and that doesn't need to race for free ports.
+func (ml *ModelLoader) backendAddress(stateDir string) (string, error) {
+	if ml.tcpTransport {
+		port, err := freeport.GetFreePort()
+		if err != nil {
+			return "", fmt.Errorf("failed allocating free ports: %s", err.Error())
+		}
+		return fmt.Sprintf("127.0.0.1:%d", port), nil
+	}
+	return "unix://" + filepath.Join(stateDir, "grpc.sock"), nil
 }
 
 // starts the grpcModelProcess for the backend, and returns a grpc client
 // It also loads the model
 func (ml *ModelLoader) grpcModel(backend string, o *Options) func(string, string) (*grpc.Client, error) {
 	return func(modelName, modelFile string) (*grpc.Client, error) {
-		log.Debug().Msgf("Loading GRPC Model %s: %+v", backend, *o)
+		log.Debug().Msgf("Loading GRPC Model %s: %s", backend, o.model)
 
-		var client *grpc.Client
+		// grpcModel is called by LoadModel, which already holds the lock:
+		// the processes of failed attempts are cleaned up directly with deleteProcess
 
-		getFreeAddress := func() (string, error) {
-			port, err := freeport.GetFreePort()
-			if err != nil {
-				return "", fmt.Errorf("failed allocating free ports: %s", err.Error())
-			}
-			return fmt.Sprintf("127.0.0.1:%d", port), nil
-		}
+		var client *grpc.Client
 
 		// Check if the backend is provided as external
 		if uri, ok := o.externalBackends[backend]; ok {
-			log.Debug().Msgf("Loading external backend: %s", uri)
+			log.Debug().Msgf("Loading external backend: %s", backend)
 			// check if uri is a file or a address
 			if _, err := os.Stat(uri); err == nil {
-				serverAddress, err := getFreeAddress()
+				serverAddress, err := ml.startProcess(uri, o.model)
 				if err != nil {
-					return nil, fmt.Errorf("failed allocating free ports: %s", err.Error())
-				}
-				// Make sure the process is executable
-				if err := ml.startProcess(uri, o.model, serverAddress); err != nil {
 					return nil, err
 				}
 
@@ -158,8 +173,12 @@ func (ml *ModelLoader) grpcModel(backend string, o *Options) func(string, string
 
 				client = grpc.NewClient(serverAddress)
 			} else {
-				// address
-				client = grpc.NewClient(uri)
+				// address, optionally with the options to authenticate
+				address, clientOpts, err := grpc.ParseURI(uri)
+				if err != nil {
+					return nil, err
+				}
+				client = grpc.NewClientWithOptions(address, clientOpts)
 			}
 		} else {
 			grpcProcess := filepath.Join(o.assetDir, "backend-assets", "grpc", backend)
@@ -168,13 +187,8 @@ func (ml *ModelLoader) grpcModel(backend string, o *Options) func(string, string
 				return nil, fmt.Errorf("grpc process not found: %s. some backends(stablediffusion, tts) require LocalAI compiled with GO_TAGS", grpcProcess)
 			}
 
-			serverAddress, err := getFreeAddress()
+			serverAddress, err := ml.startProcess(grpcProcess, o.model)
 			if err != nil {
-				return nil, fmt.Errorf("failed allocating free ports: %s", err.Error())
-			}
-
-			// Make sure the process is executable
-			if err := ml.startProcess(grpcProcess, o.model, serverAddress); err != nil {
 				return nil, err
 			}
 
@@ -196,23 +210,40 @@ func (ml *ModelLoader) grpcModel(backend string, o *Options) func(string, string
 
 		if !ready {
 			log.Debug().Msgf("GRPC Service NOT ready")
+			client.Close()
+			ml.deleteProcess(modelName)
 			return nil, fmt.Errorf("grpc service not ready")
 		}
 
-		options := *o.gRPCOptions
+		if o.capability != "" && !client.Supports(o.context, o.capability) {
+			log.Debug().Msgf("GRPC Service for %s does not support %s", backend, o.capability)
+			client.Close()
+			ml.deleteProcess(modelName)
+			return nil, grpc.NotSupportedError(backend, o.capability)
+		}
+
+		// the options are cloned, as the messages hold a lock and must not be copied
+		options := proto.Clone(o.gRPCOptions).(*pb.ModelOptions)
 		options.Model = modelName
 		options.ModelFile = modelFile
 
 		log.Debug().Msgf("GRPC: Loading model with options: %+v", options)
 
-		res, err := client.LoadModel(o.context, &options)
+		res, err := client.LoadModel(o.context, options)
 		if err != nil {
+			client.Close()
+			ml.deleteProcess(modelName)
 			return nil, fmt.Errorf("could not load model: %w", err)
 		}
 		if !res.Success {
+			client.Close()
+			ml.deleteProcess(modelName)
 			return nil, fmt.Errorf("could not load model (no success): %s", res.Message)
 		}
 
+		// grpcModel is called by LoadModel, which already holds the lock
+		ml.backends[modelName] = backend
+
 		return client, nil
 	}
 }
@@ -224,6 +255,8 @@ func (ml *ModelLoader) BackendLoader(opts ...Option) (model *grpc.Client, err er
 
 	backend := strings.ToLower(o.backendString)
 
+	ml.setModelTimeouts(o.model, o.idleTimeout, o.busyTimeout)
+
 	// if an external backend is provided, use it
 	_, externalBackendExists := o.externalBackends[backend]
 	if externalBackendExists {
@@ -247,6 +280,19 @@ func (ml *ModelLoader) BackendLoader(opts ...Option) (model *grpc.Client, err er
 	}
 }
 
+// autoLoadBackends returns the built-in backends to try for the model. If the format of the model
+// file is recognized, only the backends that can load it are returned, otherwise all of them.
+func (ml *ModelLoader) autoLoadBackends(modelFile string) []string {
+	format, err := DetectFormat(filepath.Join(ml.ModelPath, modelFile))
+	if err != nil || format.Format == FormatUnknown {
+		return AutoLoadBackends
+	}
+
+	backends := BackendsForFormat(format.Format)
+	log.Debug().Msgf("Model '%s' is in %s format (version %d), candidate backends: %s", modelFile, format.Format, format.Version, strings.Join(backends, ", "))
+	return backends
+}
+
 func (ml *ModelLoader) GreedyLoader(opts ...Option) (*grpc.Client, error) {
 	o := NewOptions(opts...)
 
@@ -259,15 +305,20 @@ func (ml *ModelLoader) GreedyLoader(opts ...Option) (*grpc.Client, error) {
 	}
 	ml.mu.Unlock()
 	var err error
+	var notSupported int
 
 	// autoload also external backends
 	allBackendsToAutoLoad := []string{}
-	allBackendsToAutoLoad = append(allBackendsToAutoLoad, AutoLoadBackends...)
-	for _, b := range o.externalBackends {
+	allBackendsToAutoLoad = append(allBackendsToAutoLoad, ml.autoLoadBackends(o.model)...)
+	for b := range o.externalBackends {
 		allBackendsToAutoLoad = append(allBackendsToAutoLoad, b)
 	}
 	log.Debug().Msgf("Loading model '%s' greedly from all the available backends: %s", o.model, strings.Join(allBackendsToAutoLoad, ", "))
 
+	if len(allBackendsToAutoLoad) == 0 {
+		return nil, fmt.Errorf("could not load model '%s': no available backend supports its format", o.model)
+	}
+
 	for _, b := range allBackendsToAutoLoad {
 		log.Debug().Msgf("[%s] Attempting to load", b)
 		options := []Option{
@@ -276,6 +327,9 @@ func (ml *ModelLoader) GreedyLoader(opts ...Option) (*grpc.Client, error) {
 			WithLoadGRPCLoadModelOpts(o.gRPCOptions),
 			WithThreads(o.threads),
 			WithAssetDir(o.assetDir),
+			WithIdleTimeout(o.idleTimeout),
+			WithBusyTimeout(o.busyTimeout),
+			WithCapability(o.capability),
 		}
 
 		for k, v := range o.externalBackends {
@@ -286,6 +340,9 @@ func (ml *ModelLoader) GreedyLoader(opts ...Option) (*grpc.Client, error) {
 		if modelerr == nil && model != nil {
 			log.Debug().Msgf("[%s] Loads OK", b)
 			return model, nil
+		} else if errors.Is(modelerr, grpc.ErrNotSupported) {
+			notSupported++
+			log.Debug().Msgf("[%s] Skipped: %s", b, modelerr.Error())
 		} else if modelerr != nil {
 			err = multierror.Append(err, modelerr)
 			log.Debug().Msgf("[%s] Fails: %s", b, modelerr.Error())
@@ -295,5 +352,9 @@ func (ml *ModelLoader) GreedyLoader(opts ...Option) (*grpc.Client, error) {
 		}
 	}
 
+	if err == nil && notSupported > 0 {
+		return nil, grpc.NotSupportedError("", o.capability)
+	}
+
 	return nil, fmt.Errorf("could not load model - all backends returned error: %s", err.Error())
 }
@@ -3,19 +3,25 @@ package model
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
 	"sync"
 	"text/template"
+	"time"
 
 	grammar "github.com/go-skynet/LocalAI/pkg/grammar"
 	"github.com/go-skynet/LocalAI/pkg/grpc"
+	"github.com/go-skynet/LocalAI/pkg/jinja"
 	process "github.com/mudler/go-processmanager"
 	"github.com/rs/zerolog/log"
 )
 
+// ErrTemplateNotFound is returned when evaluating a template the model doesn't have
+var ErrTemplateNotFound = errors.New("template not found")
+
 // Rather than pass an interface{} to the prompt template:
 // These are the definitions of all possible variables LocalAI will currently populate for use in a prompt template file
 // Please note: Not all of these are populated on every endpoint - your template should either be tested for each endpoint you map it to, or tolerant of zero values.
@@ -34,6 +40,8 @@ type ChatMessageTemplateData struct {
 	Role         string
 	RoleName     string
 	Content      string
+	// FunctionCall holds the function call or the tool calls of an assistant message
+	FunctionCall interface{}
 	MessageIndex int
 }
 
@@ -47,6 +55,8 @@ const (
 	CompletionPromptTemplate
 	EditPromptTemplate
 	FunctionsPromptTemplate
+	// JinjaChatTemplate is a Hugging Face chat template, rendered from the messages rather than from a prompt, see EvaluateJinjaChatTemplate
+	JinjaChatTemplate
 
 	// The following TemplateType is **NOT** a valid value and MUST be last. It exists to make the sanity integration tests simpler!
 	IntegrationTestTemplate
@@ -62,19 +72,70 @@ type ModelLoader struct {
 	models        map[string]*grpc.Client
 	grpcProcesses map[string]*process.Process
 	templates     map[TemplateType]map[string]*template.Template
+	// jinjaTemplates caches the chat templates, by file name or by source
+	jinjaTemplates map[string]*jinja.Template
+	// tcpTransport makes locally spawned backends listen on TCP instead of Unix sockets
+	tcpTransport bool
+
+	// lastUsed tracks when each loaded model was last requested, used to pick eviction candidates
+	lastUsed        map[string]time.Time
+	loadedAt        map[string]time.Time
+	backends        map[string]string
+	maxLoadedModels int
+	memoryBudget    uint64
+	evictions       []Eviction
+
+	// busy tracks the models that are currently serving a request, and since when.
+	// The requests are counted per backend instance, identified in instances.
+	busy         map[string]int
+	busySince    map[string]time.Time
+	instances    map[string]uint64
+	lastInstance uint64
+	timeouts     map[string]modelTimeouts
+	idleTimeout  time.Duration
+	busyTimeout  time.Duration
+
+	// supervision of the backend processes, see StartSupervisor
+	loaders       map[string]func(string, string) (*grpc.Client, error)
+	crashes       map[string]*crashState
+	restartPolicy RestartPolicy
+	supervisorCtx context.Context
+
+	// metadata caches the headers of the GGUF model files, see ModelMetadata
+	metadataMu sync.Mutex
+	metadata   map[string]cachedMetadata
 }
 
 func NewModelLoader(modelPath string) *ModelLoader {
 	nml := &ModelLoader{
-		ModelPath:     modelPath,
-		models:        make(map[string]*grpc.Client),
-		templates:     make(map[TemplateType]map[string]*template.Template),
-		grpcProcesses: make(map[string]*process.Process),
+		ModelPath:      modelPath,
+		models:         make(map[string]*grpc.Client),
+		templates:      make(map[TemplateType]map[string]*template.Template),
+		jinjaTemplates: make(map[string]*jinja.Template),
+		grpcProcesses:  make(map[string]*process.Process),
+		lastUsed:       make(map[string]time.Time),
+		loadedAt:       make(map[string]time.Time),
+		backends:       make(map[string]string),
+		busy:           make(map[string]int),
+		busySince:      make(map[string]time.Time),
+		instances:      make(map[string]uint64),
+		timeouts:       make(map[string]modelTimeouts),
+		metadata:       make(map[string]cachedMetadata),
+		loaders:        make(map[string]func(string, string) (*grpc.Client, error)),
+		crashes:        make(map[string]*crashState),
 	}
 	nml.initializeTemplateMap()
 	return nml
 }
 
+// SetTCPTransport makes the backends started from now on listen on a local TCP port rather than a Unix socket.
+// This is needed for backends that do not support Unix sockets.
+func (ml *ModelLoader) SetTCPTransport(b bool) {
+	ml.mu.Lock()
+	defer ml.mu.Unlock()
+	ml.tcpTransport = b
+}
+
 func (ml *ModelLoader) ExistsInModelPath(s string) bool {
 	return existsInPath(ml.ModelPath, s)
 }
@@ -87,8 +148,12 @@ func (ml *ModelLoader) ListModels() ([]string, error) {
 
 	models := []string{}
 	for _, file := range files {
+		// Skip the directory of the partials shared by the templates
+		if file.IsDir() && file.Name() == TemplatesDir {
+			continue
+		}
 		// Skip templates, YAML, .keep, .json, and .DS_Store files - TODO: as this list grows, is there a more efficient method?
-		if strings.HasSuffix(file.Name(), ".tmpl") || strings.HasSuffix(file.Name(), ".keep") || strings.HasSuffix(file.Name(), ".yaml") || strings.HasSuffix(file.Name(), ".yml") || strings.HasSuffix(file.Name(), ".json") || strings.HasSuffix(file.Name(), ".DS_Store") {
+		if strings.HasSuffix(file.Name(), ".tmpl") || strings.HasSuffix(file.Name(), JinjaExtension) || strings.HasSuffix(file.Name(), ".keep") || strings.HasSuffix(file.Name(), ".yaml") || strings.HasSuffix(file.Name(), ".yml") || strings.HasSuffix(file.Name(), ".json") || strings.HasSuffix(file.Name(), ".DS_Store") {
 			continue
 		}
 
@@ -107,12 +172,24 @@ func (ml *ModelLoader) LoadModel(modelName string, loader func(string, string) (
 		return model, nil
 	}
 
+	// Fail fast if the backend keeps crashing
+	if err := ml.checkCircuit(modelName); err != nil {
+		return nil, err
+	}
+
 	// Load the model and keep it in memory for later use
 	modelFile := filepath.Join(ml.ModelPath, modelName)
 	log.Debug().Msgf("Loading model in memory from file: %s", modelFile)
 
+	// Make room for the new model if we are over the configured limits
+	ml.evictIfNeeded(modelName, modelFile)
+
 	model, err := loader(modelName, modelFile)
 	if err != nil {
+		if st, ok := ml.crashes[modelName]; ok && !st.openedAt.IsZero() {
+			// the attempt allowed by the half-open circuit failed
+			st.openedAt = time.Now()
+		}
 		return nil, err
 	}
 
@@ -125,6 +202,17 @@ func (ml *ModelLoader) LoadModel(modelName string, loader func(string, string) (
 	// }
 
 	ml.models[modelName] = model
+	ml.loaders[modelName] = loader
+	// a new instance: the requests marked busy before it was loaded are not served by it
+	ml.lastInstance++
+	ml.instances[modelName] = ml.lastInstance
+	delete(ml.busy, modelName)
+	delete(ml.busySince, modelName)
+	if st, ok := ml.crashes[modelName]; ok {
+		st.openedAt, st.restartAt = time.Time{}, time.Time{}
+	}
+	ml.lastUsed[modelName] = time.Now()
+	ml.loadedAt[modelName] = time.Now()
 	return model, nil
 }
 
@@ -134,16 +222,15 @@ func (ml *ModelLoader) checkIsLoaded(s string) *grpc.Client {
 
 		if !m.HealthCheck(context.Background()) {
 			log.Debug().Msgf("GRPC Model not responding: %s", s)
-			if !ml.grpcProcesses[s].IsAlive() {
+			if p, ok := ml.grpcProcesses[s]; ok && !p.IsAlive() {
 				log.Debug().Msgf("GRPC Process is not responding: %s", s)
 				// stop and delete the process, this forces to re-load the model and re-create again the service
-				ml.grpcProcesses[s].Stop()
-				delete(ml.grpcProcesses, s)
-				delete(ml.models, s)
+				ml.handleCrash(s)
 				return nil
 			}
 		}
 
+		ml.lastUsed[s] = time.Now()
 		return m
 	}
 
@@ -188,7 +275,7 @@ func (ml *ModelLoader) evaluateTemplate(templateType TemplateType, templateName
 		m = ml.templates[templateType][templateName] // ok is not important since we check m on the next line, and wealready checked
 	}
 	if m == nil {
-		return "", fmt.Errorf("failed loading a template for %s", templateName)
+		return "", fmt.Errorf("failed loading a template for %s: %w", templateName, ErrTemplateNotFound)
 	}
 
 	var buf bytes.Buffer
@@ -219,7 +306,7 @@ func (ml *ModelLoader) loadTemplateIfExists(templateType TemplateType, templateN
 	}
 
 	// Parse the template
-	tmpl, err := template.New("prompt").Parse(string(dat))
+	tmpl, err := ml.parseTemplate(string(dat))
 	if err != nil {
 		return err
 	}
@@ -227,3 +314,37 @@ func (ml *ModelLoader) loadTemplateIfExists(templateType TemplateType, templateN
 
 	return nil
 }
+
+// InvalidateTemplateFile drops the templates parsed from the file, so that they are read again the next time they are used.
+// As partials can be included by any template, changing one of them drops all the templates.
+func (ml *ModelLoader) InvalidateTemplateFile(file string) {
+	if filepath.Ext(file) == JinjaExtension {
+		ml.mu.Lock()
+		delete(ml.jinjaTemplates, filepath.Base(file))
+		ml.mu.Unlock()
+		return
+	}
+	if filepath.Ext(file) != ".tmpl" {
+		return
+	}
+	templateName := strings.TrimSuffix(filepath.Base(file), ".tmpl")
+
+	ml.mu.Lock()
+	defer ml.mu.Unlock()
+	if filepath.Base(filepath.Dir(file)) == TemplatesDir {
+		// partials can be included by any template
+		ml.initializeTemplateMap()
+		return
+	}
+	for tt := range ml.templates {
+		delete(ml.templates[tt], templateName)
+	}
+}
+
+// ResetTemplates drops all the cached templates
+func (ml *ModelLoader) ResetTemplates() {
+	ml.mu.Lock()
+	defer ml.mu.Unlock()
+	ml.initializeTemplateMap()
+	ml.jinjaTemplates = make(map[string]*jinja.Template)
+}
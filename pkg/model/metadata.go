@@ -0,0 +1,48 @@
+package model
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/go-skynet/LocalAI/pkg/model/gguf"
+)
+
+type cachedMetadata struct {
+	modTime  time.Time
+	size     int64
+	metadata *gguf.Metadata
+}
+
+// ModelMetadata returns the metadata stored in the header of a GGUF model file in the model path.
+// It returns nil without error if the model is not a GGUF file.
+// Results are cached until the file changes.
+func (ml *ModelLoader) ModelMetadata(modelFile string) (*gguf.Metadata, error) {
+	p := filepath.Join(ml.ModelPath, modelFile)
+	info, err := os.Stat(p)
+	if err != nil {
+		return nil, err
+	}
+	if !info.Mode().IsRegular() {
+		return nil, nil
+	}
+
+	ml.metadataMu.Lock()
+	defer ml.metadataMu.Unlock()
+
+	if c, ok := ml.metadata[modelFile]; ok && c.modTime.Equal(info.ModTime()) && c.size == info.Size() {
+		return c.metadata, nil
+	}
+
+	m, err := gguf.ReadFile(p)
+	if errors.Is(err, gguf.ErrNotGGUF) {
+		m, err = nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	ml.metadata[modelFile] = cachedMetadata{modTime: info.ModTime(), size: info.Size(), metadata: m}
+	return m, nil
+}
@@ -2,7 +2,9 @@ package model
 
 import (
 	"context"
+	"time"
 
+	grpc "github.com/go-skynet/LocalAI/pkg/grpc"
 	pb "github.com/go-skynet/LocalAI/pkg/grpc/proto"
 )
 
@@ -19,6 +21,11 @@ type Options struct {
 
 	grpcAttempts      int
 	grpcAttemptsDelay int
+
+	idleTimeout time.Duration
+	busyTimeout time.Duration
+
+	capability grpc.Capability
 }
 
 type Option func(*Options)
@@ -44,6 +51,28 @@ func WithGRPCAttemptsDelay(delay int) Option {
 	}
 }
 
+// WithIdleTimeout overrides the watchdog idle timeout for the model
+func WithIdleTimeout(d time.Duration) Option {
+	return func(o *Options) {
+		o.idleTimeout = d
+	}
+}
+
+// WithBusyTimeout overrides the watchdog busy timeout for the model
+func WithBusyTimeout(d time.Duration) Option {
+	return func(o *Options) {
+		o.busyTimeout = d
+	}
+}
+
+// WithCapability requires the backend to implement the operation:
+// backends that don't are skipped without loading the model
+func WithCapability(c grpc.Capability) Option {
+	return func(o *Options) {
+		o.capability = c
+	}
+}
+
 func WithBackendString(backend string) Option {
 	return func(o *Options) {
 		o.backendString = backend
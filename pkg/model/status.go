@@ -0,0 +1,105 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// BackendStatus describes a model loaded in memory and the backend serving it
+type BackendStatus struct {
+	Model    string    `json:"model"`
+	Backend  string    `json:"backend"`
+	PID      string    `json:"pid,omitempty"`
+	Address  string    `json:"address"`
+	LoadedAt time.Time `json:"loaded_at"`
+	Uptime   string    `json:"uptime"`
+	LastUsed time.Time `json:"last_used"`
+	Busy     bool      `json:"busy"`
+	Healthy  bool      `json:"healthy"`
+}
+
+// ShutdownModel stops the backend serving the model and unloads it from memory
+func (ml *ModelLoader) ShutdownModel(modelName string) error {
+	ml.mu.Lock()
+	defer ml.mu.Unlock()
+
+	// unloading a model also resets its circuit breaker
+	crashed := ml.forgetCrashes(modelName)
+	if _, ok := ml.models[modelName]; !ok {
+		if crashed {
+			return nil
+		}
+		return fmt.Errorf("model %s is not loaded", modelName)
+	}
+
+	ml.deleteProcess(modelName)
+	return nil
+}
+
+// BackendStatus returns the status of the backend serving a loaded model
+func (ml *ModelLoader) BackendStatus(ctx context.Context, modelName string) (BackendStatus, error) {
+	ml.mu.Lock()
+	st, ok := ml.backendStatus(modelName)
+	ml.mu.Unlock()
+	if !ok {
+		return st, fmt.Errorf("model %s is not loaded", modelName)
+	}
+
+	st.Healthy = ml.healthy(ctx, modelName)
+	return st, nil
+}
+
+// BackendsStatus returns the status of all the backends currently loaded
+func (ml *ModelLoader) BackendsStatus(ctx context.Context) []BackendStatus {
+	ml.mu.Lock()
+	res := []BackendStatus{}
+	for m := range ml.models {
+		if st, ok := ml.backendStatus(m); ok {
+			res = append(res, st)
+		}
+	}
+	ml.mu.Unlock()
+
+	// Health checks can take a while, so they are run without holding the lock
+	for i := range res {
+		res[i].Healthy = ml.healthy(ctx, res[i].Model)
+	}
+
+	sort.Slice(res, func(i, j int) bool { return res[i].Model < res[j].Model })
+	return res
+}
+
+// backendStatus must be called with ml.mu held
+func (ml *ModelLoader) backendStatus(modelName string) (BackendStatus, bool) {
+	client, ok := ml.models[modelName]
+	if !ok {
+		return BackendStatus{}, false
+	}
+
+	st := BackendStatus{
+		Model:    modelName,
+		Backend:  ml.backends[modelName],
+		Address:  client.Address(),
+		LoadedAt: ml.loadedAt[modelName],
+		Uptime:   time.Since(ml.loadedAt[modelName]).Round(time.Second).String(),
+		LastUsed: ml.lastUsed[modelName],
+		Busy:     ml.busy[modelName] > 0,
+	}
+	if p, ok := ml.grpcProcesses[modelName]; ok && p != nil {
+		st.PID = strings.TrimSpace(p.PID)
+	}
+	return st, true
+}
+
+func (ml *ModelLoader) healthy(ctx context.Context, modelName string) bool {
+	ml.mu.Lock()
+	client, ok := ml.models[modelName]
+	ml.mu.Unlock()
+	if !ok {
+		return false
+	}
+	return client.HealthCheck(ctx)
+}
@@ -0,0 +1,120 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	grpc "github.com/go-skynet/LocalAI/pkg/grpc"
+	"github.com/go-skynet/LocalAI/pkg/grpc/base"
+	process "github.com/mudler/go-processmanager"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Backend status", func() {
+	var ml *ModelLoader
+
+	loader := func(modelName, modelFile string) (*grpc.Client, error) {
+		return grpc.NewClient("127.0.0.1:0"), nil
+	}
+
+	BeforeEach(func() {
+		ml = NewModelLoader(GinkgoT().TempDir())
+	})
+
+	It("fails to unload a model that isn't loaded", func() {
+		Expect(ml.ShutdownModel("model")).To(MatchError("model model is not loaded"))
+
+		_, err := ml.BackendStatus(context.Background(), "model")
+		Expect(err).To(MatchError("model model is not loaded"))
+	})
+
+	It("forgets the unloaded models and resets their circuit", func() {
+		_, err := ml.LoadModel("model", loader)
+		Expect(err).ToNot(HaveOccurred())
+		ml.MarkBusy("model")
+
+		ml.mu.Lock()
+		ml.backends["model"] = "llama"
+		ml.crashes["model"] = &crashState{crashes: 2, lastCrash: time.Now()}
+		ml.mu.Unlock()
+		Expect(ml.Circuits()).To(HaveLen(1))
+
+		Expect(ml.ShutdownModel("model")).To(Succeed())
+		Expect(ml.Circuits()).To(BeEmpty())
+		Expect(ml.BackendsStatus(context.Background())).To(BeEmpty())
+
+		ml.mu.Lock()
+		for name, m := range map[string]int{
+			"models":   len(ml.models),
+			"backends": len(ml.backends),
+			"lastUsed": len(ml.lastUsed),
+			"loadedAt": len(ml.loadedAt),
+			"busy":     len(ml.busy),
+			"loaders":  len(ml.loaders),
+		} {
+			Expect(m).To(BeZero(), fmt.Sprintf("%s still holds the model", name))
+		}
+		ml.mu.Unlock()
+
+		// a model whose backend crashed is no longer loaded, but unloading it still resets its circuit
+		ml.mu.Lock()
+		ml.crashes["model"] = &crashState{crashes: 3, openedAt: time.Now()}
+		ml.mu.Unlock()
+		Expect(ml.ShutdownModel("model")).To(Succeed())
+		Expect(ml.Circuits()).To(BeEmpty())
+	})
+
+	It("describes the backends of the loaded models", func() {
+		address := "unix://" + filepath.Join(GinkgoT().TempDir(), "backend.sock")
+		go grpc.StartServer(address, &base.Base{})
+
+		_, err := ml.LoadModel("healthy", func(modelName, modelFile string) (*grpc.Client, error) {
+			return grpc.NewClient(address), nil
+		})
+		Expect(err).ToNot(HaveOccurred())
+		_, err = ml.LoadModel("down", loader)
+		Expect(err).ToNot(HaveOccurred())
+
+		used := time.Now().Add(-time.Minute)
+		ml.mu.Lock()
+		ml.backends["healthy"] = "llama"
+		ml.loadedAt["healthy"] = time.Now().Add(-time.Hour)
+		ml.lastUsed["healthy"] = used
+		// the process is never stopped, as its PID is only read by the status
+		ml.grpcProcesses["healthy"] = &process.Process{PID: "4242\n"}
+		ml.mu.Unlock()
+		DeferCleanup(func() {
+			ml.mu.Lock()
+			delete(ml.grpcProcesses, "healthy")
+			ml.mu.Unlock()
+		})
+		ml.MarkBusy("down")
+
+		var st BackendStatus
+		Eventually(func() bool {
+			st, err = ml.BackendStatus(context.Background(), "healthy")
+			Expect(err).ToNot(HaveOccurred())
+			return st.Healthy
+		}, "5s").Should(BeTrue())
+		Expect(st.Model).To(Equal("healthy"))
+		Expect(st.Backend).To(Equal("llama"))
+		Expect(st.PID).To(Equal("4242"))
+		Expect(st.Address).To(Equal(address))
+		Expect(st.Uptime).To(Equal("1h0m0s"))
+		Expect(st.LastUsed).To(Equal(used))
+		Expect(st.Busy).To(BeFalse())
+
+		statuses := ml.BackendsStatus(context.Background())
+		Expect(statuses).To(HaveLen(2))
+		Expect(statuses[0].Model).To(Equal("down"))
+		Expect(statuses[0].Address).To(Equal("127.0.0.1:0"))
+		Expect(statuses[0].PID).To(BeEmpty())
+		Expect(statuses[0].Busy).To(BeTrue())
+		Expect(statuses[0].Healthy).To(BeFalse())
+		Expect(statuses[1].Model).To(Equal("healthy"))
+		Expect(statuses[1].Healthy).To(BeTrue())
+	})
+})
@@ -0,0 +1,258 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	grpc "github.com/go-skynet/LocalAI/pkg/grpc"
+	process "github.com/mudler/go-processmanager"
+	"github.com/rs/zerolog/log"
+)
+
+// supervisorInterval is how often the supervisor checks that the backend processes are still running
+const supervisorInterval = 2 * time.Second
+
+// maxRestartDelay caps the exponential backoff between restarts
+const maxRestartDelay = time.Minute
+
+// crashResetAfter is how long a backend has to run before its previous crashes are forgotten
+const crashResetAfter = 10 * time.Minute
+
+// ErrCircuitOpen is returned when a model is not loaded because its backend crashed too many times in a row
+var ErrCircuitOpen = errors.New("backend circuit breaker open")
+
+// Circuit breaker states
+const (
+	CircuitClosed   = "closed"
+	CircuitOpen     = "open"
+	CircuitHalfOpen = "half-open"
+)
+
+// RestartPolicy controls how crashed backends are restarted
+type RestartPolicy struct {
+	// MaxCrashes is the number of consecutive crashes after which the circuit breaker opens
+	// and the backend is not restarted anymore. 0 disables automatic restarts.
+	MaxCrashes int
+	// Backoff is the delay before the first restart, doubled at each consecutive crash
+	Backoff time.Duration
+	// Cooldown is how long requests fail fast once the circuit is open, before a new attempt is allowed
+	Cooldown time.Duration
+}
+
+// CircuitStatus describes a model whose backend crashed
+type CircuitStatus struct {
+	Model          string     `json:"model"`
+	State          string     `json:"state"`
+	Crashes        int        `json:"crashes"`
+	LastExitStatus string     `json:"last_exit_status"`
+	LastCrash      time.Time  `json:"last_crash"`
+	NextAttempt    *time.Time `json:"next_attempt,omitempty"`
+}
+
+type crashState struct {
+	crashes   int
+	lastExit  string
+	lastCrash time.Time
+	openedAt  time.Time
+	restartAt time.Time
+	// generation invalidates the restarts scheduled before the model was unloaded or loaded again
+	generation int
+}
+
+// SetRestartPolicy sets how the backends that crash are restarted
+func (ml *ModelLoader) SetRestartPolicy(p RestartPolicy) {
+	ml.mu.Lock()
+	defer ml.mu.Unlock()
+	ml.restartPolicy = p
+}
+
+// StartSupervisor starts a goroutine that detects the backend processes that exited
+// and restarts them with an exponential backoff. It returns when the context is canceled.
+func (ml *ModelLoader) StartSupervisor(ctx context.Context) {
+	ml.mu.Lock()
+	ml.supervisorCtx = ctx
+	ml.mu.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(supervisorInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				ml.checkProcesses()
+			}
+		}
+	}()
+}
+
+func (ml *ModelLoader) checkProcesses() {
+	ml.mu.Lock()
+	defer ml.mu.Unlock()
+
+	for m, p := range ml.grpcProcesses {
+		if _, loaded := ml.models[m]; !loaded || p == nil {
+			continue
+		}
+		if p.IsAlive() {
+			if _, crashed := ml.crashes[m]; crashed && time.Since(ml.loadedAt[m]) > crashResetAfter {
+				ml.forgetCrashes(m)
+			}
+			continue
+		}
+		ml.handleCrash(m)
+	}
+}
+
+// handleCrash forgets about a model whose backend exited, and schedules its restart.
+// It must be called with ml.mu held.
+func (ml *ModelLoader) handleCrash(s string) {
+	exit := exitStatus(ml.grpcProcesses[s])
+	uptime := time.Since(ml.loadedAt[s])
+	loader := ml.loaders[s]
+	ml.deleteProcess(s)
+
+	st, ok := ml.crashes[s]
+	if !ok || uptime > crashResetAfter {
+		st = &crashState{}
+		ml.crashes[s] = st
+	}
+	log.Warn().Msgf("[supervisor] Backend of model %s exited (%s) after %s", s, exit, uptime.Round(time.Second))
+	ml.recordFailure(s, st, exit, loader)
+}
+
+// recordFailure counts a crash (or a failed restart) and either schedules a restart or opens the circuit.
+// It must be called with ml.mu held.
+func (ml *ModelLoader) recordFailure(s string, st *crashState, exit string, loader func(string, string) (*grpc.Client, error)) {
+	now := time.Now()
+	st.crashes++
+	st.lastExit = exit
+	st.lastCrash = now
+	st.restartAt = time.Time{}
+	st.generation++
+
+	policy := ml.restartPolicy
+	if policy.MaxCrashes <= 0 || loader == nil || ml.supervisorCtx == nil {
+		return
+	}
+
+	if st.crashes >= policy.MaxCrashes {
+		st.openedAt = now
+		log.Error().Msgf("[supervisor] Backend of model %s crashed %d times in a row, not restarting it for %s", s, st.crashes, policy.Cooldown)
+		return
+	}
+
+	delay := policy.Backoff << (st.crashes - 1)
+	if delay > maxRestartDelay || delay <= 0 {
+		delay = maxRestartDelay
+	}
+	st.restartAt = now.Add(delay)
+	log.Info().Msgf("[supervisor] Restarting backend of model %s in %s", s, delay)
+	go ml.restart(ml.supervisorCtx, s, st.generation, delay, loader)
+}
+
+func (ml *ModelLoader) restart(ctx context.Context, s string, generation int, delay time.Duration, loader func(string, string) (*grpc.Client, error)) {
+	select {
+	case <-ctx.Done():
+		return
+	case <-time.After(delay):
+	}
+
+	ml.mu.Lock()
+	st, ok := ml.crashes[s]
+	if !ok || st.generation != generation {
+		ml.mu.Unlock()
+		return
+	}
+	st.restartAt = time.Time{}
+	ml.mu.Unlock()
+
+	if _, err := ml.LoadModel(s, loader); err != nil {
+		log.Warn().Msgf("[supervisor] Failed restarting backend of model %s: %s", s, err.Error())
+		ml.mu.Lock()
+		defer ml.mu.Unlock()
+		if st, ok := ml.crashes[s]; ok && st.generation == generation {
+			ml.recordFailure(s, st, "restart failed", loader)
+		}
+		return
+	}
+	log.Info().Msgf("[supervisor] Backend of model %s restarted", s)
+}
+
+// checkCircuit returns an error if requests for the model should fail fast because its backend keeps crashing.
+// It must be called with ml.mu held.
+func (ml *ModelLoader) checkCircuit(s string) error {
+	st, ok := ml.crashes[s]
+	if !ok || st.openedAt.IsZero() {
+		return nil
+	}
+	retry := st.openedAt.Add(ml.restartPolicy.Cooldown)
+	if time.Now().After(retry) {
+		// half-open: let a request try to load the model again
+		return nil
+	}
+	return fmt.Errorf("%w: backend of model %s crashed %d times in a row (last exit status: %s), retrying after %s",
+		ErrCircuitOpen, s, st.crashes, st.lastExit, retry.Format(time.RFC3339))
+}
+
+// forgetCrashes drops the crash history of a model, closing its circuit and canceling pending restarts.
+// It must be called with ml.mu held.
+func (ml *ModelLoader) forgetCrashes(s string) bool {
+	_, ok := ml.crashes[s]
+	delete(ml.crashes, s)
+	return ok
+}
+
+// Circuits returns the state of the models whose backend crashed recently
+func (ml *ModelLoader) Circuits() []CircuitStatus {
+	ml.mu.Lock()
+	defer ml.mu.Unlock()
+
+	res := []CircuitStatus{}
+	for m, st := range ml.crashes {
+		cs := CircuitStatus{
+			Model:          m,
+			State:          CircuitClosed,
+			Crashes:        st.crashes,
+			LastExitStatus: st.lastExit,
+			LastCrash:      st.lastCrash,
+		}
+		if !st.restartAt.IsZero() {
+			t := st.restartAt
+			cs.NextAttempt = &t
+		}
+		if !st.openedAt.IsZero() {
+			t := st.openedAt.Add(ml.restartPolicy.Cooldown)
+			cs.State = CircuitOpen
+			if time.Now().After(t) {
+				cs.State = CircuitHalfOpen
+			}
+			cs.NextAttempt = &t
+		}
+		res = append(res, cs)
+	}
+
+	sort.Slice(res, func(i, j int) bool { return res[i].Model < res[j].Model })
+	return res
+}
+
+// exitStatus describes how a backend process terminated
+func exitStatus(p *process.Process) string {
+	if p == nil {
+		return "unknown"
+	}
+	code, err := p.ExitCode()
+	if err != nil {
+		return "unknown"
+	}
+	code = strings.TrimSpace(code)
+	if code == "-1" {
+		return "killed by a signal"
+	}
+	return "exit code " + code
+}
@@ -0,0 +1,103 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	grpc "github.com/go-skynet/LocalAI/pkg/grpc"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Backend supervision", func() {
+	var ml *ModelLoader
+	var loads int
+	var failing bool
+
+	loader := func(modelName, modelFile string) (*grpc.Client, error) {
+		loads++
+		if failing {
+			return nil, errors.New("cannot start")
+		}
+		return grpc.NewClient("127.0.0.1:0"), nil
+	}
+
+	BeforeEach(func() {
+		loads, failing = 0, false
+		ml = NewModelLoader(GinkgoT().TempDir())
+		ml.SetRestartPolicy(RestartPolicy{MaxCrashes: 3, Backoff: 10 * time.Millisecond, Cooldown: time.Hour})
+		ctx, cancel := context.WithCancel(context.Background())
+		DeferCleanup(cancel)
+		ml.supervisorCtx = ctx
+	})
+
+	crash := func(s string) {
+		ml.mu.Lock()
+		defer ml.mu.Unlock()
+		ml.handleCrash(s)
+	}
+
+	loaded := func(s string) func() bool {
+		return func() bool {
+			ml.mu.Lock()
+			defer ml.mu.Unlock()
+			_, ok := ml.models[s]
+			return ok
+		}
+	}
+
+	It("restarts crashed backends", func() {
+		_, err := ml.LoadModel("model", loader)
+		Expect(err).ToNot(HaveOccurred())
+
+		crash("model")
+		Expect(loaded("model")()).To(BeFalse())
+		Eventually(loaded("model")).Should(BeTrue())
+		Expect(loads).To(Equal(2))
+
+		circuits := ml.Circuits()
+		Expect(circuits).To(HaveLen(1))
+		Expect(circuits[0].State).To(Equal(CircuitClosed))
+		Expect(circuits[0].Crashes).To(Equal(1))
+	})
+
+	It("opens the circuit after too many crashes", func() {
+		_, err := ml.LoadModel("model", loader)
+		Expect(err).ToNot(HaveOccurred())
+
+		for i := 0; i < 2; i++ {
+			crash("model")
+			Eventually(loaded("model")).Should(BeTrue())
+		}
+		crash("model")
+
+		Consistently(loaded("model"), 100*time.Millisecond).Should(BeFalse())
+		_, err = ml.LoadModel("model", loader)
+		Expect(errors.Is(err, ErrCircuitOpen)).To(BeTrue())
+
+		circuits := ml.Circuits()
+		Expect(circuits).To(HaveLen(1))
+		Expect(circuits[0].State).To(Equal(CircuitOpen))
+		Expect(circuits[0].Crashes).To(Equal(3))
+		Expect(circuits[0].NextAttempt).ToNot(BeNil())
+
+		// unloading the model resets the circuit
+		Expect(ml.ShutdownModel("model")).To(Succeed())
+		_, err = ml.LoadModel("model", loader)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ml.Circuits()).To(BeEmpty())
+	})
+
+	It("counts failed restarts as crashes", func() {
+		_, err := ml.LoadModel("model", loader)
+		Expect(err).ToNot(HaveOccurred())
+
+		failing = true
+		crash("model")
+		Eventually(func() string {
+			return ml.Circuits()[0].State
+		}).Should(Equal(CircuitOpen))
+		Expect(loads).To(Equal(3))
+	})
+})
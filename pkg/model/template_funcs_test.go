@@ -0,0 +1,27 @@
+package model
+
+import (
+	"bytes"
+	"text/template"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = DescribeTable("Template helper functions",
+	func(text string, data interface{}, expected string) {
+		tmpl, err := template.New("test").Funcs(templateFuncs).Parse(text)
+		Expect(err).ToNot(HaveOccurred())
+		var buf bytes.Buffer
+		Expect(tmpl.Execute(&buf, data)).To(Succeed())
+		Expect(buf.String()).To(Equal(expected))
+	},
+	Entry("trim", "[{{ . | trim }}]", "  hi \n", "[hi]"),
+	Entry("join", `{{ . | join ", " }}`, []string{"a", "b"}, "a, b"),
+	Entry("join of other types", `{{ join "+" . }}`, []int{1, 2}, "1+2"),
+	Entry("toJson", "{{ toJson . }}", map[string]interface{}{"q": `say "hi"`}, `{"q":"say \"hi\""}`),
+	Entry("default with no value", `{{ .S | default "Be helpful." }}`, struct{ S string }{}, "Be helpful."),
+	Entry("default with a value", `{{ .S | default "Be helpful." }}`, struct{ S string }{"Be brief."}, "Be brief."),
+	Entry("default with no data", `{{ .missing | default "none" }}`, map[string]string{}, "none"),
+	Entry("indent", "{{ . | indent 2 }}", "a\nb", "  a\n  b"),
+)
@@ -0,0 +1,74 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"text/template"
+)
+
+// TemplatesDir is the subdirectory of the models path holding the partials shared by the prompt templates.
+// Each file.tmpl in it can be included with {{ template "file" . }}.
+const TemplatesDir = "templates"
+
+// templateFuncs are the helper functions available to the prompt templates
+var templateFuncs = template.FuncMap{
+	// trim removes the leading and trailing spaces: {{ .Input | trim }}
+	"trim": strings.TrimSpace,
+	// join concatenates the elements of a list: {{ .Stop | join ", " }}
+	"join": func(sep string, list interface{}) string {
+		v := reflect.ValueOf(list)
+		if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+			return fmt.Sprint(list)
+		}
+		elems := make([]string, v.Len())
+		for i := range elems {
+			elems[i] = fmt.Sprint(v.Index(i).Interface())
+		}
+		return strings.Join(elems, sep)
+	},
+	// toJson encodes a value as JSON: {{ toJson .Functions }}
+	"toJson": func(v interface{}) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+	// default returns the value if set, the default otherwise: {{ .SystemPrompt | default "You are a helpful assistant." }}
+	"default": func(def interface{}, v interface{}) interface{} {
+		if v == nil {
+			return def
+		}
+		if rv := reflect.ValueOf(v); rv.IsZero() {
+			return def
+		}
+		return v
+	},
+	// indent prefixes every line with the given number of spaces: {{ .Content | indent 4 }}
+	"indent": func(spaces int, s string) string {
+		pad := strings.Repeat(" ", spaces)
+		return pad + strings.ReplaceAll(s, "\n", "\n"+pad)
+	},
+}
+
+// parseTemplate parses a prompt template, along with the partials of the templates directory
+func (ml *ModelLoader) parseTemplate(text string) (*template.Template, error) {
+	tmpl := template.New("prompt").Funcs(templateFuncs)
+
+	partials, err := filepath.Glob(filepath.Join(ml.ModelPath, TemplatesDir, "*.tmpl"))
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range partials {
+		dat, err := os.ReadFile(p)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := tmpl.New(strings.TrimSuffix(filepath.Base(p), ".tmpl")).Parse(string(dat)); err != nil {
+			return nil, err
+		}
+	}
+
+	return tmpl.Parse(text)
+}
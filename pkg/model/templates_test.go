@@ -0,0 +1,143 @@
+package model_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/go-skynet/LocalAI/pkg/model"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Prompt templates", func() {
+	var dir string
+	var ml *ModelLoader
+
+	write := func(file, content string) {
+		Expect(os.MkdirAll(filepath.Dir(filepath.Join(dir, file)), 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(dir, file), []byte(content), 0644)).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		dir = GinkgoT().TempDir()
+		ml = NewModelLoader(dir)
+	})
+
+	Context("shipped in prompt-templates", func() {
+		BeforeEach(func() {
+			templates, err := filepath.Glob("../../prompt-templates/*.tmpl")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(templates).ToNot(BeEmpty())
+			for _, t := range templates {
+				dat, err := os.ReadFile(t)
+				Expect(err).ToNot(HaveOccurred())
+				write(filepath.Base(t), string(dat))
+			}
+		})
+
+		DescribeTable("render the prompt",
+			func(name string) {
+				out, err := ml.EvaluateTemplateForPrompt(CompletionPromptTemplate, name, PromptTemplateData{Input: "What is the capital of France?"})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(out).To(ContainSubstring("What is the capital of France?"))
+			},
+			Entry("alpaca", "alpaca"),
+			Entry("ggml-gpt4all-j", "ggml-gpt4all-j"),
+			Entry("koala", "koala"),
+			Entry("vicuna", "vicuna"),
+			Entry("wizardlm", "wizardlm"),
+		)
+
+		DescribeTable("render the llama2 chat messages",
+			func(data ChatMessageTemplateData, expected string) {
+				out, err := ml.EvaluateTemplateForChatMessage("llama2-chat-message", data)
+				Expect(err).ToNot(HaveOccurred())
+				// the template has Windows line endings
+				Expect(strings.TrimSpace(strings.ReplaceAll(out, "\r\n", "\n"))).To(Equal(expected))
+			},
+			Entry("assistant", ChatMessageTemplateData{RoleName: "assistant", Content: "Paris."}, "Paris."),
+			Entry("user", ChatMessageTemplateData{RoleName: "user", Content: "Hi"}, "[INST]\nHi\n[/INST]"),
+			Entry("system", ChatMessageTemplateData{RoleName: "system", Content: "Be brief."}, "[INST]\n<<SYS>>Be brief.<</SYS>>\n\n\n[/INST]"),
+		)
+	})
+
+	It("includes the partials of the templates directory", func() {
+		write("templates/system.tmpl", "{{ .SystemPrompt | default \"You are a helpful assistant.\" }}")
+		write("chat.tmpl", "{{ template \"system\" . }}\nUSER: {{ .Input }}")
+
+		out, err := ml.EvaluateTemplateForPrompt(ChatPromptTemplate, "chat", PromptTemplateData{Input: "Hi"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out).To(Equal("You are a helpful assistant.\nUSER: Hi"))
+	})
+
+	It("returns ErrTemplateNotFound for the templates the model doesn't have", func() {
+		_, err := ml.EvaluateTemplateForPrompt(ChatPromptTemplate, "missing", PromptTemplateData{})
+		Expect(err).To(MatchError(ErrTemplateNotFound))
+
+		write("broken.tmpl", "{{ .Input ")
+		_, err = ml.EvaluateTemplateForPrompt(ChatPromptTemplate, "broken", PromptTemplateData{})
+		Expect(err).To(HaveOccurred())
+		Expect(err).ToNot(MatchError(ErrTemplateNotFound))
+	})
+
+	It("doesn't list the partials directory as a model", func() {
+		write("templates/system.tmpl", "You are a helpful assistant.")
+		write("chat.tmpl", "{{ .Input }}")
+		write("model.bin", "")
+
+		models, err := ml.ListModels()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(models).To(ConsistOf("model.bin"))
+	})
+
+	It("reads the templates again once invalidated", func() {
+		write("templates/system.tmpl", "first")
+		write("chat.tmpl", "{{ template \"system\" . }}")
+		out, err := ml.EvaluateTemplateForPrompt(ChatPromptTemplate, "chat", PromptTemplateData{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out).To(Equal("first"))
+
+		write("templates/system.tmpl", "second")
+		ml.InvalidateTemplateFile(filepath.Join(dir, "templates", "system.tmpl"))
+		out, err = ml.EvaluateTemplateForPrompt(ChatPromptTemplate, "chat", PromptTemplateData{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out).To(Equal("second"))
+	})
+
+	Context("Jinja chat templates", func() {
+		const chatML = "{% for m in messages %}<|im_start|>{{ m.role }}\n{{ m.content }}<|im_end|>\n{% endfor %}" +
+			"{% if add_generation_prompt %}<|im_start|>assistant\n{% endif %}"
+		vars := map[string]interface{}{
+			"messages":              []map[string]interface{}{{"role": "user", "content": "Hi"}},
+			"add_generation_prompt": true,
+		}
+
+		It("renders the template given in the config", func() {
+			out, err := ml.EvaluateJinjaChatTemplate(chatML, "model.bin", vars)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(Equal("<|im_start|>user\nHi<|im_end|>\n<|im_start|>assistant\n"))
+		})
+
+		It("reads the template from a file of the model path", func() {
+			write("chatml.jinja", chatML)
+			for _, name := range []string{"chatml", "chatml.jinja"} {
+				out, err := ml.EvaluateJinjaChatTemplate(name, "model.bin", vars)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(out).To(HavePrefix("<|im_start|>user"))
+			}
+
+			write("chatml.jinja", "{{ bos_token }}{{ messages[0].content }}")
+			ml.InvalidateTemplateFile(filepath.Join(dir, "chatml.jinja"))
+			out, err := ml.EvaluateJinjaChatTemplate("chatml", "model.bin", vars)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(Equal("Hi"))
+		})
+
+		It("fails if the model file has no chat template", func() {
+			write("model.bin", "not a GGUF file")
+			_, err := ml.EvaluateJinjaChatTemplate(GGUFChatTemplate, "model.bin", vars)
+			Expect(err).To(MatchError(ContainSubstring("no chat template")))
+		})
+	})
+})
@@ -0,0 +1,123 @@
+package model
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// watchdogInterval is how often the watchdog checks for idle or stuck backends
+const watchdogInterval = 10 * time.Second
+
+// modelTimeouts are per-model overrides of the watchdog timeouts
+type modelTimeouts struct {
+	idle, busy time.Duration
+}
+
+// SetWatchdogTimeouts sets the global timeouts used by the watchdog.
+// Backends idle for longer than idle, or busy serving a single request for longer than busy, are stopped.
+// A zero value disables the respective check.
+func (ml *ModelLoader) SetWatchdogTimeouts(idle, busy time.Duration) {
+	ml.mu.Lock()
+	defer ml.mu.Unlock()
+	ml.idleTimeout = idle
+	ml.busyTimeout = busy
+}
+
+// setModelTimeouts overrides the watchdog timeouts for a specific model
+func (ml *ModelLoader) setModelTimeouts(s string, idle, busy time.Duration) {
+	ml.mu.Lock()
+	defer ml.mu.Unlock()
+	if idle == 0 && busy == 0 {
+		delete(ml.timeouts, s)
+		return
+	}
+	ml.timeouts[s] = modelTimeouts{idle: idle, busy: busy}
+}
+
+// MarkBusy marks a model as serving a request, until the returned function is called.
+// The request is counted for the backend instance currently loaded: if the backend is stopped
+// and loaded again meanwhile, the end of the request doesn't count for the new instance.
+func (ml *ModelLoader) MarkBusy(s string) (idle func()) {
+	ml.mu.Lock()
+	defer ml.mu.Unlock()
+	if ml.busy[s] == 0 {
+		ml.busySince[s] = time.Now()
+	}
+	ml.busy[s]++
+	ml.lastUsed[s] = time.Now()
+
+	instance := ml.instances[s]
+	var once sync.Once
+	return func() {
+		once.Do(func() { ml.markIdle(s, instance) })
+	}
+}
+
+// markIdle marks a model as done serving a request received by the given backend instance
+func (ml *ModelLoader) markIdle(s string, instance uint64) {
+	ml.mu.Lock()
+	defer ml.mu.Unlock()
+	if ml.instances[s] != instance {
+		// the backend serving the request was stopped, the counts are the ones of another instance
+		return
+	}
+	if ml.busy[s] > 0 {
+		ml.busy[s]--
+	}
+	if ml.busy[s] == 0 {
+		delete(ml.busy, s)
+		delete(ml.busySince, s)
+	}
+	ml.lastUsed[s] = time.Now()
+}
+
+// StartWatchdog starts a goroutine that periodically stops the backends that exceeded
+// their idle or busy timeouts. It returns when the context is canceled.
+func (ml *ModelLoader) StartWatchdog(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(watchdogInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				ml.checkTimeouts()
+			}
+		}
+	}()
+}
+
+func (ml *ModelLoader) checkTimeouts() {
+	ml.mu.Lock()
+	defer ml.mu.Unlock()
+
+	now := time.Now()
+	for m := range ml.models {
+		idle, busy := ml.idleTimeout, ml.busyTimeout
+		if t, ok := ml.timeouts[m]; ok {
+			if t.idle != 0 {
+				idle = t.idle
+			}
+			if t.busy != 0 {
+				busy = t.busy
+			}
+		}
+
+		if since, isBusy := ml.busySince[m]; isBusy {
+			if busy > 0 && now.Sub(since) > busy {
+				log.Warn().Msgf("[watchdog] Model %s busy for more than %s, stopping its backend", m, busy)
+				ml.deleteProcess(m)
+			}
+			continue
+		}
+
+		if idle > 0 && now.Sub(ml.lastUsed[m]) > idle {
+			log.Info().Msgf("[watchdog] Model %s idle for more than %s, stopping its backend", m, idle)
+			ml.deleteProcess(m)
+		}
+	}
+}
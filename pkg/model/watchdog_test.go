@@ -0,0 +1,163 @@
+package model
+
+import (
+	"time"
+
+	grpc "github.com/go-skynet/LocalAI/pkg/grpc"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Watchdog", func() {
+	var ml *ModelLoader
+
+	loader := func(modelName, modelFile string) (*grpc.Client, error) {
+		return grpc.NewClient("127.0.0.1:0"), nil
+	}
+
+	load := func(models ...string) {
+		for _, m := range models {
+			_, err := ml.LoadModel(m, loader)
+			Expect(err).ToNot(HaveOccurred())
+		}
+	}
+
+	// age makes the model look idle, or busy if it is serving a request, for the given time
+	age := func(s string, d time.Duration) {
+		ml.mu.Lock()
+		defer ml.mu.Unlock()
+		ml.lastUsed[s] = time.Now().Add(-d)
+		if _, busy := ml.busySince[s]; busy {
+			ml.busySince[s] = time.Now().Add(-d)
+		}
+	}
+
+	loaded := func() []string {
+		ml.mu.Lock()
+		defer ml.mu.Unlock()
+		res := []string{}
+		for m := range ml.models {
+			res = append(res, m)
+		}
+		return res
+	}
+
+	isBusy := func(s string) bool {
+		ml.mu.Lock()
+		defer ml.mu.Unlock()
+		_, busy := ml.busySince[s]
+		return busy
+	}
+
+	BeforeEach(func() {
+		ml = NewModelLoader(GinkgoT().TempDir())
+	})
+
+	It("counts the requests a model is serving", func() {
+		load("a")
+		age("a", time.Hour)
+
+		idle1 := ml.MarkBusy("a")
+		idle2 := ml.MarkBusy("a")
+		idle1()
+		Expect(isBusy("a")).To(BeTrue())
+		idle2()
+		Expect(isBusy("a")).To(BeFalse())
+
+		// the last request makes the model used recently
+		ml.mu.Lock()
+		Expect(ml.lastUsed["a"]).To(BeTemporally("~", time.Now(), time.Second))
+		ml.mu.Unlock()
+
+		// an extra call doesn't count twice
+		idle3 := ml.MarkBusy("a")
+		ml.MarkBusy("a")
+		idle3()
+		idle3()
+		Expect(isBusy("a")).To(BeTrue())
+	})
+
+	It("doesn't count the requests of a stopped backend for the reloaded one", func() {
+		ml.SetWatchdogTimeouts(0, time.Minute)
+		load("a")
+		stale := ml.MarkBusy("a")
+		age("a", time.Hour)
+		ml.checkTimeouts()
+		Expect(loaded()).To(BeEmpty())
+
+		load("a")
+		idle := ml.MarkBusy("a")
+		// the request to the stopped backend completes after the new one started
+		stale()
+		Expect(isBusy("a")).To(BeTrue())
+		idle()
+		Expect(isBusy("a")).To(BeFalse())
+	})
+
+	It("stops nothing without timeouts", func() {
+		load("a", "b")
+		age("a", time.Hour)
+		ml.MarkBusy("b")
+		age("b", time.Hour)
+
+		ml.checkTimeouts()
+		Expect(loaded()).To(ConsistOf("a", "b"))
+	})
+
+	It("stops the models idle for too long", func() {
+		ml.SetWatchdogTimeouts(time.Minute, 0)
+		load("a", "b", "c")
+		age("a", time.Hour)
+		// a model busy for long is not idle
+		ml.MarkBusy("b")
+		age("b", time.Hour)
+
+		ml.checkTimeouts()
+		Expect(loaded()).To(ConsistOf("b", "c"))
+	})
+
+	It("stops the models busy for too long", func() {
+		ml.SetWatchdogTimeouts(0, time.Minute)
+		load("a", "b", "c")
+		ml.MarkBusy("a")
+		age("a", time.Hour)
+		ml.MarkBusy("b")
+		age("c", time.Hour)
+
+		ml.checkTimeouts()
+		Expect(loaded()).To(ConsistOf("b", "c"))
+		Expect(isBusy("a")).To(BeFalse())
+
+		// the requests to the reloaded model are timed on their own
+		load("a")
+		ml.MarkBusy("a")
+		Expect(isBusy("a")).To(BeTrue())
+		ml.checkTimeouts()
+		Expect(loaded()).To(ConsistOf("a", "b", "c"))
+	})
+
+	It("applies the timeouts of the models over the global ones", func() {
+		ml.SetWatchdogTimeouts(time.Minute, time.Minute)
+		ml.setModelTimeouts("long", 2*time.Hour, 2*time.Hour)
+		ml.setModelTimeouts("short-idle", time.Second, 0)
+		ml.setModelTimeouts("short-busy", 0, time.Second)
+		load("long", "long-busy", "short-idle", "short-busy", "default")
+		ml.setModelTimeouts("long-busy", 2*time.Hour, 2*time.Hour)
+
+		age("long", time.Hour)
+		ml.MarkBusy("long-busy")
+		age("long-busy", time.Hour)
+		age("short-idle", 10*time.Second)
+		ml.MarkBusy("short-busy")
+		age("short-busy", 10*time.Second)
+		age("default", 10*time.Second)
+
+		ml.checkTimeouts()
+		Expect(loaded()).To(ConsistOf("long", "long-busy", "default"))
+
+		// zero timeouts remove the override
+		ml.setModelTimeouts("long", 0, 0)
+		ml.checkTimeouts()
+		Expect(loaded()).To(ConsistOf("long-busy", "default"))
+	})
+})